@@ -17,94 +17,63 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
-	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
-func (h *Handler) handleChannelUnarchive(body []byte) ([]byte, error) {
-	unarchiveEvent := struct {
-		Event struct {
-			Channel string `json:"channel"`
-			User    string `json:"user"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &unarchiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleChannelUnarchive(ctx context.Context, cb *events.Callback) error {
+	unarchiveEvent := events.ChannelEvent{}
+	if err := cb.Decode(&unarchiveEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *unarchived* by <@%s>", unarchiveEvent.Event.Channel, unarchiveEvent.Event.User)
-	return nil, nil
+	h.sendMessage(ctx, "Channel %s was *unarchived* by %s", mrkdwn.Channel(unarchiveEvent.Channel), mrkdwn.User(unarchiveEvent.User))
+	return nil
 }
 
-func (h *Handler) handleChannelRename(body []byte) ([]byte, error) {
-	renameEvent := struct {
-		Event struct {
-			Channel struct {
-				ID      string `json:"id"`
-				Name    string `json:"name"`
-				Created int    `json:"created"`
-			} `json:"channel"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &renameEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleChannelRename(ctx context.Context, cb *events.Callback) error {
+	renameEvent := events.ChannelCreatedEvent{}
+	if err := cb.Decode(&renameEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	channel := renameEvent.Event.Channel
+	channel := renameEvent.Channel
 
-	h.sendMessage("Channel <#%s> was *renamed* to %q", channel.ID, slack.EscapeMessage(channel.Name))
-	return nil, nil
+	h.sendMessage(ctx, "Channel %s was *renamed* to %q", mrkdwn.Channel(channel.ID), channel.Name)
+	return nil
 }
 
-func (h *Handler) handleChannelDeleted(body []byte) ([]byte, error) {
-	unarchiveEvent := struct {
-		Event struct {
-			Channel string `json:"channel"`
-			User    string `json:"user"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &unarchiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleChannelDeleted(ctx context.Context, cb *events.Callback) error {
+	deleteEvent := events.ChannelEvent{}
+	if err := cb.Decode(&deleteEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *deleted*", unarchiveEvent.Event.Channel)
-	return nil, nil
+	h.sendMessage(ctx, "Channel %s was *deleted*", mrkdwn.Channel(deleteEvent.Channel))
+	return nil
 }
 
-func (h *Handler) handleChannelCreated(body []byte) ([]byte, error) {
-	createEvent := struct {
-		Event struct {
-			Channel struct {
-				ID      string `json:"id"`
-				Name    string `json:"name"`
-				Created int    `json:"created"`
-				Creator string `json:"creator"`
-			} `json:"channel"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &createEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleChannelCreated(ctx context.Context, cb *events.Callback) error {
+	createEvent := events.ChannelCreatedEvent{}
+	if err := cb.Decode(&createEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	channel := createEvent.Event.Channel
+	channel := createEvent.Channel
 
-	h.sendMessage("Channel <#%s|%s> was *created* by <@%s>", channel.ID, channel.Name, channel.Creator)
-	return nil, nil
+	h.sendMessage(ctx, "Channel %s was *created* by %s", mrkdwn.ChannelNamed(channel.ID, channel.Name), mrkdwn.User(channel.Creator))
+	return nil
 }
 
-func (h *Handler) handleChannelArchive(body []byte) ([]byte, error) {
-	archiveEvent := struct {
-		Event struct {
-			Channel string `json:"channel"`
-			User    string `json:"user"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &archiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleChannelArchive(ctx context.Context, cb *events.Callback) error {
+	archiveEvent := events.ChannelEvent{}
+	if err := cb.Decode(&archiveEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *archived* by <@%s>", archiveEvent.Event.Channel, archiveEvent.Event.User)
-	return nil, nil
+	h.sendMessage(ctx, "Channel %s was *archived* by %s", mrkdwn.Channel(archiveEvent.Channel), mrkdwn.User(archiveEvent.User))
+	return nil
 }
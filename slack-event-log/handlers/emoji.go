@@ -17,40 +17,35 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strings"
+
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
-func (h *Handler) handleEmojiChanged(body []byte) ([]byte, error) {
-	emojiEvent := struct {
-		Event struct {
-			Subtype string   `json:"subtype"`
-			Name    string   `json:"name,omitempty"`
-			Names   []string `json:"names,omitempty"`
-			Value   string   `json:"value,omitempty"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &emojiEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleEmojiChanged(ctx context.Context, cb *events.Callback) error {
+	emoji := events.EmojiChangedEvent{}
+	if err := cb.Decode(&emoji); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
-	emoji := emojiEvent.Event
 	if emoji.Subtype == "add" {
 		if strings.HasPrefix(emoji.Value, "alias:") {
-			h.sendMessage("A *new emoji alias was added*: `:%s:`. It's an alias for `:%s:`. :%s:", emoji.Name, strings.TrimPrefix(emoji.Value, "alias:"), emoji.Name)
+			h.sendMessage(ctx, "A *new emoji alias was added*: %s. It's an alias for %s. :%s:", mrkdwn.Code(":"+emoji.Name+":"), mrkdwn.Code(":"+strings.TrimPrefix(emoji.Value, "alias:")+":"), emoji.Name)
 		} else {
-			h.sendMessage("A *new emoji was added*: `:%s:` :%s:", emoji.Name, emoji.Name)
+			h.sendMessage(ctx, "A *new emoji was added*: %s :%s:", mrkdwn.Code(":"+emoji.Name+":"), emoji.Name)
 		}
 	} else if emoji.Subtype == "remove" {
 		if len(emoji.Names) == 1 {
-			h.sendMessage("An *emoji was deleted*: `:%s:`", emoji.Names[0])
+			h.sendMessage(ctx, "An *emoji was deleted*: %s", mrkdwn.Code(":"+emoji.Names[0]+":"))
 		} else {
 			var aliases []string
 			for _, a := range emoji.Names {
-				aliases = append(aliases, "`:"+a+":`")
+				aliases = append(aliases, string(mrkdwn.Code(":"+a+":")))
 			}
-			h.sendMessage("An *emoji was deleted*. It had several names: %s", strings.Join(aliases, ", "))
+			h.sendMessage(ctx, "An *emoji was deleted*. It had several names: %s", mrkdwn.Text(strings.Join(aliases, ", ")))
 		}
 	}
-	return nil, nil
+	return nil
 }
@@ -18,122 +18,57 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"context"
 	"log"
 	"net/http"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
-type handlerFunc func(body []byte) ([]byte, error)
-
 // Handler handles Slack events.
 type Handler struct {
+	// Handler serves Slack's requests over HTTP.
+	http.Handler
+	// Router routes Slack's requests to the handler, and can be used with Socket Mode.
+	Router *events.Router
+
 	client *slack.Client
 }
 
 // New returns a new Handler.
 func New(client *slack.Client) *Handler {
-	return &Handler{client: client}
-}
-
-// HandleWebhook can be passed to http.HandlerFunc and will perform all processing associated with
-// Slack webhooks.
-func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Printf(fmt.Sprintf("failed to read body: %v", err))
-		http.Error(w, fmt.Sprintf("failed to read body: %v", err), 500)
-		return
-	}
-	log.Printf("%#v", r.Header)
-	log.Printf(string(body))
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		log.Printf(fmt.Sprintf("signature verification failed: %v", err))
-		http.Error(w, fmt.Sprintf("signature verification failed: %v", err), 403)
-		return
-	}
-
-	response, err := h.HandleMessage(body)
-
-	if err != nil {
-		log.Printf("Handling message failed: %v", err)
-	}
-
-	if response != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(response)
-	}
-}
-
-// HandleMessage handles a Slack webhook that has already been validated.
-func (h *Handler) HandleMessage(body []byte) ([]byte, error) {
-	t := struct {
-		Type string `json:"type"`
-	}{}
-	if err := json.Unmarshal(body, &t); err != nil {
-		return nil, err
-	}
-
-	messageMapping := map[string]handlerFunc{
-		"url_verification": h.handleURLVerification,
-		"event_callback":   h.handleEvent,
-	}
-
-	fn, ok := messageMapping[t.Type]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type %q", t.Type)
-	}
-	output, err := fn(body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", t.Type, err)
-	}
-	return output, nil
+	h := &Handler{client: client}
+	r := events.NewRouter()
+	// Slack retries events we are slow to acknowledge; only post one audit line for each.
+	r.Deduplicate(events.NewMemoryDedupeStore(), 0)
+	r.Event("emoji_changed", h.handleEmojiChanged)
+	r.Event("team_join", h.handleTeamJoin)
+	r.Event("user_change", h.handleUserChange)
+	r.Event("team_rename", h.handleTeamRename)
+	r.Event("team_domain_change", h.handleTeamDomainChange)
+	r.Event("subteam_updated", h.handleSubteamUpdated)
+	r.Event("subteam_created", h.handleSubteamCreated)
+	r.Event("channel_unarchive", h.handleChannelUnarchive)
+	r.Event("channel_rename", h.handleChannelRename)
+	r.Event("channel_deleted", h.handleChannelDeleted)
+	r.Event("channel_created", h.handleChannelCreated)
+	r.Event("channel_archive", h.handleChannelArchive)
+	h.Router = r
+	h.Handler = events.Handler(client, r)
+	return h
 }
 
-func (h *Handler) sendMessage(message string, args ...interface{}) {
-	s := fmt.Sprintf(message, args...)
+// sendMessage formats a message as mrkdwn.Sprintf does, so every argument that isn't
+// mrkdwn.Text is escaped, and sends it.
+func (h *Handler) sendMessage(ctx context.Context, message string, args ...interface{}) {
+	s := string(mrkdwn.Sprintf(message, args...))
 	log.Printf("Sending message: %q", s)
-	if err := h.client.SendMessage(s); err != nil {
+	if err := h.client.SendMessageContext(ctx, s); err != nil {
 		log.Printf("Sending message failed: %v", err)
+		messagesSent.WithLabelValues("error").Inc()
+		return
 	}
-}
-
-func (h *Handler) handleEvent(body []byte) ([]byte, error) {
-	event := struct {
-		Event struct {
-			Type string `json:"type"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &event); err != nil {
-		return nil, fmt.Errorf("error unmarshalling event type: %v", err)
-	}
-	t := event.Event.Type
-
-	eventMapping := map[string]handlerFunc{
-		"emoji_changed":      h.handleEmojiChanged,
-		"team_join":          h.handleTeamJoin,
-		"user_change":        h.handleUserChange,
-		"team_rename":        h.handleTeamRename,
-		"team_domain_change": h.handleTeamDomainChange,
-		"subteam_updated":    h.handleSubteamUpdated,
-		"subteam_created":    h.handleSubteamCreated,
-		"channel_unarchive":  h.handleChannelUnarchive,
-		"channel_rename":     h.handleChannelRename,
-		"channel_deleted":    h.handleChannelDeleted,
-		"channel_created":    h.handleChannelCreated,
-		"channel_archive":    h.handleChannelArchive,
-	}
-
-	fn, ok := eventMapping[t]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type %q", t)
-	}
-	response, err := fn(body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", t, err)
-	}
-	return response, nil
+	messagesSent.WithLabelValues("ok").Inc()
 }
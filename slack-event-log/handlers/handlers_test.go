@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-event-log/handlers"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "shhh"
+
+// spoofName is a name that would mention everyone and impersonate a user if it weren't escaped.
+const spoofName = "<!everyone> <@U99999999|x>"
+
+func TestNamesCantSpoofAuditLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+	}{
+		{
+			name:  "channel_created",
+			event: fmt.Sprintf(`{"type": "channel_created", "channel": {"id": "C00000001", "name": %q, "creator": "U00000001"}}`, spoofName),
+		},
+		{
+			name:  "channel_rename",
+			event: fmt.Sprintf(`{"type": "channel_rename", "channel": {"id": "C00000001", "name": %q}}`, spoofName),
+		},
+		{
+			name:  "subteam_created",
+			event: fmt.Sprintf(`{"type": "subteam_created", "subteam": {"id": "S00000001", "is_usergroup": true, "name": %q, "handle": %q, "created_by": "U00000001"}}`, spoofName, spoofName),
+		},
+		{
+			name:  "subteam_updated",
+			event: fmt.Sprintf(`{"type": "subteam_updated", "subteam": {"id": "S00000001", "is_usergroup": true, "name": %q, "handle": %q, "updated_by": "U00000001"}}`, spoofName, spoofName),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := slacktest.NewServer()
+			defer s.Close()
+			h := handlers.New(s.Client(slack.Config{SigningSecret: testSigningSecret}))
+
+			body := fmt.Sprintf(`{"type": "event_callback", "event_id": "Ev00000001", "event": %s}`, tc.event)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, slacktest.NewEventRequest(testSigningSecret, []byte(body)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			messages := s.WebhookMessages()
+			if len(messages) != 1 {
+				t.Fatalf("Expected one audit line, got %d", len(messages))
+			}
+			text, _ := messages[0]["text"].(string)
+			for _, spoof := range []string{"<!everyone>", "<@U99999999|x>", "everyone>", "|x>"} {
+				if strings.Contains(text, spoof) {
+					t.Errorf("Expected the name to be escaped, but found %q in %q", spoof, text)
+				}
+			}
+			if !strings.Contains(text, "&lt;!everyone&gt;") {
+				t.Errorf("Expected the escaped name in %q", text)
+			}
+		})
+	}
+}
@@ -16,18 +16,13 @@ limitations under the License.
 
 package handlers
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "github.com/prometheus/client_golang/prometheus"
 
-func (h *Handler) handleURLVerification(body []byte) ([]byte, error) {
-	request := struct {
-		Challenge string `json:"challenge"`
-	}{}
-	if err := json.Unmarshal(body, &request); err != nil {
-		return nil, fmt.Errorf("error parsing request: %v", err)
-	}
-	response := map[string]string{"challenge": request.Challenge}
-	return json.Marshal(response)
+var messagesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "slack_event_log_messages_sent_total",
+	Help: "Audit log lines sent to Slack, by outcome (ok or error).",
+}, []string{"outcome"})
+
+func init() {
+	prometheus.MustRegister(messagesSent)
 }
@@ -17,32 +17,26 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack/events"
 )
 
-func (h *Handler) handleTeamRename(body []byte) ([]byte, error) {
-	renameEvent := struct {
-		Event struct {
-			Name string `json:"name"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &renameEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleTeamRename(ctx context.Context, cb *events.Callback) error {
+	renameEvent := events.TeamRenameEvent{}
+	if err := cb.Decode(&renameEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
-	h.sendMessage("The *Slack team was renamed* to %q", renameEvent.Event.Name)
-	return nil, nil
+	h.sendMessage(ctx, "The *Slack team was renamed* to %q", renameEvent.Name)
+	return nil
 }
 
-func (h *Handler) handleTeamDomainChange(body []byte) ([]byte, error) {
-	moveEvent := struct {
-		Event struct {
-			URL string `json:"url"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &moveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleTeamDomainChange(ctx context.Context, cb *events.Callback) error {
+	moveEvent := events.TeamDomainChangeEvent{}
+	if err := cb.Decode(&moveEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
-	h.sendMessage("The *Slack team moved* to %s", moveEvent.Event.URL)
-	return nil, nil
+	h.sendMessage(ctx, "The *Slack team moved* to %s", moveEvent.URL)
+	return nil
 }
@@ -17,44 +17,37 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
-	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
-func (h *Handler) handleTeamJoin(body []byte) ([]byte, error) {
-	userEvent := struct {
-		Event struct {
-			User slack.User `json:"user"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &userEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleTeamJoin(ctx context.Context, cb *events.Callback) error {
+	userEvent := events.UserEvent{}
+	if err := cb.Decode(&userEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	user := userEvent.Event.User
-	displayName := slack.EscapeMessage(user.Profile.DisplayName)
+	user := userEvent.User
+	displayName := mrkdwn.Escape(user.Profile.DisplayName)
 	if displayName == "" {
-		displayName = "_none_"
+		displayName = mrkdwn.Italic("none")
 	}
-	h.sendMessage(fmt.Sprintf("A *new user joined*: <@%s> (display name: %s, real name: %s)", user.ID, displayName, slack.EscapeMessage(user.Profile.RealName)))
-	return nil, nil
+	h.sendMessage(ctx, "A *new user joined*: %s (display name: %s, real name: %s)", mrkdwn.User(user.ID), displayName, user.Profile.RealName)
+	return nil
 }
 
-func (h *Handler) handleUserChange(body []byte) ([]byte, error) {
-	userEvent := struct {
-		Event struct {
-			User slack.User `json:"user"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &userEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+func (h *Handler) handleUserChange(ctx context.Context, cb *events.Callback) error {
+	userEvent := events.UserEvent{}
+	if err := cb.Decode(&userEvent); err != nil {
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	user := userEvent.Event.User
+	user := userEvent.User
 	if user.Deleted {
-		h.sendMessage("A *user was deactivated*: <@%s> (this is heuristic: they are definitely deactivated now, but may also have been before)", user.ID)
+		h.sendMessage(ctx, "A *user was deactivated*: %s (this is heuristic: they are definitely deactivated now, but may also have been before)", mrkdwn.User(user.ID))
 	}
-	return nil, nil
+	return nil
 }
@@ -17,23 +17,29 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-event-log/handlers"
 )
 
 type options struct {
 	configPath string
+	secretsDir string
+	socketMode bool
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.secretsDir, "secrets-dir", "", "Path to a directory of files that override settings in the slack config, each named after the setting's key")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Also receive requests over Socket Mode, using the appToken in the slack config")
 	flag.Parse()
 	return o
 }
@@ -42,11 +48,10 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("ok"))
 }
 
-func runServer(client *slack.Client) {
-	h := handlers.New(client)
-
+func runServer(h *handlers.Handler) {
 	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
 	http.HandleFunc("/healthz", handleHealthz)
+	http.Handle("/metrics", promhttp.Handler())
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -60,10 +65,20 @@ func runServer(client *slack.Client) {
 
 func main() {
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	loader := slack.ConfigLoader{Path: o.configPath, SecretsDir: o.secretsDir, EnvPrefix: "SLACK_"}
+	config, err := slack.WatchConfig(context.Background(), loader, slack.DefaultConfigReloadInterval)
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
 	}
+	c := config.Config()
 	s := slack.New(c)
-	runServer(s)
+	s.ConfigSource = config
+	h := handlers.New(s)
+	if o.socketMode {
+		go func() {
+			sm := &slack.SocketMode{Client: s, Handler: h.Router}
+			log.Fatalf("Socket Mode stopped: %v", sm.Run(context.Background()))
+		}()
+	}
+	runServer(h)
 }
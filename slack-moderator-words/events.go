@@ -17,16 +17,15 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"strings"
 
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/events"
 )
 
 type handler struct {
@@ -34,80 +33,48 @@ type handler struct {
 	filters model.FilterConfig
 }
 
-// ServeHTTP handles Slack webhook requests.
-func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		logError(rw, "Failed to read incoming request body: %v", err)
-		return
-	}
-	defer r.Body.Close()
+// routes returns an http.Handler serving Slack's requests to the bot over HTTP.
+func (h *handler) routes() http.Handler {
+	return events.Handler(h.client, h.router())
+}
 
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		logError(rw, "Failed validation: %v", err)
-		return
-	}
+// router returns a Router serving Slack's requests to the bot over either HTTP or Socket Mode.
+func (h *handler) router() *events.Router {
+	r := events.NewRouter()
+	r.Deduplicate(events.NewMemoryDedupeStore(), 0)
+	r.Event("channel_created", h.handleChannelCreated)
+	r.Event("message", h.handleMessage)
+	return r
+}
 
-	event := &model.SlackEvent{}
-	err = json.NewDecoder(bytes.NewReader(body)).Decode(event)
-	if err != nil {
-		logError(rw, "Failed to unmarshal payload: %v", err)
-		panic(err)
+// Triggered when is a new channel created
+// and the bot will join to the channel
+// Slack Event needed for this: channel_created
+func (h *handler) handleChannelCreated(ctx context.Context, cb *events.Callback) error {
+	event := events.ChannelCreatedEvent{}
+	if err := cb.Decode(&event); err != nil {
+		return fmt.Errorf("failed to decode event channel: %v", err)
 	}
+	channelCreated := event.Channel
 
-	// This is used for the first time when configuring the slack events
-	if event.Type == "url_verification" {
-		resp := &model.Challenge{}
-		resp.Challenge = event.Challenge
-		challengeJson, err := json.Marshal(resp)
-		if err != nil {
-			logError(rw, "Failed to marshal challenge payload: %v", err)
-		}
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusOK)
-		_, _ = rw.Write(challengeJson)
-		return
+	log.Printf("New public channels: %s/%s\n", channelCreated.ID, channelCreated.Name)
+	if err := h.client.JoinConversation(ctx, channelCreated.ID); err != nil {
+		return fmt.Errorf("failed to join channel %s: %v", channelCreated.Name, err)
 	}
+	return nil
+}
 
-	// Triggered when is a new channel created
-	// and the bot will join to the channel
-	// Slack Event needed for this: channel_created
-	if event.Event.Type == "channel_created" {
-		b, err := json.Marshal(event.Event.Channel)
-		if err != nil {
-			panic(err)
-		}
-		channelCreated := &model.Channel{}
-		err = json.Unmarshal(b, channelCreated)
-		if err != nil {
-			log.Fatalf("Failed to decode event channel: %v", err)
-		}
-
-		log.Printf("New public channels: %s/%s\n", channelCreated.ID, channelCreated.Name)
-		req := map[string]interface{}{
-			"channel": channelCreated.ID,
-		}
-		err = h.client.CallMethod("conversations.join", req, nil)
-		if err != nil {
-			log.Fatalf("Failed to join channel %s: %v", channelCreated.Name, err)
-		}
-
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusOK)
-		_, _ = rw.Write([]byte(""))
-		return
+// When is a message from the channels the bot is listening
+// Slack Event needed for this: message.channels
+func (h *handler) handleMessage(ctx context.Context, cb *events.Callback) error {
+	event := events.MessageEvent{}
+	if err := cb.Decode(&event); err != nil {
+		return fmt.Errorf("failed to decode message: %v", err)
 	}
 
-	// When is a message from the channels the bot is listening
-	// Slack Event needed for this: message.channels
-	// reply ok rigth away
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusOK)
-	_, _ = rw.Write([]byte(""))
-
 	// If come from Bot just ignore and not moderate
-	if event.Event.BotID != "" {
-		return
+	if event.BotID != "" {
+		return nil
 	}
 
 	if h.filters != nil {
@@ -115,26 +82,29 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		// Control if we will log the full event
 		matched := false
 
+		// Only match what the author wrote themselves, not code, quotes, links or mentions.
+		text := slack.PlainText(slack.ParseMessage(event.Text, event.Blocks))
+
 		for _, filter := range h.filters {
 			for _, word := range filter.Triggers {
-				if strings.Contains(event.Event.Text, word) {
+				if strings.Contains(text, word) {
 
 					matched = true
+					filterMatches.WithLabelValues(word).Inc()
 					log.Printf("[MATCH] Filter word '%s' found in event text, logging enabled for full event.", word)
 
 					req := map[string]interface{}{
-						"channel": event.Event.Channel,
-						"user":    event.Event.User,
+						"channel": event.Channel,
+						"user":    event.User,
 						"text":    filter.Message,
 					}
 
-					if event.Event.ThreadTS != "" {
-						req["thread_ts"] = event.Event.ThreadTS
+					if event.ThreadTS != "" {
+						req["thread_ts"] = event.ThreadTS
 					}
 
-					err = h.client.CallMethod(filter.Action, req, nil)
-					if err != nil {
-						logError(rw, "Failed send message to slack: %v", err)
+					if err := h.client.CallMethodContext(ctx, filter.Action, req, nil); err != nil {
+						log.Printf("Failed send message to slack: %v", err)
 					}
 				}
 			}
@@ -142,13 +112,10 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 
 		// Only log events that match one or more filters
 		if matched {
+			// The raw blocks would be logged as bytes.
+			event.Blocks = nil
 			log.Printf("[EVENT] %+v", event)
 		}
 	}
-}
-
-func logError(rw http.ResponseWriter, format string, args ...interface{}) {
-	s := fmt.Sprintf(format, args...)
-	log.Println(s)
-	http.Error(rw, s, 500)
+	return nil
 }
@@ -2,11 +2,16 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
 )
 
 func TestEventsHandler(t *testing.T) {
@@ -36,7 +41,7 @@ func TestEventsHandler(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	h := &handler{client: nil, filters: nil}
-	handler := http.Handler(h)
+	handler := h.routes()
 
 	// TODO: this is a failing test when the slack headers does not match
 	// rewrite to pass a fake and make a happy path :)
@@ -44,8 +49,36 @@ func TestEventsHandler(t *testing.T) {
 	req.Header.Add("X-Slack-Request-Timestamp", time.Now().String())
 	handler.ServeHTTP(rr, req)
 
-	if status := rr.Code; status != http.StatusInternalServerError {
+	if status := rr.Code; status != http.StatusForbidden {
 		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusOK)
+			status, http.StatusForbidden)
+	}
+}
+
+func TestEventsHandlerPostsFilterMessage(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "someone"})
+	channel := s.AddConversation(slack.Conversation{Name: "general", IsMember: true}, user)
+
+	filters := model.FilterConfig{{Triggers: []string{"guys"}, Action: "chat.postEphemeral", Message: "Try \"all\"?"}}
+	h := &handler{client: s.Client(slack.Config{SigningSecret: "secret"}), filters: filters}
+
+	// Only the first message should match: the filter ignores code, quotes and links.
+	for _, text := range []string{"hi guys", "hi all", "run `guys --help`", "&gt; hi guys", "see <https://example.com/guys|this>"} {
+		body := fmt.Sprintf(`{"type": "event_callback", "event": {"type": "message", "user": %q, "channel": %q, "text": %q, "ts": "1612790186.002000"}}`, user, channel, text)
+		rr := httptest.NewRecorder()
+		h.routes().ServeHTTP(rr, slacktest.NewEventRequest("secret", []byte(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	}
+
+	calls := s.CallsTo("chat.postEphemeral")
+	if len(calls) != 1 {
+		t.Fatalf("Expected one ephemeral message, got %d", len(calls))
+	}
+	if calls[0].Arg("user") != user || calls[0].Arg("channel") != channel || calls[0].Arg("text") != "Try \"all\"?" {
+		t.Errorf("Unexpected ephemeral message: %#v", calls[0].Params)
 	}
 }
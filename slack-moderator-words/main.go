@@ -17,36 +17,48 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/oauth"
 )
 
 type options struct {
-	configPath       string
-	filterConfigPath string
+	configPath        string
+	secretsDir        string
+	filterConfigPath  string
+	installationsPath string
+	socketMode        bool
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.secretsDir, "secrets-dir", "", "Path to a directory of files that override settings in the slack config, each named after the setting's key")
 	flag.StringVar(&o.filterConfigPath, "filter-config-path", "filters.yaml", "Path to a file containing the filter config")
+	flag.StringVar(&o.installationsPath, "installations-path", "", "Path to a file of OAuth installations. If set, the bot can be installed in other workspaces at /install, using the clientId and clientSecret in the slack config")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Also receive requests over Socket Mode, using the appToken in the slack config")
 	flag.Parse()
 	return o
 }
 
-func runServer(h *handler) error {
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+func runServer(h *handler, installer http.Handler) error {
+	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h.routes())
+	if installer != nil {
+		http.Handle(os.Getenv("PATH_PREFIX")+"/install", installer)
+	}
 	http.HandleFunc("/healthz", handleHealthz)
+	http.Handle("/metrics", promhttp.Handler())
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -73,10 +85,12 @@ func loadFilterConfig(path string) (model.FilterConfig, error) {
 
 func main() {
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	loader := slack.ConfigLoader{Path: o.configPath, SecretsDir: o.secretsDir, EnvPrefix: "SLACK_"}
+	config, err := slack.WatchConfig(context.Background(), loader, slack.DefaultConfigReloadInterval)
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
 	}
+	c := config.Config()
 
 	filters, err := loadFilterConfig(o.filterConfigPath)
 	if err != nil {
@@ -84,6 +98,16 @@ func main() {
 	}
 
 	s := slack.New(c)
+	s.ConfigSource = config
+	var installer http.Handler
+	if o.installationsPath != "" {
+		if c.ClientID == "" || c.ClientSecret == "" {
+			log.Fatalf("clientId and clientSecret are required to use --installations-path but were not found in %s", o.configPath)
+		}
+		store := oauth.NewFileStore(o.installationsPath)
+		s.Tokens = oauth.Tokens(store, s)
+		installer = &oauth.InstallHandler{Client: s, Store: store, Scopes: []string{"channels:history", "channels:join", "channels:read", "chat:write", "chat:write.public"}}
+	}
 
 	// List all public channels and try to join.
 	// This is needed otherwise the bot cannot receive the events for the channels
@@ -106,26 +130,17 @@ func main() {
 			continue
 		}
 
-		for {
-			req := map[string]string{
-				"channel": channel.ID,
-			}
-			err := s.CallMethod("conversations.join", req, nil)
-			if err == nil {
-				break
-			}
-			if timeout, ok := err.(slack.ErrRateLimit); ok {
-				log.Printf("Slack is rate limiting us, trying again in %s...\n", timeout.Wait)
-				time.Sleep(timeout.Wait)
-				continue
-			}
-			if err != nil {
-				log.Fatalf("Failed to join channel %s: %v", channel.Name, err)
-			}
+		if err := s.JoinConversation(context.Background(), channel.ID); err != nil {
+			log.Fatalf("Failed to join channel %s: %v", channel.Name, err)
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	h := &handler{client: s, filters: filters}
-	log.Fatal(runServer(h))
+	if o.socketMode {
+		go func() {
+			sm := &slack.SocketMode{Client: s, Handler: h.router()}
+			log.Fatalf("Socket Mode stopped: %v", sm.Run(context.Background()))
+		}()
+	}
+	log.Fatal(runServer(h, installer))
 }
@@ -16,41 +16,6 @@ limitations under the License.
 
 package model
 
-type Challenge struct {
-	Challenge string `json:"challenge"`
-}
-
-type SlackEvent struct {
-	Token       string   `json:"token"`
-	TeamID      string   `json:"team_id"`
-	APIAppID    string   `json:"api_app_id"`
-	Event       Event    `json:"event"`
-	Type        string   `json:"type"`
-	AuthedTeams []string `json:"authed_teams"`
-	EventID     string   `json:"event_id"`
-	EventTime   int      `json:"event_time"`
-	Challenge   string   `json:"challenge,omitempty"`
-}
-
-type Event struct {
-	Type        string      `json:"type"`
-	Channel     interface{} `json:"channel"`
-	User        string      `json:"user"`
-	Text        string      `json:"text"`
-	TS          string      `json:"ts"`
-	EventTS     string      `json:"event_ts"`
-	ThreadTS    string      `json:"thread_ts"`
-	ChannelType string      `json:"channel_type"`
-	BotID       string      `json:"bot_id"`
-}
-
-type Channel struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Creator string `json:"creator"`
-	Created int    `json:"created"`
-}
-
 type FilterConfig []struct {
 	Triggers []string `yaml:"triggers"`
 	Action   string   `yaml:"action"`
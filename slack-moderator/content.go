@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -27,7 +29,9 @@ import (
 	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *handler) removeUserContent(interaction slackInteraction, duration time.Duration, targetUser string) (removedFiles, remainingFiles, removedMessages, remainingMessages int, err error) {
+// removeUserContent removes the content targetUser posted in the last duration. If channel is set,
+// only content in that channel is removed.
+func (h *handler) removeUserContent(ctx context.Context, duration time.Duration, targetUser, channel string) (removedFiles, remainingFiles, removedMessages, remainingMessages int, err error) {
 	start := time.Now().Add(-duration)
 
 	wg := sync.WaitGroup{}
@@ -35,7 +39,7 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 	go func() {
 		defer wg.Done()
 		var err error
-		removedFiles, remainingFiles, err = h.removeFilesFromUser(targetUser, start)
+		removedFiles, remainingFiles, err = h.removeFilesFromUser(ctx, targetUser, channel, start)
 		if err != nil {
 			log.Printf("Couldn't remove files: %v", err)
 		}
@@ -43,7 +47,7 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 	go func() {
 		defer wg.Done()
 		var err error
-		removedMessages, remainingMessages, err = h.removeMessagesFromUser(targetUser, start)
+		removedMessages, remainingMessages, err = h.removeMessagesFromUser(ctx, targetUser, channel, start)
 		if err != nil {
 			log.Printf("Couldn't remove messages: %v", err)
 		}
@@ -54,27 +58,17 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 	return
 }
 
-func (h *handler) removeFilesFromUser(targetUser string, since time.Time) (removed, remaining int, err error) {
-	page := 1
-	var files []string
-	for {
-		f, hasMore, err := h.searchForFiles(targetUser, since, page)
-		if err != nil {
-			if len(files) == 0 {
-				return 0, 0, err
-			}
-			log.Printf("Failed to fetch more files (already got %d): %v\n", len(files), err)
-			break
+func (h *handler) removeFilesFromUser(ctx context.Context, targetUser, channel string, since time.Time) (removed, remaining int, err error) {
+	files, err := h.searchForFiles(ctx, targetUser, channel, since)
+	if err != nil {
+		if len(files) == 0 {
+			return 0, 0, err
 		}
-		files = append(files, f...)
-		if !hasMore {
-			break
-		}
-		page++
+		log.Printf("Failed to fetch more files (already got %d): %v\n", len(files), err)
 	}
 	log.Printf("Got %d files to remove...\n", len(files))
 	for _, v := range files {
-		if err := h.removeFile(v); err != nil {
+		if err := h.removeFile(ctx, v); err != nil {
 			log.Printf("Failed to remove file %s: %v\n", v, err)
 			remaining++
 		} else {
@@ -84,63 +78,88 @@ func (h *handler) removeFilesFromUser(targetUser string, since time.Time) (remov
 	return removed, remaining, nil
 }
 
-func (h *handler) removeFile(id string) error {
+func (h *handler) removeFile(ctx context.Context, id string) error {
 	log.Printf("Removing file %s\n", id)
 
-	for {
-		err := h.client.CallMethod("files.delete", map[string]string{"file": id}, nil)
-		if err == nil {
-			return nil
-		}
-		if timeout, ok := err.(slack.ErrRateLimit); ok {
-			log.Printf("Slack is rate limiting us, trying again in %s...\n", timeout.Wait)
-			time.Sleep(timeout.Wait)
-			continue
-		}
+	err := slack.Retry(ctx, removalRetries, removalRetryDelay, func() error {
+		return h.client.DeleteFile(ctx, id)
+	})
+	if errors.Is(err, slack.ErrFileNotFound) || errors.Is(err, slack.ErrFileDeleted) {
+		log.Printf("File to delete not found, probably already deleted.\n")
+		return nil
+	}
+	if err != nil {
 		return err
 	}
+	contentRemoved.WithLabelValues("file").Inc()
+	return nil
 }
 
-func (h *handler) searchForFiles(targetUser string, since time.Time, page int) ([]string, bool, error) {
-	args := map[string]string{
-		"query":    fmt.Sprintf("from:<@%s> after:%s", targetUser, dateBefore(since)),
-		"count":    "100",
+// removalRetries is how many more times to try removing something after Slack fails in a way
+// that might not happen again.
+const removalRetries = 2
+
+// removalRetryDelay is how long to wait before trying again.
+var removalRetryDelay = 2 * time.Second
+
+// searchArgs returns the arguments to search for content from targetUser since the given time,
+// newest first. If channel is set, only that channel is searched.
+func searchArgs(targetUser, channel string, since time.Time) map[string]string {
+	query := fmt.Sprintf("from:<@%s> after:%s", targetUser, dateBefore(since))
+	if channel != "" {
+		query += fmt.Sprintf(" in:<#%s>", channel)
+	}
+	return map[string]string{
+		"query":    query,
 		"sort":     "timestamp",
 		"sort_dir": "desc",
-		"page":     strconv.Itoa(page),
 	}
-	log.Printf("Searching files for %q", args["query"])
+}
+
+type fileMatch struct {
+	ID       string   `json:"id"`
+	Created  int64    `json:"created"`
+	User     string   `json:"user"`
+	Channels []string `json:"channels"`
+	Groups   []string `json:"groups"`
+	IMs      []string `json:"ims"`
+}
 
-	result := struct {
-		Files struct {
-			Matches []struct {
-				ID      string `json:"id"`
-				Created int64  `json:"created"`
-				User    string `json:"user"`
-			} `json:"matches"`
-			Pagination struct {
-				PageCount int `json:"page_count"`
-			} `json:"pagination"`
-		} `json:"files"`
-	}{}
+// onlyIn returns true if the file is shared in the given conversation and nowhere else.
+func (f fileMatch) onlyIn(channel string) bool {
+	shares := append(append(append([]string{}, f.Channels...), f.Groups...), f.IMs...)
+	return len(shares) == 1 && shares[0] == channel
+}
 
-	if err := h.client.CallOldMethod("search.files", args, &result); err != nil {
-		return nil, false, fmt.Errorf("failed to find files: %v", err)
-	}
+// searchForFiles returns the IDs of files targetUser has shared since the given time. If channel
+// is set, only files shared in that channel and nowhere else are returned, because removing a file
+// removes it everywhere. If it fails partway through, it returns the files it found so far along
+// with the error.
+func (h *handler) searchForFiles(ctx context.Context, targetUser, channel string, since time.Time) ([]string, error) {
+	args := searchArgs(targetUser, channel, since)
+	log.Printf("Searching files for %q", args["query"])
+	pager := slack.Pager[fileMatch]{Client: h.client, Method: "search.files", Args: args, Field: "files.matches", Pages: true, PageSize: 100}
 
-	files := make([]string, 0, len(result.Files.Matches))
-	for _, v := range result.Files.Matches {
+	var files []string
+	for v, err := range pager.All(ctx) {
+		if err != nil {
+			return files, fmt.Errorf("failed to find files: %v", err)
+		}
 		if v.User != targetUser {
 			log.Printf("Got unexpected file %s from user %s instead of target user %s", v.ID, v.User, targetUser)
 			continue
 		}
+		if channel != "" && !v.onlyIn(channel) {
+			log.Printf("Not removing file %s, which is shared outside %s", v.ID, channel)
+			continue
+		}
 		if time.Unix(v.Created, 0).Before(since) {
 			log.Printf("Got unexpected file %s created at %s, which is before %s", v.ID, time.Unix(v.Created, 0), since)
 			break
 		}
 		files = append(files, v.ID)
 	}
-	return files, result.Files.Pagination.PageCount > page, nil
+	return files, nil
 }
 
 type messageID struct {
@@ -148,27 +167,17 @@ type messageID struct {
 	channel string
 }
 
-func (h *handler) removeMessagesFromUser(targetUser string, since time.Time) (removed, remaining int, err error) {
-	page := 1
-	var messages []messageID
-	for {
-		m, hasMore, err := h.searchForMessages(targetUser, since, page)
-		if err != nil {
-			if len(messages) == 0 {
-				return 0, 0, err
-			}
-			log.Printf("Failed to fetch more messages (already got %d): %v\n", len(messages), err)
-			break
+func (h *handler) removeMessagesFromUser(ctx context.Context, targetUser, channel string, since time.Time) (removed, remaining int, err error) {
+	messages, err := h.searchForMessages(ctx, targetUser, channel, since)
+	if err != nil {
+		if len(messages) == 0 {
+			return 0, 0, err
 		}
-		messages = append(messages, m...)
-		if !hasMore {
-			break
-		}
-		page++
+		log.Printf("Failed to fetch more messages (already got %d): %v\n", len(messages), err)
 	}
 	log.Printf("Got %d messages to remove...\n", len(messages))
 	for _, v := range messages {
-		if err := h.removeMessage(v); err != nil {
+		if err := h.removeMessage(ctx, v); err != nil {
 			log.Printf("Failed to remove message %s: %v\n", v, err)
 			remaining++
 		} else {
@@ -178,34 +187,21 @@ func (h *handler) removeMessagesFromUser(targetUser string, since time.Time) (re
 	return removed, remaining, nil
 }
 
-func (h *handler) removeMessage(message messageID) error {
-	req := map[string]interface{}{
-		"channel": message.channel,
-		"ts":      message.ts,
-		"as_user": true,
-	}
-
+func (h *handler) removeMessage(ctx context.Context, message messageID) error {
 	log.Printf("Removing message %s\n", message)
 
-	for {
-		err := h.client.CallMethod("chat.delete", req, nil)
-		if err == nil {
-			return nil
-		}
-		switch e := err.(type) {
-		case slack.ErrRateLimit:
-			log.Printf("Slack is rate limiting us, trying again in %s...\n", e.Wait)
-			time.Sleep(e.Wait)
-		case slack.ErrSlack:
-			if e.Type == "message_not_found" {
-				log.Printf("Message to delete not found, probably already deleted.\n")
-				return nil
-			}
-			return err
-		default:
-			return err
-		}
+	err := slack.Retry(ctx, removalRetries, removalRetryDelay, func() error {
+		return h.client.DeleteMessage(ctx, message.channel, message.ts)
+	})
+	if errors.Is(err, slack.ErrMessageNotFound) {
+		log.Printf("Message to delete not found, probably already deleted.\n")
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+	contentRemoved.WithLabelValues("message").Inc()
+	return nil
 }
 
 // Because slack search can only search for messages *after* a specific date
@@ -214,41 +210,35 @@ func dateBefore(when time.Time) string {
 	return when.Add(-2 * 24 * time.Hour).Format("2006-01-02")
 }
 
-func (h *handler) searchForMessages(targetUser string, since time.Time, page int) ([]messageID, bool, error) {
-	args := map[string]string{
-		"query":    fmt.Sprintf("from:<@%s> after:%s", targetUser, dateBefore(since)),
-		"count":    "100",
-		"sort":     "timestamp",
-		"sort_dir": "desc",
-		"page":     strconv.Itoa(page),
-	}
-	log.Printf("Searching messages for %q", args["query"])
-
-	result := struct {
-		Messages struct {
-			Matches []struct {
-				Channel struct {
-					ID string `json:"id"`
-				} `json:"channel"`
-				TS   string `json:"ts"`
-				User string `json:"user"`
-			} `json:"matches"`
-			Pagination struct {
-				PageCount int `json:"page_count"`
-			} `json:"pagination"`
-		} `json:"messages"`
-	}{}
+type messageMatch struct {
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	TS   string `json:"ts"`
+	User string `json:"user"`
+}
 
-	if err := h.client.CallOldMethod("search.messages", args, &result); err != nil {
-		return nil, false, fmt.Errorf("failed to find messages: %v", err)
-	}
+// searchForMessages returns the messages targetUser has sent since the given time, in channel if it
+// is set. If it fails partway through, it returns the messages it found so far along with the
+// error.
+func (h *handler) searchForMessages(ctx context.Context, targetUser, channel string, since time.Time) ([]messageID, error) {
+	args := searchArgs(targetUser, channel, since)
+	log.Printf("Searching messages for %q", args["query"])
+	pager := slack.Pager[messageMatch]{Client: h.client, Method: "search.messages", Args: args, Field: "messages.matches", Pages: true, PageSize: 100}
 
-	messages := make([]messageID, 0, len(result.Messages.Matches))
-	for _, v := range result.Messages.Matches {
+	var messages []messageID
+	for v, err := range pager.All(ctx) {
+		if err != nil {
+			return messages, fmt.Errorf("failed to find messages: %v", err)
+		}
 		if v.User != targetUser {
 			log.Printf("Unexpected message %s/%s from user %s, not target user %s\n", v.Channel, v.TS, v.User, targetUser)
 			continue
 		}
+		if channel != "" && v.Channel.ID != channel {
+			log.Printf("Unexpected message %s/%s outside channel %s\n", v.Channel.ID, v.TS, channel)
+			continue
+		}
 		ts := strings.SplitN(v.TS, ".", 2)[0]
 		t, err := strconv.ParseInt(ts, 10, 64)
 		if err != nil {
@@ -264,5 +254,5 @@ func (h *handler) searchForMessages(targetUser string, since time.Time, page int
 			channel: v.Channel.ID,
 		})
 	}
-	return messages, result.Messages.Pagination.PageCount > page, nil
+	return messages, nil
 }
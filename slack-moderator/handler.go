@@ -17,95 +17,97 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
+	"context"
 	"net/http"
-	"net/url"
+	"sync"
+	"time"
+
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/moderators"
+	"sigs.k8s.io/slack-infra/slack/report"
+)
+
+const (
+	// requestTimeout is how long we spend on Slack calls while handling a request. Slack gives
+	// up waiting for our response after three seconds, so there's no point going on any longer.
+	requestTimeout = 3 * time.Second
+	// moderationTimeout is how long moderation actions may run in the background.
+	moderationTimeout = 30 * time.Minute
 )
 
 type handler struct {
-	client     *slack.Client
-	adminToken string
+	client *slack.Client
+	// users caches the users of the workspace, so checking moderation powers is cheap.
+	users *slack.Directory
+	// moderators lists the channel moderators exported by Tempelis. If nil, only workspace admins
+	// can moderate.
+	moderators *moderators.Watcher
+
+	// ctx is the parent of any work that outlives a request; it is cancelled when the server is
+	// shutting down. If nil, background work is never cancelled.
+	ctx context.Context
+	// background tracks work that outlives a request, so shutdown can wait for it.
+	background sync.WaitGroup
 }
 
-// ServeHTTP handles Slack webhook requests.
-func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		logError(rw, "Failed to read incoming request body: %v", err)
-		return
-	}
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		logError(rw, "Failed validation: %v", err)
-		return
-	}
-	f, err := url.ParseQuery(string(body))
-	if err != nil {
-		logError(rw, "Failed to parse incoming content: %v", err)
-		return
-	}
-	content := f.Get("payload")
-	if content == "" {
-		logError(rw, "Payload was blank.")
-		return
-	}
-	interaction := slackInteraction{}
-	if err := json.Unmarshal([]byte(content), &interaction); err != nil {
-		logError(rw, "Failed to unmarshal payload: %v", err)
-		return
-	}
-	if interaction.Type == "message_action" && interaction.CallbackID == "report_message" {
-		if isMod, err := h.userHasModerationPowers(interaction.User.ID); err == nil && isMod {
-			h.handleModerateMessage(interaction, rw)
-		} else {
-			h.handleReportMessage(interaction, rw)
-		}
-	} else if interaction.Type == "dialog_submission" {
-		switch interaction.CallbackID {
-		case "send_report":
-			h.handleReportSubmission(interaction, rw)
-		case "moderate_user":
-			// Spin this off because it takes longer than Slack is willing to wait for a response.
-			go h.handleModerateSubmission(interaction)
-		}
+// routes returns an http.Handler serving Slack's requests to the bot over HTTP.
+func (h *handler) routes() http.Handler {
+	return events.Handler(h.client, h.router())
+}
+
+// router returns a Router serving Slack's requests to the bot over either HTTP or Socket Mode.
+func (h *handler) router() *events.Router {
+	r := events.NewRouter()
+	r.Timeout(requestTimeout)
+	r.UpdateDirectory(h.users)
+	r.MessageShortcut("report_message", h.handleMessageShortcut)
+	r.ViewSubmission(report.CallbackID, h.reporter().HandleSubmission)
+	r.ViewSubmission("moderate_user", h.handleModerateSubmission)
+	return r
+}
+
+// reporter returns a Reporter that sends reports to the moderators.
+func (h *handler) reporter() *report.Reporter {
+	return &report.Reporter{
+		Client: h.client,
+		Users:  h.users,
+		Filed:  func(category string) { reportsFiled.WithLabelValues(category).Inc() },
 	}
 }
 
-func logError(rw http.ResponseWriter, format string, args ...interface{}) {
-	s := fmt.Sprintf(format, args...)
-	log.Println(s)
-	http.Error(rw, s, 500)
+// handleMessageShortcut lets moderators of the message's channel moderate its author, and anyone
+// else report it.
+func (h *handler) handleMessageShortcut(ctx context.Context, interaction *events.Interaction) error {
+	if p, err := h.moderationPowers(ctx, interaction.User.ID, interaction.Channel.ID); err == nil && p != noPowers {
+		return h.handleModerateMessage(ctx, interaction, p)
+	}
+	return h.reporter().OpenModal(ctx, interaction)
 }
 
-type slackInteraction struct {
-	Token       string `json:"token"`
-	CallbackID  string `json:"callback_id"`
-	Type        string `json:"type"`
-	TriggerID   string `json:"trigger_id"`
-	ResponseURL string `json:"response_url"`
-	Team        struct {
-		ID     string `json:"id"`
-		Domain string `json:"string"`
-	} `json:"team"`
-	Channel struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"channel"`
-	User struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"user"`
-	Message struct {
-		Type      string `json:"type"`
-		User      string `json:"user"`
-		Timestamp string `json:"ts"`
-		Text      string `json:"text"`
+// runInBackground runs f in a new goroutine with a context that is cancelled after timeout, or
+// when the server shuts down. The context keeps the team from the request's context, so that f
+// calls Slack with the same token.
+func (h *handler) runInBackground(ctx context.Context, timeout time.Duration, f func(ctx context.Context)) {
+	parent := h.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if team, ok := slack.TeamFromContext(ctx); ok {
+		parent = slack.WithTeam(parent, team)
 	}
-	Submission map[string]string `json:"submission"`
-	State      string            `json:"state"`
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+		f(ctx)
+	}()
+}
+
+// wait blocks until all background work has finished.
+func (h *handler) wait() {
+	h.background.Wait()
 }
 
 // shortenString returns the first N slice of a string.
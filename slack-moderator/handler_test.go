@@ -0,0 +1,363 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+	"sigs.k8s.io/slack-infra/slack/moderators"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "shhh"
+
+func sendInteraction(t *testing.T, h *handler, interaction map[string]interface{}) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(interaction)
+	if err != nil {
+		t.Fatalf("Failed to marshal interaction: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.routes().ServeHTTP(rr, slacktest.NewInteractionRequest(testSigningSecret, payload))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+func TestMessageActionOpensModalByRole(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	reporter := s.AddUser(slack.User{Name: "reporter"})
+	admin := s.AddUser(slack.User{Name: "admin", IsAdmin: true})
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	c := s.Client(slack.Config{SigningSecret: testSigningSecret, AdminToken: "xoxp-admin"})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0)}
+
+	for _, user := range []string{reporter, admin} {
+		sendInteraction(t, h, map[string]interface{}{
+			"type":        "message_action",
+			"callback_id": "report_message",
+			"trigger_id":  "trigger",
+			"user":        map[string]string{"id": user},
+			"channel":     map[string]string{"id": "C00000001", "name": "general"},
+			"message":     map[string]string{"user": spammer, "ts": "1.000001", "text": "spam"},
+		})
+	}
+
+	views := s.CallsTo("views.open")
+	if len(views) != 2 {
+		t.Fatalf("Expected two modals to be opened, got %d", len(views))
+	}
+	if !strings.Contains(views[0].Arg("view"), `"callback_id":"send_report"`) {
+		t.Errorf("Expected a regular user to get the report modal, got %s", views[0].Arg("view"))
+	}
+	if !strings.Contains(views[1].Arg("view"), `"callback_id":"moderate_user"`) {
+		t.Errorf("Expected an admin to get the moderation modal, got %s", views[1].Arg("view"))
+	}
+}
+
+// openReport opens the report modal for a message from sender, as reporter, who mustn't be a
+// moderator, and returns its private_metadata.
+func openReport(t *testing.T, s *slacktest.Server, h *handler, reporter, channel, sender, ts, text string) string {
+	views := len(s.CallsTo("views.open"))
+	sendInteraction(t, h, map[string]interface{}{
+		"type":        "message_action",
+		"callback_id": "report_message",
+		"trigger_id":  "trigger",
+		"user":        map[string]string{"id": reporter},
+		"channel":     map[string]string{"id": channel, "name": "general"},
+		"message":     map[string]string{"user": sender, "ts": ts, "text": text},
+	})
+	calls := s.CallsTo("views.open")
+	if len(calls) != views+1 {
+		t.Fatalf("Expected the report modal to be opened, got %d calls", len(calls)-views)
+	}
+	view := struct {
+		PrivateMetadata string `json:"private_metadata"`
+	}{}
+	if err := json.Unmarshal([]byte(calls[len(calls)-1].Arg("view")), &view); err != nil {
+		t.Fatalf("Failed to parse view: %v", err)
+	}
+	return view.PrivateMetadata
+}
+
+func reportSubmission(reporter, metadata, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": reporter, "name": "reporter"},
+		"view": map[string]interface{}{
+			"callback_id":      "send_report",
+			"private_metadata": metadata,
+			"state": map[string]interface{}{"values": map[string]interface{}{
+				"category":  map[string]interface{}{"category": map[string]interface{}{"type": "radio_buttons", "selected_option": map[string]interface{}{"value": "spam"}}},
+				"message":   map[string]interface{}{"message": map[string]interface{}{"type": "plain_text_input", "value": reason}},
+				"anonymous": map[string]interface{}{"anonymous": map[string]interface{}{"type": "radio_buttons", "selected_option": map[string]interface{}{"value": "yes"}}},
+			}},
+		},
+	}
+}
+
+func TestReportSubmissionSendsReport(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	reporter := s.AddUser(slack.User{Name: "reporter"})
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	channel := s.AddConversation(slack.Conversation{Name: "general"})
+	ts := s.AddMessage(channel, slacktest.Message{User: spammer, Text: "spam"})
+	c := s.Client(slack.Config{SigningSecret: testSigningSecret})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0)}
+
+	metadata := openReport(t, s, h, reporter, channel, spammer, ts, "spam")
+	rr := sendInteraction(t, h, reportSubmission(reporter, metadata, "this is spam"))
+
+	reports := s.WebhookMessages()
+	if len(reports) != 1 {
+		t.Fatalf("Expected one report, got %d", len(reports))
+	}
+	if text, _ := reports[0]["text"].(string); !strings.HasPrefix(text, "An anonymous user") || !strings.Contains(text, "*Spam*") {
+		t.Errorf("Expected an anonymous spam report, got %q", text)
+	}
+	if !strings.Contains(slacktest.Call{Params: reports[0]}.Arg("attachments"), slacktest.Permalink(channel, ts)) {
+		t.Errorf("Expected the report to link to the reported message, got %v", reports[0]["attachments"])
+	}
+	if !strings.Contains(rr.Body.String(), `"response_action":"update"`) {
+		t.Errorf("Expected the reporter to be thanked, got %s", rr.Body.String())
+	}
+}
+
+func TestReportSubmissionRequiresReason(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{SigningSecret: testSigningSecret})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0)}
+
+	rr := sendInteraction(t, h, reportSubmission("U00000002", "{}", "   "))
+
+	response := blockkit.ViewSubmissionResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response %q: %v", rr.Body.String(), err)
+	}
+	if response.ResponseAction != "errors" || response.Errors["message"] == "" {
+		t.Errorf("Expected an error on the message input, got %+v", response)
+	}
+	if reports := s.WebhookMessages(); len(reports) != 0 {
+		t.Errorf("Expected no report to be sent, got %v", reports)
+	}
+}
+
+func TestModerateSubmissionDeactivatesUser(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	admin := s.AddUser(slack.User{Name: "admin", IsAdmin: true})
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	c := s.Client(slack.Config{SigningSecret: testSigningSecret, AdminToken: "xoxp-admin"})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0)}
+
+	rr := sendInteraction(t, h, map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": admin, "name": "admin"},
+		"view": map[string]interface{}{
+			"callback_id":      "moderate_user",
+			"private_metadata": spammer,
+			"state": map[string]interface{}{"values": map[string]interface{}{
+				"deactivate":     map[string]interface{}{"deactivate": map[string]interface{}{"type": "checkboxes", "selected_options": []map[string]string{{"value": "yes"}}}},
+				"remove_content": map[string]interface{}{"remove_content": map[string]interface{}{"type": "static_select", "selected_option": map[string]string{"value": "none"}}},
+			}},
+		},
+	})
+	h.wait()
+
+	if !strings.Contains(rr.Body.String(), `"response_action":"update"`) {
+		t.Errorf("Expected the modal to be updated, got %s", rr.Body.String())
+	}
+	if u, _ := s.User(spammer); !u.Deleted {
+		t.Errorf("Expected %s to be deactivated", spammer)
+	}
+	if calls := s.CallsTo("users.admin.setInactive"); len(calls) != 1 || calls[0].Token != "xoxp-admin" {
+		t.Errorf("Expected a single deactivation using the admin token, got %v", calls)
+	}
+	posts := s.CallsTo("chat.postMessage")
+	if len(posts) != 1 || !strings.Contains(posts[0].Arg("text"), "Successfully deactivated") {
+		t.Errorf("Expected the moderator to be told about the deactivation, got %v", posts)
+	}
+}
+
+// watchModerators returns a Watcher for a file listing the given channel moderators.
+func watchModerators(t *testing.T, channels map[string][]string) *moderators.Watcher {
+	path := filepath.Join(t.TempDir(), "moderators.json")
+	if err := moderators.Save(path, moderators.List{Channels: channels}); err != nil {
+		t.Fatalf("Failed to save moderators: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w, err := moderators.Watch(ctx, path, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to watch moderators: %v", err)
+	}
+	return w
+}
+
+func moderateSubmission(moderator string, target moderationTarget, values map[string]interface{}) map[string]interface{} {
+	metadata, _ := json.Marshal(target)
+	return map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": moderator, "name": "moderator"},
+		"view": map[string]interface{}{
+			"callback_id":      "moderate_user",
+			"private_metadata": string(metadata),
+			"state":            map[string]interface{}{"values": values},
+		},
+	}
+}
+
+func TestChannelModeratorsModerateOnlyTheirChannels(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	mod := s.AddUser(slack.User{Name: "mod"})
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	general := s.AddConversation(slack.Conversation{Name: "general", IsMember: true})
+	random := s.AddConversation(slack.Conversation{Name: "random", IsMember: true})
+	spam := s.AddMessage(general, slacktest.Message{User: spammer, Text: "spam"})
+	s.AddMessage(random, slacktest.Message{User: spammer, Text: "more spam"})
+	c := s.Client(slack.Config{SigningSecret: testSigningSecret, AdminToken: "xoxp-admin"})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0), moderators: watchModerators(t, map[string][]string{general: {mod}})}
+
+	for _, channel := range []string{general, random} {
+		sendInteraction(t, h, map[string]interface{}{
+			"type":        "message_action",
+			"callback_id": "report_message",
+			"trigger_id":  "trigger",
+			"user":        map[string]string{"id": mod},
+			"channel":     map[string]string{"id": channel},
+			"message":     map[string]string{"user": spammer, "ts": spam, "text": "spam"},
+		})
+	}
+	views := s.CallsTo("views.open")
+	if len(views) != 2 {
+		t.Fatalf("Expected two modals to be opened, got %d", len(views))
+	}
+	if v := views[0].Arg("view"); !strings.Contains(v, `"callback_id":"moderate_user"`) || strings.Contains(v, "deactivate") {
+		t.Errorf("Expected a channel moderator to get a moderation modal without deactivation in their channel, got %s", v)
+	}
+	if v := views[1].Arg("view"); !strings.Contains(v, `"callback_id":"send_report"`) {
+		t.Errorf("Expected a channel moderator to get the report modal elsewhere, got %s", v)
+	}
+
+	target := moderationTarget{User: spammer, Channel: general, TS: spam}
+	deactivate := map[string]interface{}{"deactivate": map[string]interface{}{"type": "checkboxes", "selected_options": []map[string]string{{"value": "yes"}}}}
+	rr := sendInteraction(t, h, moderateSubmission(mod, target, map[string]interface{}{
+		"deactivate":     deactivate,
+		"remove_content": map[string]interface{}{"remove_content": map[string]interface{}{"type": "static_select", "selected_option": map[string]string{"value": "none"}}},
+	}))
+	if !strings.Contains(rr.Body.String(), `"response_action":"errors"`) {
+		t.Errorf("Expected a channel moderator to be refused deactivation, got %s", rr.Body.String())
+	}
+	sendInteraction(t, h, moderateSubmission(mod, moderationTarget{User: spammer, Channel: random, TS: spam}, map[string]interface{}{
+		"delete_message": map[string]interface{}{"delete_message": map[string]interface{}{"type": "checkboxes", "selected_options": []map[string]string{{"value": "yes"}}}},
+		"remove_content": map[string]interface{}{"remove_content": map[string]interface{}{"type": "static_select", "selected_option": map[string]string{"value": "none"}}},
+	}))
+	h.wait()
+	if calls := s.CallsTo("chat.delete"); len(calls) != 0 {
+		t.Errorf("Expected nothing to be deleted outside the moderator's channel, got %v", calls)
+	}
+
+	sendInteraction(t, h, moderateSubmission(mod, target, map[string]interface{}{
+		"delete_message": map[string]interface{}{"delete_message": map[string]interface{}{"type": "checkboxes", "selected_options": []map[string]string{{"value": "yes"}}}},
+		"remove_content": map[string]interface{}{"remove_content": map[string]interface{}{"type": "static_select", "selected_option": map[string]string{"value": "none"}}},
+	}))
+	h.wait()
+	if messages := s.Messages(general); len(messages) != 0 {
+		t.Errorf("Expected the spam to be deleted, got %v", messages)
+	}
+	if messages := s.Messages(random); len(messages) != 1 {
+		t.Errorf("Expected spam elsewhere to be left alone, got %v", messages)
+	}
+	if u, _ := s.User(spammer); u.Deleted {
+		t.Errorf("Expected %s not to be deactivated by a channel moderator", spammer)
+	}
+}
+
+func TestRemoveUserContentInChannel(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	general := s.AddConversation(slack.Conversation{Name: "general", IsMember: true})
+	random := s.AddConversation(slack.Conversation{Name: "random", IsMember: true})
+	s.AddMessage(general, slacktest.Message{User: spammer, Text: "spam"})
+	s.AddMessage(random, slacktest.Message{User: spammer, Text: "more spam"})
+	s.AddFile(slacktest.File{Name: "spam.png", User: spammer, Channels: []string{general}})
+	shared := s.AddFile(slacktest.File{Name: "shared.png", User: spammer, Channels: []string{general, random}})
+	c := s.Client(slack.Config{})
+	h := &handler{client: c, users: slack.NewDirectory(c, 0)}
+
+	removedFiles, _, removedMessages, _, err := h.removeUserContent(context.Background(), time.Hour, spammer, general)
+	if err != nil {
+		t.Fatalf("Failed to remove content: %v", err)
+	}
+	if removedFiles != 1 || removedMessages != 1 {
+		t.Errorf("Expected one file and one message to be removed, got %d and %d", removedFiles, removedMessages)
+	}
+	if messages := s.Messages(random); len(messages) != 1 {
+		t.Errorf("Expected messages in other channels to be left alone, got %v", messages)
+	}
+	if files := s.Files(); len(files) != 1 || files[0].ID != shared {
+		t.Errorf("Expected only the file shared outside the channel to be left, got %v", files)
+	}
+}
+
+func TestBackgroundWorkIsCancelledOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &handler{ctx: ctx}
+
+	started := make(chan struct{})
+	var err error
+	h.runInBackground(context.Background(), time.Hour, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		err = ctx.Err()
+	})
+	<-started
+	cancel()
+	h.wait()
+	if err != context.Canceled {
+		t.Errorf("Expected background work to be cancelled, got %v", err)
+	}
+}
+
+func TestBackgroundWorkKeepsTeam(t *testing.T) {
+	h := &handler{ctx: context.Background()}
+	want := slack.Team{EnterpriseID: "E00000001", ID: "T00000002"}
+
+	var got slack.Team
+	var ok bool
+	h.runInBackground(slack.WithTeam(context.Background(), want), time.Hour, func(ctx context.Context) {
+		got, ok = slack.TeamFromContext(ctx)
+	})
+	h.wait()
+	if !ok || got != want {
+		t.Errorf("Expected background work to run for team %s, got %s (%v)", want, got, ok)
+	}
+}
@@ -17,24 +17,35 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/moderators"
 )
 
 type options struct {
-	configPath string
+	configPath        string
+	secretsDir        string
+	socketMode        bool
+	channelModerators string
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.secretsDir, "secrets-dir", "", "Path to a directory of files that override settings in the slack config, each named after the setting's key")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Also receive requests over Socket Mode, using the appToken in the slack config")
+	flag.StringVar(&o.channelModerators, "channel-moderators", "", "Path to the channel moderators written by Tempelis, who may moderate their own channels")
 	flag.Parse()
 	return o
 }
@@ -43,9 +54,17 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("ok"))
 }
 
-func runServer(h *handler) error {
-	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+// shutdownTimeout is how long in-flight requests have to finish once we've been asked to stop.
+const shutdownTimeout = 10 * time.Second
+
+// runServer serves until ctx is done, then stops accepting requests, waits for in-flight ones to
+// finish, and cancels any moderation work still running in the background. If socketMode is set,
+// it also receives requests over Socket Mode.
+func runServer(ctx context.Context, h *handler, socketMode bool) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h.routes())
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -53,36 +72,70 @@ func runServer(h *handler) error {
 		log.Printf("Defaulting to port %s", port)
 	}
 
-	log.Printf("Listening on port %s", port)
-	return http.ListenAndServe(fmt.Sprintf(":%s", port), nil)
-}
+	background, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.ctx = background
 
-func loadAdminToken(path string) (string, error) {
-	extraConf := struct {
-		AdminToken string `json:"adminToken"`
-	}{}
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return "", fmt.Errorf("couldn't open file: %v", err)
+	server := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: mux}
+	errs := make(chan error, 2)
+	go func() {
+		log.Printf("Listening on port %s", port)
+		errs <- server.ListenAndServe()
+	}()
+	socketDone := make(chan struct{})
+	if socketMode {
+		go func() {
+			defer close(socketDone)
+			sm := &slack.SocketMode{Client: h.client, Handler: h.router()}
+			if err := sm.Run(ctx); err != nil {
+				errs <- fmt.Errorf("socket mode stopped: %v", err)
+			}
+		}()
+	} else {
+		close(socketDone)
 	}
-	if err := json.Unmarshal(content, &extraConf); err != nil {
-		return "", fmt.Errorf("couldn't parse config: %v", err)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	err := server.Shutdown(shutdownCtx)
+	<-socketDone
+	cancel()
+	h.wait()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down cleanly: %v", err)
 	}
-	return extraConf.AdminToken, nil
+	return nil
 }
 
 func main() {
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	loader := slack.ConfigLoader{Path: o.configPath, SecretsDir: o.secretsDir, EnvPrefix: "SLACK_"}
+	config, err := slack.WatchConfig(context.Background(), loader, slack.DefaultConfigReloadInterval)
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
 	}
-	adminToken, err := loadAdminToken(o.configPath)
-	if err != nil {
-		log.Fatalf("Failed to load admin token from %s: %v", o.configPath, err)
-	}
+	c := config.Config()
 	s := slack.New(c)
+	s.ConfigSource = config
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	h := &handler{client: s, adminToken: adminToken}
-	log.Fatal(runServer(h))
+	h := &handler{client: s, users: slack.NewDirectory(s, 0)}
+	if o.channelModerators != "" {
+		if h.moderators, err = moderators.Watch(ctx, o.channelModerators, slack.DefaultConfigReloadInterval); err != nil {
+			log.Fatalf("Failed to load channel moderators from %s: %v", o.channelModerators, err)
+		}
+	}
+	go h.users.Run(ctx)
+	if err := runServer(ctx, h, o.socketMode); err != nil {
+		log.Fatal(err)
+	}
 }
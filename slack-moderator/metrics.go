@@ -0,0 +1,43 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	reportsFiled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_moderator_reports_filed_total",
+		Help: "Messages reported to the moderators, by category.",
+	}, []string{"category"})
+	moderationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "slack_moderator_moderation_duration_seconds",
+		Help:    "How long moderation jobs took.",
+		Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 900},
+	})
+	contentRemoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_moderator_content_removed_total",
+		Help: "Content removed by moderation jobs, by kind (message or file).",
+	}, []string{"kind"})
+	usersDeactivated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "slack_moderator_users_deactivated_total",
+		Help: "Users deactivated by moderation jobs.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(reportsFiled, moderationDuration, contentRemoved, usersDeactivated)
+}
@@ -17,146 +17,215 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"strings"
 	"time"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
 const maxRemovalDuration = 8760 * time.Hour
 
-func (h *handler) handleModerateMessage(interaction slackInteraction, rw http.ResponseWriter) {
-	targetUser, err := h.getDisplayName(interaction.Message.User)
+// removalOptions are the amounts of recent content a moderator can choose to remove. If you
+// change these, you may need to change maxRemovalDuration accordingly.
+var removalOptions = []blockkit.Option{
+	blockkit.NewOption("None", "none"),
+	blockkit.NewOption("10 minutes", "10m"),
+	blockkit.NewOption("1 hour", "1h"),
+	blockkit.NewOption("6 hours", "6h"),
+	blockkit.NewOption("12 hours", "12h"),
+	blockkit.NewOption("24 hours", "24h"),
+	blockkit.NewOption("48 hours", "48h"),
+	blockkit.NewOption("1 year", "8760h"),
+}
+
+// moderationTarget is the message a moderation modal was opened on. It is kept in the modal's
+// private metadata.
+type moderationTarget struct {
+	User    string `json:"user"`
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
+
+// decodeModerationTarget decodes the private metadata of a moderation modal. Modals opened by
+// older versions of the bot only carry the user's ID.
+func decodeModerationTarget(metadata string) moderationTarget {
+	target := moderationTarget{}
+	if err := json.Unmarshal([]byte(metadata), &target); err != nil || target.User == "" {
+		return moderationTarget{User: metadata}
+	}
+	return target
+}
+
+func (h *handler) handleModerateMessage(ctx context.Context, interaction *events.Interaction, p powers) error {
+	targetUser, err := h.getDisplayName(ctx, interaction.Message.User)
 	if err != nil {
 		targetUser = "<error>"
 	}
-	deactivateElement := slack.SelectElement{
-		Name:  "deactivate",
-		Label: fmt.Sprintf("Deactivate %s (%s)?", shortenString(slack.EscapeMessage(targetUser), 24), interaction.Message.User),
-		Options: []slack.SelectOption{
-			{
-				Label: "No",
-				Value: "no",
-			},
-			{
-				Label: "Yes",
-				Value: "yes",
-			},
-		},
-		Value: "no",
-	}
-	removeContentElement := slack.SelectElement{
-		Name:  "remove_content",
-		Label: fmt.Sprintf("How much content would you like to remove?"),
-		Options: []slack.SelectOption{
-			{
-				Label: "None",
-				Value: "none",
-			},
-			{
-				Label: "10 minutes",
-				Value: "10m",
-			},
-			{
-				Label: "1 hour",
-				Value: "1h",
-			},
-			{
-				Label: "6 hours",
-				Value: "6h",
-			},
-			{
-				Label: "12 hours",
-				Value: "12h",
-			},
-			{
-				Label: "24 hours",
-				Value: "24h",
-			},
-			{
-				Label: "48 hours",
-				Value: "48h",
-			},
-			{
-				Label: "1 year",
-				Value: "8760h",
-			},
-			// If you change these, you may need to change maxRemovalDuration accordingly.
-		},
-		Value: "10m",
-	}
-	dialog := slack.DialogWrapper{
-		TriggerID: interaction.TriggerID,
-		Dialog: slack.Dialog{
-			CallbackID:     "moderate_user",
-			NotifyOnCancel: false,
-			Title:          "Moderate User",
-			Elements:       []interface{}{deactivateElement, removeContentElement},
-			State:          interaction.Message.User,
-		},
-	}
-	if err := h.client.CallMethod("dialog.open", dialog, nil); err != nil {
-		logError(rw, "Failed to call dialog.open: %v", err)
-		return
+	metadata, err := json.Marshal(moderationTarget{User: interaction.Message.User, Channel: interaction.Channel.ID, TS: interaction.Message.TS})
+	if err != nil {
+		return fmt.Errorf("failed to serialise metadata for modal: %v", err)
+	}
+	deleteMessage := blockkit.NewInput("delete_message", "Message", &blockkit.Checkboxes{
+		ActionID: "delete_message",
+		Options:  []blockkit.Option{blockkit.NewOption("Delete this message", "yes")},
+	})
+	deleteMessage.Optional = true
+	view := blockkit.NewModal("moderate_user", "Moderate User")
+	if p == workspacePowers {
+		deactivate := blockkit.NewInput("deactivate", "Deactivation", &blockkit.Checkboxes{
+			ActionID: "deactivate",
+			Options:  []blockkit.Option{blockkit.NewOption(fmt.Sprintf("Deactivate %s (%s)", shortenString(targetUser, 40), interaction.Message.User), "yes")},
+		})
+		deactivate.Optional = true
+		view.Blocks = append(view.Blocks,
+			blockkit.NewSection(string(mrkdwn.Sprintf("Moderating %s.", mrkdwn.User(interaction.Message.User)))),
+			deleteMessage,
+			deactivate,
+			blockkit.NewInput("remove_content", "How much content would you like to remove?", &blockkit.StaticSelect{
+				ActionID:      "remove_content",
+				Options:       removalOptions,
+				InitialOption: &removalOptions[1],
+			}),
+		)
+	} else {
+		view.Blocks = append(view.Blocks,
+			blockkit.NewSection(string(mrkdwn.Sprintf("Moderating %s in %s, which you moderate.", mrkdwn.User(interaction.Message.User), mrkdwn.Channel(interaction.Channel.ID)))),
+			deleteMessage,
+			blockkit.NewInput("remove_content", "How much of their content in this channel would you like to remove?", &blockkit.StaticSelect{
+				ActionID:      "remove_content",
+				Options:       removalOptions,
+				InitialOption: &removalOptions[0],
+			}),
+		)
+	}
+	view.Submit = blockkit.PlainText("Moderate")
+	view.PrivateMetadata = string(metadata)
+	if _, err := h.client.OpenView(ctx, interaction.TriggerID, view); err != nil {
+		return fmt.Errorf("failed to call views.open: %v", err)
 	}
+	return nil
 }
 
-func (h *handler) handleModerateSubmission(interaction slackInteraction) {
-	isMod, err := h.userHasModerationPowers(interaction.User.ID)
-	if err != nil || !isMod {
+// moderation is a checked request to moderate a user.
+type moderation struct {
+	moderator     string
+	target        moderationTarget
+	deactivate    bool
+	deleteMessage bool
+	duration      time.Duration
+	// channel, if set, limits the content removed to that channel, because the moderator only
+	// moderates that channel.
+	channel string
+}
+
+// handleModerateSubmission checks the moderation request, then spins off the moderation itself
+// because it takes longer than Slack is willing to wait for a response.
+func (h *handler) handleModerateSubmission(ctx context.Context, interaction *events.Interaction) (blockkit.ViewSubmissionResponse, error) {
+	target := decodeModerationTarget(interaction.View.PrivateMetadata)
+	p, err := h.moderationPowers(ctx, interaction.User.ID, target.Channel)
+	if err != nil || p == noPowers {
 		log.Printf("User %s (%s) does not seem to be a mod: %v\n", interaction.User.ID, interaction.User.Name, err)
-		return
+		return blockkit.ErrorsResponse(map[string]string{"remove_content": "You don't seem to be a moderator."}), nil
+	}
+	m := moderation{
+		moderator:     interaction.User.ID,
+		target:        target,
+		deactivate:    interaction.View.State.StringValue("deactivate", "deactivate") == "yes",
+		deleteMessage: interaction.View.State.StringValue("delete_message", "delete_message") == "yes" && target.TS != "",
+	}
+	if p == channelPowers {
+		if m.deactivate {
+			return blockkit.ErrorsResponse(map[string]string{"remove_content": "Only workspace admins can deactivate users."}), nil
+		}
+		m.channel = target.Channel
 	}
+	if remove := interaction.View.State.StringValue("remove_content", "remove_content"); remove != "none" {
+		m.duration, err = time.ParseDuration(remove)
+		if err != nil {
+			return blockkit.ErrorsResponse(map[string]string{"remove_content": fmt.Sprintf("Couldn't understand %q: %v", remove, err)}), nil
+		}
+		if m.duration > maxRemovalDuration {
+			return blockkit.ErrorsResponse(map[string]string{"remove_content": fmt.Sprintf("Unacceptably long content removal duration: %s", m.duration)}), nil
+		}
+	}
+	if !m.deactivate && !m.deleteMessage && m.duration == 0 {
+		if p == channelPowers {
+			return blockkit.ErrorsResponse(map[string]string{"remove_content": "Choose some content to remove."}), nil
+		}
+		return blockkit.ErrorsResponse(map[string]string{"remove_content": "Choose some content to remove, or deactivate the user."}), nil
+	}
+
+	h.runInBackground(ctx, moderationTimeout, func(ctx context.Context) {
+		h.moderate(ctx, m)
+	})
+
+	started := blockkit.NewModal("moderation_started", "Moderate User",
+		blockkit.NewSection(string(mrkdwn.Sprintf("Moderating %s. You'll get a message when it's done.", mrkdwn.User(target.User)))))
+	started.Close = blockkit.PlainText("Done")
+	return blockkit.UpdateResponse(started), nil
+}
+
+// moderate deletes the target message, deactivates the target user and removes their recent
+// content, as requested. The results are sent to the moderator and to the moderators' channel.
+func (h *handler) moderate(ctx context.Context, m moderation) {
+	defer func(start time.Time) {
+		moderationDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
 	var messages []string
-	targetUser := interaction.State
-	targetDisplayName, err := h.getDisplayName(targetUser)
+	targetUser, moderator, duration := m.target.User, m.moderator, m.duration
+	targetDisplayName, err := h.getDisplayName(ctx, targetUser)
 	if err != nil {
 		targetDisplayName = "<unknown>"
 	}
 
-	quickResponse := map[string]interface{}{
-		"text":             "Please wait...",
-		"response_type":    "ephemeral",
-		"replace_original": false,
+	removal := "none"
+	if duration > 0 {
+		removal = duration.String()
 	}
-	if h.client.CallMethod(interaction.ResponseURL, quickResponse, nil) != nil {
-		log.Printf("Failed to send quick response: %v.\n", err)
+	modMessage := mrkdwn.Sprintf("%s triggered moderation on %s. Deactivate: %t, delete message: %t, remove content: %s", mrkdwn.User(moderator), mrkdwn.User(targetUser), m.deactivate, m.deleteMessage, removal)
+	if m.channel != "" {
+		modMessage = mrkdwn.Sprintf("%s triggered moderation on %s in %s, as a channel moderator. Delete message: %t, remove content: %s", mrkdwn.User(moderator), mrkdwn.User(targetUser), mrkdwn.Channel(m.channel), m.deleteMessage, removal)
 	}
-
-	modMessage := fmt.Sprintf("<@%s> triggered moderation on <@%s>. Deactivate: %s, remove content: %s", interaction.User.ID, targetUser, interaction.Submission["deactivate"], interaction.Submission["remove_content"])
-	if h.client.CallMethod(h.client.Config.WebhookURL, map[string]string{"text": modMessage}, nil) != nil {
+	if err := h.client.CallMethodContext(ctx, h.client.CurrentConfig().WebhookURL, map[string]string{"text": string(modMessage)}, nil); err != nil {
 		log.Printf("Failed to send quick response: %v.\n", err)
 	}
 
-	if interaction.Submission["deactivate"] == "yes" {
-		if err := h.deactivateUser(interaction, targetUser); err != nil {
+	if m.deleteMessage {
+		if err := h.removeMessage(ctx, messageID{channel: m.target.Channel, ts: m.target.TS}); err != nil {
+			messages = append(messages, fmt.Sprintf("Failed to delete the message: %v", err))
+		} else {
+			messages = append(messages, "Successfully deleted the message")
+		}
+	}
+	if m.deactivate {
+		if err := h.deactivateUser(ctx, targetUser); err != nil {
 			messages = append(messages, fmt.Sprintf("Failed to deactivate user %s (%s): %v", targetUser, targetDisplayName, err))
 		} else {
 			messages = append(messages, fmt.Sprintf("Successfully deactivated user %s (%s)", targetUser, targetDisplayName))
 		}
 	}
-	if remove, ok := interaction.Submission["remove_content"]; ok && remove != "none" {
-		duration, err := time.ParseDuration(remove)
-		if err != nil {
-			messages = append(messages, fmt.Sprintf("Failed to parse removal duration, and therefore could not remove any content: %v", err))
-			goto respond
-		}
-		if duration > maxRemovalDuration {
-			messages = append(messages, fmt.Sprintf("unacceptably long content removal duration: %s", duration))
-			goto respond
-		}
-		removedFiles, remainingFiles, removedMessages, remainingMessages, err := h.removeUserContent(interaction, duration, targetUser)
+	if duration > 0 {
+		removedFiles, remainingFiles, removedMessages, remainingMessages, err := h.removeUserContent(ctx, duration, targetUser, m.channel)
 
 		if err != nil {
 			messages = append(messages, fmt.Sprintf("Failed to remove any content: %v", err))
 			goto respond
 		}
 		// Delete things again in case search was behind before.
-		time.Sleep(10 * time.Second)
-		fs2, fe2, ms2, me2, err := h.removeUserContent(interaction, duration, targetUser)
+		select {
+		case <-ctx.Done():
+			messages = append(messages, fmt.Sprintf("Deleted things once, but gave up before the cleanup check: %v", ctx.Err()))
+			goto respond
+		case <-time.After(10 * time.Second):
+		}
+		fs2, fe2, ms2, me2, err := h.removeUserContent(ctx, duration, targetUser, m.channel)
 		removedFiles += fs2
 		remainingFiles += fe2
 		removedMessages += ms2
@@ -179,16 +248,10 @@ respond:
 		messages = append(messages, "Did nothing.")
 	}
 
-	response := map[string]interface{}{
-		"text":             strings.Join(messages, "\n"),
-		"response_type":    "ephemeral",
-		"replace_original": true,
-	}
-
-	if h.client.CallMethod(interaction.ResponseURL, response, nil) != nil {
+	if _, err := h.client.PostMessage(ctx, slack.PostMessageRequest{Channel: moderator, Text: strings.Join(messages, "\n")}); err != nil {
 		log.Printf("Failed to send response: %v.\n", err)
 	}
-	if h.client.CallMethod(h.client.Config.WebhookURL, map[string]string{"text": strings.Join(messages, "\n")}, nil) != nil {
+	if err := h.client.CallMethodContext(ctx, h.client.CurrentConfig().WebhookURL, map[string]string{"text": strings.Join(messages, "\n")}, nil); err != nil {
 		log.Printf("Failed to send quick response: %v.\n", err)
 	}
 }
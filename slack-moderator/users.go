@@ -17,59 +17,54 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
-
-	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *handler) getDisplayName(id string) (string, error) {
-	user, err := h.getUserInfo(id)
+func (h *handler) getDisplayName(ctx context.Context, id string) (string, error) {
+	user, err := h.users.User(ctx, id)
 	if err != nil {
 		return "", err
 	}
 	return user.Name, nil
 }
 
-func (h *handler) getUserInfo(id string) (slack.User, error) {
-	user := struct {
-		User slack.User `json:"user"`
-	}{}
+// powers are what a user may do to moderate a message.
+type powers int
 
-	if err := h.client.CallOldMethod("users.info", map[string]string{"user": id}, &user); err != nil {
-		return slack.User{}, fmt.Errorf("failed get user: %v", err)
-	}
-	return user.User, nil
-}
+const (
+	// noPowers only lets the user report the message.
+	noPowers powers = iota
+	// channelPowers let the user remove content from a channel they moderate.
+	channelPowers
+	// workspacePowers let the user remove content from anywhere, and deactivate users.
+	workspacePowers
+)
 
-func (h *handler) userHasModerationPowers(id string) (bool, error) {
-	user, err := h.getUserInfo(id)
+// moderationPowers returns the powers user has over messages in the given channel. Workspace
+// admins and owners can moderate everything; the channel moderators exported by Tempelis can
+// moderate their own channels.
+func (h *handler) moderationPowers(ctx context.Context, user, channel string) (powers, error) {
+	u, err := h.users.User(ctx, user)
 	if err != nil {
 		log.Printf("Failed to look up moderation powers: %v\n", err)
-		return false, err
+		return noPowers, err
+	}
+	if u.IsModerator() {
+		return workspacePowers, nil
+	}
+	if channel != "" && h.moderators.List().IsModerator(channel, user) {
+		return channelPowers, nil
 	}
-	return user.IsAdmin || user.IsOwner || user.IsPrimaryOwner, nil
+	return noPowers, nil
 }
 
-func (h *handler) deactivateUser(interaction slackInteraction, targetUser string) error {
-	result := struct {
-		Ok    bool   `json:"ok"`
-		Error string `json:"error,omitempty"`
-	}{}
-
-	resp, err := http.Post("https://slack.com/api/users.admin.setInactive", "application/x-www-form-urlencoded", bytes.NewBufferString("token="+h.adminToken+"&user="+targetUser))
-	if err != nil {
+func (h *handler) deactivateUser(ctx context.Context, targetUser string) error {
+	adminClient := h.client.WithToken(h.client.CurrentConfig().AdminToken)
+	if err := adminClient.DeactivateUser(ctx, targetUser); err != nil {
 		return fmt.Errorf("couldn't deactivate user %s: %v", targetUser, err)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode json: %v", err)
-	}
-	if !result.Ok {
-		return fmt.Errorf("couldn't update membership: %s", result.Error)
-	}
-
+	usersDeactivated.Inc()
 	return nil
 }
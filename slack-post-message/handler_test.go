@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "shhh"
+
+func sendInteraction(t *testing.T, h *handler, interaction map[string]interface{}) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(interaction)
+	if err != nil {
+		t.Fatalf("Failed to marshal interaction: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.routes().ServeHTTP(rr, slacktest.NewInteractionRequest(testSigningSecret, payload))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+func TestWriteAndPostMessage(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	poster := s.AddUser(slack.User{Name: "poster"})
+	group := s.AddUsergroup(slack.Subteam{Handle: "posters", Users: []string{poster}})
+	general := s.AddConversation(slack.Conversation{Name: "general", IsChannel: true, IsMember: true})
+	random := s.AddConversation(slack.Conversation{Name: "random", IsChannel: true, IsMember: true})
+	h := &handler{client: s.Client(slack.Config{SigningSecret: testSigningSecret}), userGroups: []string{group}}
+
+	sendInteraction(t, h, map[string]interface{}{
+		"type":        "shortcut",
+		"callback_id": "write_message",
+		"trigger_id":  "trigger",
+		"user":        map[string]string{"id": poster},
+	})
+	opens := s.CallsTo("views.open")
+	if len(opens) != 1 {
+		t.Fatalf("Expected a modal to be opened, got %d", len(opens))
+	}
+	if view := opens[0].Arg("view"); !strings.Contains(view, `"type":"multi_channels_select"`) || !strings.Contains(view, `"callback_id":"post_message"`) {
+		t.Errorf("Expected the post message modal, got %s", view)
+	}
+
+	sendInteraction(t, h, map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": poster},
+		"view": map[string]interface{}{
+			"callback_id": "post_message",
+			"state": map[string]interface{}{
+				"values": map[string]interface{}{
+					"message-input": map[string]interface{}{
+						"channel-input": map[string]interface{}{"type": "multi_channels_select", "selected_channels": []string{general, random}},
+					},
+					"message-block": map[string]interface{}{
+						"channel-block": map[string]interface{}{"type": "plain_text_input", "value": "Hello, world!"},
+					},
+				},
+			},
+		},
+	})
+	for _, c := range []string{general, random} {
+		if messages := s.Messages(c); len(messages) != 1 || messages[0].Text != "Hello, world!" {
+			t.Errorf("Expected the message to be posted in %s, got %v", c, messages)
+		}
+	}
+}
+
+func TestNonMembersAreTurnedAway(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	stranger := s.AddUser(slack.User{Name: "stranger"})
+	group := s.AddUsergroup(slack.Subteam{Handle: "posters"})
+	h := &handler{client: s.Client(slack.Config{SigningSecret: testSigningSecret}), userGroups: []string{group}}
+
+	sendInteraction(t, h, map[string]interface{}{
+		"type":        "shortcut",
+		"callback_id": "write_message",
+		"trigger_id":  "trigger",
+		"user":        map[string]string{"id": stranger},
+	})
+	opens := s.CallsTo("views.open")
+	if len(opens) != 1 || !strings.Contains(opens[0].Arg("view"), "Not authorized") {
+		t.Errorf("Expected the not authorized modal, got %v", opens)
+	}
+}
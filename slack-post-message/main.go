@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -25,16 +26,24 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/oauth"
 )
 
 type options struct {
-	configPath string
+	configPath        string
+	secretsDir        string
+	installationsPath string
+	socketMode        bool
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.secretsDir, "secrets-dir", "", "Path to a directory of files that override settings in the slack config, each named after the setting's key")
+	flag.StringVar(&o.installationsPath, "installations-path", "", "Path to a file of OAuth installations. If set, the bot can be installed in other workspaces at /install, using the clientId and clientSecret in the slack config")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Also receive requests over Socket Mode, using the appToken in the slack config")
 	flag.Parse()
 	return o
 }
@@ -43,9 +52,13 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("ok"))
 }
 
-func runServer(h *handler) error {
+func runServer(h *handler, installer http.Handler) error {
 	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h.routes())
+	if installer != nil {
+		http.Handle(os.Getenv("PATH_PREFIX")+"/install", installer)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -76,15 +89,33 @@ func loadAuthorizedUserGroups(path string) ([]string, error) {
 
 func main() {
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	loader := slack.ConfigLoader{Path: o.configPath, SecretsDir: o.secretsDir, EnvPrefix: "SLACK_"}
+	config, err := slack.WatchConfig(context.Background(), loader, slack.DefaultConfigReloadInterval)
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
 	}
+	c := config.Config()
 	userGroups, err := loadAuthorizedUserGroups(o.configPath)
 	if err != nil {
 		log.Fatalf("Failed to load user group from %s: %v", o.configPath, err)
 	}
 	s := slack.New(c)
+	s.ConfigSource = config
+	var installer http.Handler
+	if o.installationsPath != "" {
+		if c.ClientID == "" || c.ClientSecret == "" {
+			log.Fatalf("clientId and clientSecret are required to use --installations-path but were not found in %s", o.configPath)
+		}
+		store := oauth.NewFileStore(o.installationsPath)
+		s.Tokens = oauth.Tokens(store, s)
+		installer = &oauth.InstallHandler{Client: s, Store: store, Scopes: []string{"usergroups:read", "channels:read", "groups:read", "chat:write", "commands"}}
+	}
 	h := &handler{client: s, userGroups: userGroups}
-	log.Fatal(runServer(h))
+	if o.socketMode {
+		go func() {
+			sm := &slack.SocketMode{Client: s, Handler: h.router()}
+			log.Fatalf("Socket Mode stopped: %v", sm.Run(context.Background()))
+		}()
+	}
+	log.Fatal(runServer(h, installer))
 }
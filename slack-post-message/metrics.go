@@ -0,0 +1,28 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var messagesPosted = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "slack_post_message_messages_posted_total",
+	Help: "Messages posted to channels on behalf of users.",
+})
+
+func init() {
+	prometheus.MustRegister(messagesPosted)
+}
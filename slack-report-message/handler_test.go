@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "shhh"
+
+func sendInteraction(t *testing.T, h *handler, interaction map[string]interface{}) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(interaction)
+	if err != nil {
+		t.Fatalf("Failed to marshal interaction: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	h.routes().ServeHTTP(rr, slacktest.NewInteractionRequest(testSigningSecret, payload))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+func TestReportFlow(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	reporter := s.AddUser(slack.User{Name: "reporter"})
+	spammer := s.AddUser(slack.User{Name: "spammer"})
+	channel := s.AddConversation(slack.Conversation{Name: "general"})
+	ts := s.AddMessage(channel, slacktest.Message{User: spammer, Text: "spam"})
+	h := &handler{client: s.Client(slack.Config{SigningSecret: testSigningSecret})}
+
+	sendInteraction(t, h, map[string]interface{}{
+		"type":        "message_action",
+		"callback_id": "report_message",
+		"trigger_id":  "trigger",
+		"user":        map[string]string{"id": reporter},
+		"channel":     map[string]string{"id": channel, "name": "general"},
+		"message":     map[string]string{"user": spammer, "ts": ts, "text": "spam"},
+	})
+	views := s.CallsTo("views.open")
+	if len(views) != 1 {
+		t.Fatalf("Expected the report modal to be opened, got %d calls", len(views))
+	}
+	view := struct {
+		PrivateMetadata string `json:"private_metadata"`
+	}{}
+	if err := json.Unmarshal([]byte(views[0].Arg("view")), &view); err != nil {
+		t.Fatalf("Failed to parse view: %v", err)
+	}
+
+	rr := sendInteraction(t, h, map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": reporter, "name": "reporter"},
+		"view": map[string]interface{}{
+			"callback_id":      "send_report",
+			"private_metadata": view.PrivateMetadata,
+			"state": map[string]interface{}{"values": map[string]interface{}{
+				"category": map[string]interface{}{"category": map[string]interface{}{"type": "radio_buttons", "selected_option": map[string]interface{}{"value": "harassment"}}},
+				"message":  map[string]interface{}{"message": map[string]interface{}{"type": "plain_text_input", "value": "rude"}},
+			}},
+		},
+	})
+
+	reports := s.WebhookMessages()
+	if len(reports) != 1 {
+		t.Fatalf("Expected one report, got %d", len(reports))
+	}
+	if text, _ := reports[0]["text"].(string); !strings.Contains(text, "<@"+reporter+"|reporter>") || !strings.Contains(text, "*Harassment or abuse*") {
+		t.Errorf("Expected a named harassment report, got %q", text)
+	}
+	if attachments := (slacktest.Call{Params: reports[0]}).Arg("attachments"); !strings.Contains(attachments, `"text":"spam"`) {
+		t.Errorf("Expected the report to include the reported message, got %s", attachments)
+	}
+	if !strings.Contains(rr.Body.String(), `"response_action":"update"`) {
+		t.Errorf("Expected the reporter to be thanked, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "shhh"
+
+func newTestHandler(t *testing.T, s *slacktest.Server) *handler {
+	messagePath := filepath.Join(t.TempDir(), "welcome.md")
+	if err := os.WriteFile(messagePath, []byte("Welcome!"), 0644); err != nil {
+		t.Fatalf("Failed to write welcome message: %v", err)
+	}
+	client := s.Client(slack.Config{
+		SigningSecret:   testSigningSecret,
+		AdminToken:      "xoxp-admin",
+		AppToken:        "xapp-test",
+		GuardedChannels: []string{"CGUARDED1"},
+	})
+	return &handler{client: client, users: slack.NewDirectory(client, 0), messagePath: messagePath}
+}
+
+func TestTeamJoinSendsWelcome(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "newbie"})
+	h := newTestHandler(t, s)
+
+	body := fmt.Sprintf(`{"type": "event_callback", "event": {"type": "team_join", "user": {"id": %q}}}`, user)
+	rr := httptest.NewRecorder()
+	h.routes().ServeHTTP(rr, slacktest.NewEventRequest(testSigningSecret, []byte(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	posts := s.CallsTo("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("Expected one message to be posted, got %d", len(posts))
+	}
+	dm, _ := s.Conversation(posts[0].Arg("channel"))
+	if !dm.IsIM {
+		t.Errorf("Expected welcome to be sent in a DM, but was sent to %s", posts[0].Arg("channel"))
+	}
+	if text := posts[0].Arg("text"); text != "Welcome!" {
+		t.Errorf("Expected welcome text %q, got %q", "Welcome!", text)
+	}
+}
+
+func TestTeamJoinOverSocketMode(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "newbie"})
+	h := newTestHandler(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = (&slack.SocketMode{Client: h.client, Handler: h.router()}).Run(ctx)
+	}()
+
+	body := fmt.Sprintf(`{"type": "event_callback", "event": {"type": "team_join", "user": {"id": %q}}}`, user)
+	if _, err := s.SendSocketModeEvent([]byte(body)); err != nil {
+		t.Fatalf("Failed to deliver event: %v", err)
+	}
+	if posts := s.CallsTo("chat.postMessage"); len(posts) != 1 || posts[0].Arg("text") != "Welcome!" {
+		t.Errorf("Expected a welcome to be posted, got %v", posts)
+	}
+}
+
+func TestGuardedChannelRemovesNonAdminPosts(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "poster"})
+	admin := s.AddUser(slack.User{Name: "admin", IsAdmin: true})
+	channel := s.AddConversation(slack.Conversation{ID: "CGUARDED1", Name: "jobs"}, user, admin)
+	userTS := s.AddMessage(channel, slacktest.Message{User: user, Text: "hire me"})
+	adminTS := s.AddMessage(channel, slacktest.Message{User: admin, Text: "rules"})
+	h := newTestHandler(t, s)
+
+	for _, m := range []struct{ user, ts string }{{user, userTS}, {admin, adminTS}} {
+		body := fmt.Sprintf(`{"type": "event_callback", "event": {"type": "message", "user": %q, "channel": %q, "ts": %q}}`, m.user, channel, m.ts)
+		rr := httptest.NewRecorder()
+		h.routes().ServeHTTP(rr, slacktest.NewEventRequest(testSigningSecret, []byte(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	messages := s.Messages(channel)
+	if len(messages) != 1 || messages[0].TS != adminTS {
+		t.Errorf("Expected only the admin's message to remain, got %v", messages)
+	}
+	deletes := s.CallsTo("chat.delete")
+	if len(deletes) != 1 || deletes[0].Token != "xoxp-admin" {
+		t.Errorf("Expected a single chat.delete using the admin token, got %v", deletes)
+	}
+	if posts := s.CallsTo("chat.postMessage"); len(posts) != 1 {
+		t.Errorf("Expected the poster to be notified, but got %d messages", len(posts))
+	}
+}
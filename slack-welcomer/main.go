@@ -17,24 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/slack-infra/slack"
 )
 
 type options struct {
 	configPath  string
+	secretsDir  string
 	messagePath string
+	socketMode  bool
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.secretsDir, "secrets-dir", "", "Path to a directory of files that override settings in the slack config, each named after the setting's key")
 	flag.StringVar(&o.messagePath, "message-path", "welcome.md", "Path to a file containing the welcome message")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Also receive requests over Socket Mode, using the appToken in the slack config")
 	flag.Parse()
 	return o
 }
@@ -45,7 +51,8 @@ func handleHealthz(w http.ResponseWriter, r *http.Request) {
 
 func runServer(h *handler) error {
 	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h.routes())
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -59,10 +66,12 @@ func runServer(h *handler) error {
 
 func main() {
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	loader := slack.ConfigLoader{Path: o.configPath, SecretsDir: o.secretsDir, EnvPrefix: "SLACK_"}
+	config, err := slack.WatchConfig(context.Background(), loader, slack.DefaultConfigReloadInterval)
 	if err != nil {
 		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
 	}
+	c := config.Config()
 	if c.AdminToken == "" {
 		log.Fatalf("adminToken is required but was not found in %s", o.configPath)
 	}
@@ -70,7 +79,15 @@ func main() {
 		log.Fatalf("guardedChannels is required but was not found in %s", o.configPath)
 	}
 	s := slack.New(c)
+	s.ConfigSource = config
 
-	h := &handler{client: s, messagePath: o.messagePath}
+	h := &handler{client: s, users: slack.NewDirectory(s, 0), messagePath: o.messagePath}
+	go h.users.Run(context.Background())
+	if o.socketMode {
+		go func() {
+			sm := &slack.SocketMode{Client: s, Handler: h.router()}
+			log.Fatalf("Socket Mode stopped: %v", sm.Run(context.Background()))
+		}()
+	}
 	log.Fatal(runServer(h))
 }
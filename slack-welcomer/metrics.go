@@ -0,0 +1,34 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	welcomesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "slack_welcomer_welcomes_sent_total",
+		Help: "Welcome messages sent to new users.",
+	})
+	messagesDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "slack_welcomer_messages_deleted_total",
+		Help: "Messages deleted from guarded channels because they weren't posted via the workflow.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(welcomesSent, messagesDeleted)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestPostPinAndDeleteMessage(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "general", IsMember: true})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	posted, err := c.PostMessage(ctx, slack.PostMessageRequest{Channel: channel, Text: "hello", LinkNames: true})
+	if err != nil {
+		t.Fatalf("Failed to post message: %v", err)
+	}
+	if posted.Channel != channel || posted.TS == "" {
+		t.Errorf("Expected a message in %s, got %+v", channel, posted)
+	}
+	if err := c.PinMessage(ctx, channel, posted.TS); err != nil {
+		t.Errorf("Failed to pin message: %v", err)
+	}
+	if pins := s.Pins(channel); len(pins) != 1 || pins[0] != posted.TS {
+		t.Errorf("Expected %s to be pinned, got %v", posted.TS, pins)
+	}
+	link, err := c.GetPermalink(ctx, channel, posted.TS)
+	if err != nil {
+		t.Errorf("Failed to get permalink: %v", err)
+	} else if link != slacktest.Permalink(channel, posted.TS) {
+		t.Errorf("Expected permalink %q, got %q", slacktest.Permalink(channel, posted.TS), link)
+	}
+
+	if err := c.DeleteMessage(ctx, channel, posted.TS); err != nil {
+		t.Errorf("Failed to delete message: %v", err)
+	}
+	// Errors from Slack remain visible through the wrapping.
+	err = c.DeleteMessage(ctx, channel, posted.TS)
+	var e slack.ErrSlack
+	if !errors.As(err, &e) || e.Type != "message_not_found" {
+		t.Errorf("Expected message_not_found deleting the message again, got %v", err)
+	}
+}
+
+func TestOpenDMAndGetUser(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "someone", IsOwner: true})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	u, err := c.GetUser(ctx, user)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if u.Name != "someone" || !u.IsModerator() {
+		t.Errorf("Expected an owner named someone, got %+v", u)
+	}
+	dm, err := c.OpenDM(ctx, user)
+	if err != nil {
+		t.Fatalf("Failed to open DM: %v", err)
+	}
+	if conv, _ := s.Conversation(dm); !conv.IsIM {
+		t.Errorf("Expected %s to be a DM, got %+v", dm, conv)
+	}
+}
+
+func TestUsergroupLifecycle(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	alice := s.AddUser(slack.User{Name: "alice"})
+	bob := s.AddUser(slack.User{Name: "bob"})
+	channel := s.AddConversation(slack.Conversation{Name: "sig-testing"})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	g, err := c.CreateUsergroup(ctx, slack.UsergroupRequest{Name: "Testing", Handle: "testing", Channels: []string{channel}})
+	if err != nil {
+		t.Fatalf("Failed to create usergroup: %v", err)
+	}
+	if err := c.UpdateUsergroupUsers(ctx, g.ID, []string{alice, bob}); err != nil {
+		t.Fatalf("Failed to update members: %v", err)
+	}
+	if _, err := c.UpdateUsergroup(ctx, slack.UsergroupRequest{ID: g.ID, Name: "Testing", Handle: "testing", Description: "Testers"}); err != nil {
+		t.Fatalf("Failed to update usergroup: %v", err)
+	}
+	if err := c.DisableUsergroup(ctx, g.ID); err != nil {
+		t.Fatalf("Failed to disable usergroup: %v", err)
+	}
+
+	users, err := c.ListUsergroupUsers(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("Failed to list members: %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected two members, got %v", users)
+	}
+	for _, includeDisabled := range []bool{false, true} {
+		groups, err := c.ListUsergroups(ctx, slack.ListUsergroupsRequest{IncludeUsers: true, IncludeDisabled: includeDisabled})
+		if err != nil {
+			t.Fatalf("Failed to list usergroups: %v", err)
+		}
+		if !includeDisabled && len(groups) != 0 {
+			t.Errorf("Expected disabled usergroups to be hidden, got %v", groups)
+		}
+		if includeDisabled && (len(groups) != 1 || groups[0].Description != "Testers" || len(groups[0].Users) != 2) {
+			t.Errorf("Expected the updated usergroup with two members, got %+v", groups)
+		}
+	}
+}
+
+func TestBookmarksPinsAndUpdates(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "sig-testing", IsMember: true})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	me, err := c.AuthTest(ctx)
+	if err != nil {
+		t.Fatalf("Failed to check identity: %v", err)
+	}
+	posted, err := c.PostMessage(ctx, slack.PostMessageRequest{Channel: channel, Text: "notes"})
+	if err != nil {
+		t.Fatalf("Failed to post message: %v", err)
+	}
+	if err := c.PinMessage(ctx, channel, posted.TS); err != nil {
+		t.Fatalf("Failed to pin message: %v", err)
+	}
+	if err := c.UpdateMessage(ctx, slack.UpdateMessageRequest{Channel: channel, TS: posted.TS, Text: "new notes"}); err != nil {
+		t.Fatalf("Failed to update message: %v", err)
+	}
+	pins, err := c.ListPins(ctx, channel)
+	if err != nil {
+		t.Fatalf("Failed to list pins: %v", err)
+	}
+	if len(pins) != 1 || pins[0].Message == nil || pins[0].Message.Text != "new notes" || pins[0].Message.User != me.UserID {
+		t.Errorf("Expected our updated message to be pinned, got %+v", pins)
+	}
+
+	added, err := c.AddBookmark(ctx, slack.BookmarkRequest{Channel: channel, Title: "Notes", Link: "https://k8s.io/notes"})
+	if err != nil {
+		t.Fatalf("Failed to add bookmark: %v", err)
+	}
+	if _, err := c.EditBookmark(ctx, slack.BookmarkRequest{ID: added.ID, Channel: channel, Title: "Notes", Link: "https://k8s.io/new-notes", Emoji: ":memo:"}); err != nil {
+		t.Fatalf("Failed to edit bookmark: %v", err)
+	}
+	bookmarks, err := c.ListBookmarks(ctx, channel)
+	if err != nil {
+		t.Fatalf("Failed to list bookmarks: %v", err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Link != "https://k8s.io/new-notes" || bookmarks[0].Emoji != ":memo:" {
+		t.Errorf("Expected the edited bookmark, got %+v", bookmarks)
+	}
+	if err := c.RemoveBookmark(ctx, channel, added.ID); err != nil {
+		t.Errorf("Failed to remove bookmark: %v", err)
+	}
+	if b := s.Bookmarks(channel); len(b) != 0 {
+		t.Errorf("Expected no bookmarks, got %+v", b)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalAddsTypes(t *testing.T) {
+	view := NewModal("callback", "Title",
+		NewHeader("Header"),
+		&Section{Text: Markdown("*hi*"), Accessory: NewButton("button", "Click", "v")},
+		Divider{},
+		NewInput("input", "Label", &PlainTextInput{ActionID: "text"}),
+	)
+	b, err := json.Marshal(view)
+	if err != nil {
+		t.Fatalf("Failed to marshal view: %v", err)
+	}
+	expected := `{"type":"modal","title":{"type":"plain_text","text":"Title"},"blocks":[` +
+		`{"type":"header","text":{"type":"plain_text","text":"Header"}},` +
+		`{"type":"section","text":{"type":"mrkdwn","text":"*hi*"},"accessory":{"type":"button","text":{"type":"plain_text","text":"Click"},"action_id":"button","value":"v"}},` +
+		`{"type":"divider"},` +
+		`{"type":"input","label":{"type":"plain_text","text":"Label"},"element":{"type":"plain_text_input","action_id":"text"},"block_id":"input"}` +
+		`],"callback_id":"callback"}`
+	if string(b) != expected {
+		t.Errorf("Unexpected JSON.\nExpected: %s\nGot:      %s", expected, b)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := make([]Block, MaxMessageBlocks+1)
+	for i := range tooMany {
+		tooMany[i] = Divider{}
+	}
+
+	tests := []struct {
+		name        string
+		view        *View
+		blocks      []Block
+		expectedErr string
+	}{
+		{
+			name: "valid modal",
+			view: &View{Type: ModalType, Title: PlainText("Report"), Submit: PlainText("Send"), Blocks: []Block{
+				NewInput("why", "Why?", &PlainTextInput{ActionID: "why", Multiline: true}),
+				NewInput("anon", "Anonymous?", &RadioButtons{ActionID: "anon", Options: []Option{NewOption("No", "no"), NewOption("Yes", "yes")}}),
+			}},
+		},
+		{
+			name:        "modal title too long",
+			view:        NewModal("c", strings.Repeat("x", 25)),
+			expectedErr: "title: at most 24 characters are permitted, got 25",
+		},
+		{
+			name:        "markdown title",
+			view:        &View{Type: ModalType, Title: Markdown("*title*")},
+			expectedErr: "title: must be plain_text, not mrkdwn",
+		},
+		{
+			name:        "inputs without submit",
+			view:        NewModal("c", "Title", NewInput("i", "Label", &PlainTextInput{})),
+			expectedErr: "submit: required in modals containing input blocks",
+		},
+		{
+			name:        "section text too long",
+			blocks:      []Block{NewSection(strings.Repeat("é", 3001))},
+			expectedErr: "blocks[0] (section): text: at most 3000 characters are permitted, got 3001",
+		},
+		{
+			name:        "empty section",
+			blocks:      []Block{&Section{}},
+			expectedErr: "blocks[0] (section): one of text or fields is required",
+		},
+		{
+			name:        "too many blocks",
+			blocks:      tooMany,
+			expectedErr: "blocks: at most 50 blocks are permitted, got 51",
+		},
+		{
+			name:        "duplicate block IDs",
+			blocks:      []Block{&Divider{BlockID: "a"}, Divider{BlockID: "a"}},
+			expectedErr: `blocks[1] (divider): block_id "a" is not unique`,
+		},
+		{
+			name:        "button in input block",
+			blocks:      []Block{NewInput("i", "Label", NewButton("b", "Click", ""))},
+			expectedErr: "blocks[0] (input): element: button elements are not permitted in input blocks",
+		},
+		{
+			name:        "option value too long",
+			blocks:      []Block{&Actions{Elements: []Element{&StaticSelect{Options: []Option{NewOption("a", strings.Repeat("v", 151))}}}}},
+			expectedErr: "blocks[0] (actions): elements[0] (static_select): options[0].value: at most 150 characters are permitted, got 151",
+		},
+		{
+			name:        "bad button style",
+			blocks:      []Block{&Actions{Elements: []Element{&Button{Text: PlainText("Go"), Style: "loud"}}}},
+			expectedErr: `blocks[0] (actions): elements[0] (button): style: must be "primary" or "danger", got "loud"`,
+		},
+		{
+			name:        "bad context element",
+			blocks:      []Block{&Context{Elements: []interface{}{"text"}}},
+			expectedErr: "blocks[0] (context): elements[0]: must be a *Text or *ImageElement, got string",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			if tc.view != nil {
+				err = tc.view.Validate()
+			} else {
+				err = ValidateMessage(tc.blocks)
+			}
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("Expected error %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestStateValues(t *testing.T) {
+	payload := `{
+		"id": "V123",
+		"callback_id": "report",
+		"private_metadata": "meta",
+		"state": {"values": {
+			"message": {"text": {"type": "plain_text_input", "value": "spam!"}},
+			"anonymous": {"choice": {"type": "radio_buttons", "selected_option": {"text": {"type": "plain_text", "text": "Yes"}, "value": "yes"}}},
+			"channels": {"pick": {"type": "multi_channels_select", "selected_channels": ["C1", "C2"]}},
+			"empty": {"pick": {"type": "users_select", "selected_user": null}}
+		}}
+	}`
+	view := ViewPayload{}
+	if err := json.Unmarshal([]byte(payload), &view); err != nil {
+		t.Fatalf("Failed to unmarshal view: %v", err)
+	}
+	if view.ID != "V123" || view.CallbackID != "report" || view.PrivateMetadata != "meta" {
+		t.Errorf("Unexpected view metadata: %+v", view)
+	}
+
+	tests := []struct {
+		block, action string
+		expected      string
+		selected      int
+	}{
+		{"message", "text", "spam!", 1},
+		{"anonymous", "choice", "yes", 1},
+		{"channels", "pick", "C1", 2},
+		{"empty", "pick", "", 0},
+		{"missing", "pick", "", 0},
+	}
+	for _, tc := range tests {
+		if s := view.State.StringValue(tc.block, tc.action); s != tc.expected {
+			t.Errorf("%s/%s: expected %q, got %q", tc.block, tc.action, tc.expected, s)
+		}
+		v, _ := view.State.Get(tc.block, tc.action)
+		if n := len(v.Selected()); n != tc.selected {
+			t.Errorf("%s/%s: expected %d selected items, got %d", tc.block, tc.action, tc.selected, n)
+		}
+	}
+}
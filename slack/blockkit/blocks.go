@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Block is a Block Kit layout block, as included in messages, modals and the App Home.
+type Block interface {
+	// BlockType returns the block's type, as sent in its "type" field.
+	BlockType() string
+	blockID() string
+	validate() error
+}
+
+// Section is a block of text, optionally with fields and an accessory element.
+type Section struct {
+	Text      *Text   `json:"text,omitempty"`
+	BlockID   string  `json:"block_id,omitempty"`
+	Fields    []*Text `json:"fields,omitempty"`
+	Accessory Element `json:"accessory,omitempty"`
+}
+
+// NewSection returns a section containing the given mrkdwn text.
+func NewSection(text string) *Section {
+	return &Section{Text: Markdown(text)}
+}
+
+func (b Section) blockID() string { return b.BlockID }
+
+func (Section) BlockType() string { return "section" }
+
+func (b Section) MarshalJSON() ([]byte, error) {
+	type section Section
+	return marshalWithType(b.BlockType(), section(b))
+}
+
+// Divider is a horizontal rule.
+type Divider struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+func (b Divider) blockID() string { return b.BlockID }
+
+func (Divider) BlockType() string { return "divider" }
+
+func (b Divider) MarshalJSON() ([]byte, error) {
+	type divider Divider
+	return marshalWithType(b.BlockType(), divider(b))
+}
+
+// Header is a block of large, bold plain text.
+type Header struct {
+	Text    *Text  `json:"text"`
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// NewHeader returns a header containing the given plain text.
+func NewHeader(text string) *Header {
+	return &Header{Text: PlainText(text)}
+}
+
+func (b Header) blockID() string { return b.BlockID }
+
+func (Header) BlockType() string { return "header" }
+
+func (b Header) MarshalJSON() ([]byte, error) {
+	type header Header
+	return marshalWithType(b.BlockType(), header(b))
+}
+
+// Context is a block of small text and images. Its elements must each be a *Text or an
+// *ImageElement.
+type Context struct {
+	Elements []interface{} `json:"elements"`
+	BlockID  string        `json:"block_id,omitempty"`
+}
+
+func (b Context) blockID() string { return b.BlockID }
+
+func (Context) BlockType() string { return "context" }
+
+func (b Context) MarshalJSON() ([]byte, error) {
+	type context Context
+	return marshalWithType(b.BlockType(), context(b))
+}
+
+// Actions is a block of interactive elements.
+type Actions struct {
+	Elements []Element `json:"elements"`
+	BlockID  string    `json:"block_id,omitempty"`
+}
+
+func (b Actions) blockID() string { return b.BlockID }
+
+func (Actions) BlockType() string { return "actions" }
+
+func (b Actions) MarshalJSON() ([]byte, error) {
+	type actions Actions
+	return marshalWithType(b.BlockType(), actions(b))
+}
+
+// Input is a block that collects information from a user with a single element.
+type Input struct {
+	Label          *Text   `json:"label"`
+	Element        Element `json:"element"`
+	BlockID        string  `json:"block_id,omitempty"`
+	Hint           *Text   `json:"hint,omitempty"`
+	Optional       bool    `json:"optional,omitempty"`
+	DispatchAction bool    `json:"dispatch_action,omitempty"`
+}
+
+// NewInput returns an input block with the given plain text label.
+func NewInput(blockID, label string, element Element) *Input {
+	return &Input{BlockID: blockID, Label: PlainText(label), Element: element}
+}
+
+func (b Input) blockID() string { return b.BlockID }
+
+func (Input) BlockType() string { return "input" }
+
+func (b Input) MarshalJSON() ([]byte, error) {
+	type input Input
+	return marshalWithType(b.BlockType(), input(b))
+}
+
+// Image is a block containing a single image.
+type Image struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+	Title    *Text  `json:"title,omitempty"`
+	BlockID  string `json:"block_id,omitempty"`
+}
+
+func (b Image) blockID() string { return b.BlockID }
+
+func (Image) BlockType() string { return "image" }
+
+func (b Image) MarshalJSON() ([]byte, error) {
+	type image Image
+	return marshalWithType(b.BlockType(), image(b))
+}
+
+// marshalWithType marshals v, which must marshal to a JSON object, and adds a "type" field.
+func marshalWithType(t string, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 2 || b[0] != '{' {
+		return nil, fmt.Errorf("%s didn't marshal to a JSON object", t)
+	}
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"type":`)
+	typ, _ := json.Marshal(t)
+	buf.Write(typ)
+	if len(b) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(b[1:])
+	return buf.Bytes(), nil
+}
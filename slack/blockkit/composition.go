@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package blockkit models Slack's Block Kit UI framework: the blocks, elements and composition
+// objects that make up rich messages and modals, and the state Slack sends back when a user
+// interacts with them. See https://api.slack.com/block-kit.
+//
+// Types here mirror Slack's JSON closely, so Slack's reference documentation applies directly.
+// Call Validate before sending to catch violations of Slack's limits, which Slack otherwise
+// reports only as an unhelpful invalid_blocks error.
+package blockkit
+
+const (
+	// PlainTextType is the type of Text objects containing plain text.
+	PlainTextType = "plain_text"
+	// MarkdownType is the type of Text objects containing Slack's mrkdwn.
+	MarkdownType = "mrkdwn"
+)
+
+// Text represents a text composition object.
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+// PlainText returns a plain text object.
+func PlainText(text string) *Text {
+	return &Text{Type: PlainTextType, Text: text}
+}
+
+// Markdown returns a mrkdwn text object.
+func Markdown(text string) *Text {
+	return &Text{Type: MarkdownType, Text: text}
+}
+
+// Option represents an option composition object, used in selects, checkboxes, radio buttons
+// and overflow menus.
+type Option struct {
+	Text        *Text  `json:"text"`
+	Value       string `json:"value"`
+	Description *Text  `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// NewOption returns an option with the given plain text label and value.
+func NewOption(text, value string) Option {
+	return Option{Text: PlainText(text), Value: value}
+}
+
+// OptionGroup represents an option group composition object.
+type OptionGroup struct {
+	Label   *Text    `json:"label"`
+	Options []Option `json:"options"`
+}
+
+// Confirm represents a confirmation dialog composition object.
+type Confirm struct {
+	Title   *Text  `json:"title"`
+	Text    *Text  `json:"text"`
+	Confirm *Text  `json:"confirm"`
+	Deny    *Text  `json:"deny"`
+	Style   string `json:"style,omitempty"`
+}
+
+// Button styles.
+const (
+	StylePrimary = "primary"
+	StyleDanger  = "danger"
+)
@@ -0,0 +1,248 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+// Element is an interactive Block Kit element, as included in section accessories, actions
+// blocks and input blocks.
+type Element interface {
+	// ElementType returns the element's type, as sent in its "type" field.
+	ElementType() string
+	validate() error
+}
+
+// Button is a button that sends an interaction, or opens a URL.
+type Button struct {
+	Text     *Text    `json:"text"`
+	ActionID string   `json:"action_id,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Style    string   `json:"style,omitempty"`
+	Confirm  *Confirm `json:"confirm,omitempty"`
+}
+
+// NewButton returns a button with the given plain text label.
+func NewButton(actionID, text, value string) *Button {
+	return &Button{ActionID: actionID, Text: PlainText(text), Value: value}
+}
+
+func (Button) ElementType() string { return "button" }
+
+func (e Button) MarshalJSON() ([]byte, error) {
+	type button Button
+	return marshalWithType(e.ElementType(), button(e))
+}
+
+// PlainTextInput is a free text field.
+type PlainTextInput struct {
+	ActionID     string `json:"action_id,omitempty"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+	MinLength    int    `json:"min_length,omitempty"`
+	MaxLength    int    `json:"max_length,omitempty"`
+}
+
+func (PlainTextInput) ElementType() string { return "plain_text_input" }
+
+func (e PlainTextInput) MarshalJSON() ([]byte, error) {
+	type plainTextInput PlainTextInput
+	return marshalWithType(e.ElementType(), plainTextInput(e))
+}
+
+// StaticSelect is a menu of options to pick one from.
+type StaticSelect struct {
+	ActionID      string        `json:"action_id,omitempty"`
+	Placeholder   *Text         `json:"placeholder,omitempty"`
+	Options       []Option      `json:"options,omitempty"`
+	OptionGroups  []OptionGroup `json:"option_groups,omitempty"`
+	InitialOption *Option       `json:"initial_option,omitempty"`
+	Confirm       *Confirm      `json:"confirm,omitempty"`
+}
+
+func (StaticSelect) ElementType() string { return "static_select" }
+
+func (e StaticSelect) MarshalJSON() ([]byte, error) {
+	type staticSelect StaticSelect
+	return marshalWithType(e.ElementType(), staticSelect(e))
+}
+
+// MultiStaticSelect is a menu of options to pick any number from.
+type MultiStaticSelect struct {
+	ActionID         string        `json:"action_id,omitempty"`
+	Placeholder      *Text         `json:"placeholder,omitempty"`
+	Options          []Option      `json:"options,omitempty"`
+	OptionGroups     []OptionGroup `json:"option_groups,omitempty"`
+	InitialOptions   []Option      `json:"initial_options,omitempty"`
+	MaxSelectedItems int           `json:"max_selected_items,omitempty"`
+	Confirm          *Confirm      `json:"confirm,omitempty"`
+}
+
+func (MultiStaticSelect) ElementType() string { return "multi_static_select" }
+
+func (e MultiStaticSelect) MarshalJSON() ([]byte, error) {
+	type multiStaticSelect MultiStaticSelect
+	return marshalWithType(e.ElementType(), multiStaticSelect(e))
+}
+
+// UsersSelect is a menu of the workspace's users to pick one from.
+type UsersSelect struct {
+	ActionID    string   `json:"action_id,omitempty"`
+	Placeholder *Text    `json:"placeholder,omitempty"`
+	InitialUser string   `json:"initial_user,omitempty"`
+	Confirm     *Confirm `json:"confirm,omitempty"`
+}
+
+func (UsersSelect) ElementType() string { return "users_select" }
+
+func (e UsersSelect) MarshalJSON() ([]byte, error) {
+	type usersSelect UsersSelect
+	return marshalWithType(e.ElementType(), usersSelect(e))
+}
+
+// MultiUsersSelect is a menu of the workspace's users to pick any number from.
+type MultiUsersSelect struct {
+	ActionID         string   `json:"action_id,omitempty"`
+	Placeholder      *Text    `json:"placeholder,omitempty"`
+	InitialUsers     []string `json:"initial_users,omitempty"`
+	MaxSelectedItems int      `json:"max_selected_items,omitempty"`
+	Confirm          *Confirm `json:"confirm,omitempty"`
+}
+
+func (MultiUsersSelect) ElementType() string { return "multi_users_select" }
+
+func (e MultiUsersSelect) MarshalJSON() ([]byte, error) {
+	type multiUsersSelect MultiUsersSelect
+	return marshalWithType(e.ElementType(), multiUsersSelect(e))
+}
+
+// ChannelsSelect is a menu of public channels to pick one from.
+type ChannelsSelect struct {
+	ActionID       string   `json:"action_id,omitempty"`
+	Placeholder    *Text    `json:"placeholder,omitempty"`
+	InitialChannel string   `json:"initial_channel,omitempty"`
+	Confirm        *Confirm `json:"confirm,omitempty"`
+}
+
+func (ChannelsSelect) ElementType() string { return "channels_select" }
+
+func (e ChannelsSelect) MarshalJSON() ([]byte, error) {
+	type channelsSelect ChannelsSelect
+	return marshalWithType(e.ElementType(), channelsSelect(e))
+}
+
+// MultiChannelsSelect is a menu of public channels to pick any number from.
+type MultiChannelsSelect struct {
+	ActionID         string   `json:"action_id,omitempty"`
+	Placeholder      *Text    `json:"placeholder,omitempty"`
+	InitialChannels  []string `json:"initial_channels,omitempty"`
+	MaxSelectedItems int      `json:"max_selected_items,omitempty"`
+	Confirm          *Confirm `json:"confirm,omitempty"`
+}
+
+func (MultiChannelsSelect) ElementType() string { return "multi_channels_select" }
+
+func (e MultiChannelsSelect) MarshalJSON() ([]byte, error) {
+	type multiChannelsSelect MultiChannelsSelect
+	return marshalWithType(e.ElementType(), multiChannelsSelect(e))
+}
+
+// ConversationsSelect is a menu of conversations of any type to pick one from.
+type ConversationsSelect struct {
+	ActionID            string   `json:"action_id,omitempty"`
+	Placeholder         *Text    `json:"placeholder,omitempty"`
+	InitialConversation string   `json:"initial_conversation,omitempty"`
+	Confirm             *Confirm `json:"confirm,omitempty"`
+}
+
+func (ConversationsSelect) ElementType() string { return "conversations_select" }
+
+func (e ConversationsSelect) MarshalJSON() ([]byte, error) {
+	type conversationsSelect ConversationsSelect
+	return marshalWithType(e.ElementType(), conversationsSelect(e))
+}
+
+// Checkboxes is a group of options to tick any number of.
+type Checkboxes struct {
+	ActionID       string   `json:"action_id,omitempty"`
+	Options        []Option `json:"options"`
+	InitialOptions []Option `json:"initial_options,omitempty"`
+	Confirm        *Confirm `json:"confirm,omitempty"`
+}
+
+func (Checkboxes) ElementType() string { return "checkboxes" }
+
+func (e Checkboxes) MarshalJSON() ([]byte, error) {
+	type checkboxes Checkboxes
+	return marshalWithType(e.ElementType(), checkboxes(e))
+}
+
+// RadioButtons is a group of options to pick one from.
+type RadioButtons struct {
+	ActionID      string   `json:"action_id,omitempty"`
+	Options       []Option `json:"options"`
+	InitialOption *Option  `json:"initial_option,omitempty"`
+	Confirm       *Confirm `json:"confirm,omitempty"`
+}
+
+func (RadioButtons) ElementType() string { return "radio_buttons" }
+
+func (e RadioButtons) MarshalJSON() ([]byte, error) {
+	type radioButtons RadioButtons
+	return marshalWithType(e.ElementType(), radioButtons(e))
+}
+
+// DatePicker is a calendar to pick a date from. Dates are formatted as YYYY-MM-DD.
+type DatePicker struct {
+	ActionID    string   `json:"action_id,omitempty"`
+	Placeholder *Text    `json:"placeholder,omitempty"`
+	InitialDate string   `json:"initial_date,omitempty"`
+	Confirm     *Confirm `json:"confirm,omitempty"`
+}
+
+func (DatePicker) ElementType() string { return "datepicker" }
+
+func (e DatePicker) MarshalJSON() ([]byte, error) {
+	type datePicker DatePicker
+	return marshalWithType(e.ElementType(), datePicker(e))
+}
+
+// Overflow is a compact menu of up to five options.
+type Overflow struct {
+	ActionID string   `json:"action_id,omitempty"`
+	Options  []Option `json:"options"`
+	Confirm  *Confirm `json:"confirm,omitempty"`
+}
+
+func (Overflow) ElementType() string { return "overflow" }
+
+func (e Overflow) MarshalJSON() ([]byte, error) {
+	type overflow Overflow
+	return marshalWithType(e.ElementType(), overflow(e))
+}
+
+// ImageElement is a small image, for use as a section accessory or in a context block.
+type ImageElement struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+func (ImageElement) ElementType() string { return "image" }
+
+func (e ImageElement) MarshalJSON() ([]byte, error) {
+	type imageElement ImageElement
+	return marshalWithType(e.ElementType(), imageElement(e))
+}
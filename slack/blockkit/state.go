@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+// State is the state of the input elements in a view, as found in view.state of view_submission
+// and block_actions interactions.
+type State struct {
+	// Values maps block IDs to action IDs to the value of each element.
+	Values map[string]map[string]Value `json:"values"`
+}
+
+// Value is the state of a single input element. Which fields are set depends on the element's
+// type.
+type Value struct {
+	Type                  string   `json:"type"`
+	Value                 string   `json:"value,omitempty"`
+	SelectedOption        *Option  `json:"selected_option,omitempty"`
+	SelectedOptions       []Option `json:"selected_options,omitempty"`
+	SelectedUser          string   `json:"selected_user,omitempty"`
+	SelectedUsers         []string `json:"selected_users,omitempty"`
+	SelectedChannel       string   `json:"selected_channel,omitempty"`
+	SelectedChannels      []string `json:"selected_channels,omitempty"`
+	SelectedConversation  string   `json:"selected_conversation,omitempty"`
+	SelectedConversations []string `json:"selected_conversations,omitempty"`
+	SelectedDate          string   `json:"selected_date,omitempty"`
+}
+
+// Get returns the value of the element with the given action ID in the given block.
+func (s State) Get(blockID, actionID string) (Value, bool) {
+	v, ok := s.Values[blockID][actionID]
+	return v, ok
+}
+
+// StringValue returns the value of a plain text input, or the single selected item of any other
+// element, or "" if the element has no value.
+func (s State) StringValue(blockID, actionID string) string {
+	v, _ := s.Get(blockID, actionID)
+	if selected := v.Selected(); len(selected) > 0 {
+		return selected[0]
+	}
+	return ""
+}
+
+// Selected returns the values of all the items selected in the element, whatever its type. For
+// a plain text input, this is the entered text.
+func (v Value) Selected() []string {
+	var selected []string
+	for _, s := range []string{v.Value, v.SelectedUser, v.SelectedChannel, v.SelectedConversation, v.SelectedDate} {
+		if s != "" {
+			selected = append(selected, s)
+		}
+	}
+	if v.SelectedOption != nil {
+		selected = append(selected, v.SelectedOption.Value)
+	}
+	for _, o := range v.SelectedOptions {
+		selected = append(selected, o.Value)
+	}
+	selected = append(selected, v.SelectedUsers...)
+	selected = append(selected, v.SelectedChannels...)
+	selected = append(selected, v.SelectedConversations...)
+	return selected
+}
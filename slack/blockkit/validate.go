@@ -0,0 +1,448 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// MaxMessageBlocks is the most blocks a message can contain.
+	MaxMessageBlocks = 50
+	// MaxViewBlocks is the most blocks a modal or App Home view can contain.
+	MaxViewBlocks = 100
+)
+
+// ValidateMessage checks that blocks are within Slack's limits for a message.
+func ValidateMessage(blocks []Block) error {
+	return validateBlocks(blocks, MaxMessageBlocks)
+}
+
+// Validate checks that the view is within Slack's limits.
+func (v *View) Validate() error {
+	if v.Type != ModalType && v.Type != HomeType {
+		return fmt.Errorf("type: unknown view type %q", v.Type)
+	}
+	if v.Type == ModalType {
+		if err := checkText("title", v.Title, 24, true, true); err != nil {
+			return err
+		}
+	}
+	if err := checkText("close", v.Close, 24, true, false); err != nil {
+		return err
+	}
+	if err := checkText("submit", v.Submit, 24, true, false); err != nil {
+		return err
+	}
+	if err := checkString("private_metadata", v.PrivateMetadata, 3000); err != nil {
+		return err
+	}
+	if err := checkString("callback_id", v.CallbackID, 255); err != nil {
+		return err
+	}
+	if err := checkString("external_id", v.ExternalID, 255); err != nil {
+		return err
+	}
+	if v.Type == ModalType && v.Submit == nil {
+		for _, b := range v.Blocks {
+			if b != nil && b.BlockType() == "input" {
+				return fmt.Errorf("submit: required in modals containing input blocks")
+			}
+		}
+	}
+	return validateBlocks(v.Blocks, MaxViewBlocks)
+}
+
+func validateBlocks(blocks []Block, max int) error {
+	if len(blocks) > max {
+		return fmt.Errorf("blocks: at most %d blocks are permitted, got %d", max, len(blocks))
+	}
+	ids := map[string]bool{}
+	for i, b := range blocks {
+		if b == nil {
+			return fmt.Errorf("blocks[%d]: block is nil", i)
+		}
+		if err := b.validate(); err != nil {
+			return fmt.Errorf("blocks[%d] (%s): %v", i, b.BlockType(), err)
+		}
+		if id := b.blockID(); id != "" {
+			if ids[id] {
+				return fmt.Errorf("blocks[%d] (%s): block_id %q is not unique", i, b.BlockType(), id)
+			}
+			ids[id] = true
+		}
+	}
+	return nil
+}
+
+func (b Section) validate() error {
+	if b.Text == nil && len(b.Fields) == 0 {
+		return fmt.Errorf("one of text or fields is required")
+	}
+	if err := checkText("text", b.Text, 3000, false, false); err != nil {
+		return err
+	}
+	if len(b.Fields) > 10 {
+		return fmt.Errorf("fields: at most 10 are permitted, got %d", len(b.Fields))
+	}
+	for i, f := range b.Fields {
+		if err := checkText(fmt.Sprintf("fields[%d]", i), f, 2000, false, true); err != nil {
+			return err
+		}
+	}
+	if err := checkString("block_id", b.BlockID, 255); err != nil {
+		return err
+	}
+	if b.Accessory != nil {
+		if err := b.Accessory.validate(); err != nil {
+			return fmt.Errorf("accessory (%s): %v", b.Accessory.ElementType(), err)
+		}
+	}
+	return nil
+}
+
+func (b Divider) validate() error {
+	return checkString("block_id", b.BlockID, 255)
+}
+
+func (b Header) validate() error {
+	if err := checkText("text", b.Text, 150, true, true); err != nil {
+		return err
+	}
+	return checkString("block_id", b.BlockID, 255)
+}
+
+func (b Context) validate() error {
+	if len(b.Elements) == 0 {
+		return fmt.Errorf("elements: at least one is required")
+	}
+	if len(b.Elements) > 10 {
+		return fmt.Errorf("elements: at most 10 are permitted, got %d", len(b.Elements))
+	}
+	for i, e := range b.Elements {
+		field := fmt.Sprintf("elements[%d]", i)
+		switch e := e.(type) {
+		case *Text:
+			if err := checkText(field, e, 3000, false, true); err != nil {
+				return err
+			}
+		case *ImageElement:
+			if err := e.validate(); err != nil {
+				return fmt.Errorf("%s: %v", field, err)
+			}
+		default:
+			return fmt.Errorf("%s: must be a *Text or *ImageElement, got %T", field, e)
+		}
+	}
+	return checkString("block_id", b.BlockID, 255)
+}
+
+func (b Actions) validate() error {
+	if len(b.Elements) == 0 {
+		return fmt.Errorf("elements: at least one is required")
+	}
+	if len(b.Elements) > 25 {
+		return fmt.Errorf("elements: at most 25 are permitted, got %d", len(b.Elements))
+	}
+	for i, e := range b.Elements {
+		if e == nil {
+			return fmt.Errorf("elements[%d]: element is nil", i)
+		}
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("elements[%d] (%s): %v", i, e.ElementType(), err)
+		}
+	}
+	return checkString("block_id", b.BlockID, 255)
+}
+
+// inputElements are the element types permitted in input blocks.
+var inputElements = map[string]bool{
+	"plain_text_input":      true,
+	"static_select":         true,
+	"multi_static_select":   true,
+	"users_select":          true,
+	"multi_users_select":    true,
+	"channels_select":       true,
+	"multi_channels_select": true,
+	"conversations_select":  true,
+	"checkboxes":            true,
+	"radio_buttons":         true,
+	"datepicker":            true,
+}
+
+func (b Input) validate() error {
+	if err := checkText("label", b.Label, 2000, true, true); err != nil {
+		return err
+	}
+	if err := checkText("hint", b.Hint, 2000, true, false); err != nil {
+		return err
+	}
+	if b.Element == nil {
+		return fmt.Errorf("element: required")
+	}
+	if !inputElements[b.Element.ElementType()] {
+		return fmt.Errorf("element: %s elements are not permitted in input blocks", b.Element.ElementType())
+	}
+	if err := b.Element.validate(); err != nil {
+		return fmt.Errorf("element (%s): %v", b.Element.ElementType(), err)
+	}
+	return checkString("block_id", b.BlockID, 255)
+}
+
+func (b Image) validate() error {
+	if err := checkRequiredString("image_url", b.ImageURL, 3000); err != nil {
+		return err
+	}
+	if err := checkRequiredString("alt_text", b.AltText, 2000); err != nil {
+		return err
+	}
+	if err := checkText("title", b.Title, 2000, true, false); err != nil {
+		return err
+	}
+	return checkString("block_id", b.BlockID, 255)
+}
+
+func (e Button) validate() error {
+	if err := checkText("text", e.Text, 75, true, true); err != nil {
+		return err
+	}
+	if err := checkString("action_id", e.ActionID, 255); err != nil {
+		return err
+	}
+	if err := checkString("url", e.URL, 3000); err != nil {
+		return err
+	}
+	if err := checkString("value", e.Value, 2000); err != nil {
+		return err
+	}
+	if e.Style != "" && e.Style != StylePrimary && e.Style != StyleDanger {
+		return fmt.Errorf("style: must be %q or %q, got %q", StylePrimary, StyleDanger, e.Style)
+	}
+	return checkConfirm(e.Confirm)
+}
+
+func (e PlainTextInput) validate() error {
+	if err := checkString("action_id", e.ActionID, 255); err != nil {
+		return err
+	}
+	if err := checkText("placeholder", e.Placeholder, 150, true, false); err != nil {
+		return err
+	}
+	if e.MinLength < 0 || e.MinLength > 3000 {
+		return fmt.Errorf("min_length: must be between 0 and 3000, got %d", e.MinLength)
+	}
+	if e.MaxLength < 0 || e.MaxLength > 3000 {
+		return fmt.Errorf("max_length: must be between 0 and 3000, got %d", e.MaxLength)
+	}
+	if e.MaxLength > 0 && e.MinLength > e.MaxLength {
+		return fmt.Errorf("min_length: %d is greater than max_length %d", e.MinLength, e.MaxLength)
+	}
+	return nil
+}
+
+func (e StaticSelect) validate() error {
+	if err := checkSelect(e.ActionID, e.Placeholder, e.Confirm); err != nil {
+		return err
+	}
+	return checkOptionsOrGroups(e.Options, e.OptionGroups)
+}
+
+func (e MultiStaticSelect) validate() error {
+	if err := checkSelect(e.ActionID, e.Placeholder, e.Confirm); err != nil {
+		return err
+	}
+	if e.MaxSelectedItems < 0 {
+		return fmt.Errorf("max_selected_items: must not be negative")
+	}
+	return checkOptionsOrGroups(e.Options, e.OptionGroups)
+}
+
+func (e UsersSelect) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e MultiUsersSelect) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e ChannelsSelect) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e MultiChannelsSelect) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e ConversationsSelect) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e Checkboxes) validate() error {
+	if err := checkString("action_id", e.ActionID, 255); err != nil {
+		return err
+	}
+	if err := checkOptions("options", e.Options, 1, 10); err != nil {
+		return err
+	}
+	return checkConfirm(e.Confirm)
+}
+
+func (e RadioButtons) validate() error {
+	if err := checkString("action_id", e.ActionID, 255); err != nil {
+		return err
+	}
+	if err := checkOptions("options", e.Options, 1, 10); err != nil {
+		return err
+	}
+	return checkConfirm(e.Confirm)
+}
+
+func (e DatePicker) validate() error {
+	return checkSelect(e.ActionID, e.Placeholder, e.Confirm)
+}
+
+func (e Overflow) validate() error {
+	if err := checkString("action_id", e.ActionID, 255); err != nil {
+		return err
+	}
+	if err := checkOptions("options", e.Options, 2, 5); err != nil {
+		return err
+	}
+	return checkConfirm(e.Confirm)
+}
+
+func (e ImageElement) validate() error {
+	if err := checkRequiredString("image_url", e.ImageURL, 3000); err != nil {
+		return err
+	}
+	return checkRequiredString("alt_text", e.AltText, 2000)
+}
+
+func checkSelect(actionID string, placeholder *Text, confirm *Confirm) error {
+	if err := checkString("action_id", actionID, 255); err != nil {
+		return err
+	}
+	if err := checkText("placeholder", placeholder, 150, true, false); err != nil {
+		return err
+	}
+	return checkConfirm(confirm)
+}
+
+func checkOptionsOrGroups(options []Option, groups []OptionGroup) error {
+	if len(options) > 0 && len(groups) > 0 {
+		return fmt.Errorf("only one of options and option_groups is permitted")
+	}
+	if len(groups) == 0 {
+		return checkOptions("options", options, 1, 100)
+	}
+	if len(groups) > 100 {
+		return fmt.Errorf("option_groups: at most 100 are permitted, got %d", len(groups))
+	}
+	for i, g := range groups {
+		field := fmt.Sprintf("option_groups[%d]", i)
+		if err := checkText(field+".label", g.Label, 75, true, true); err != nil {
+			return err
+		}
+		if err := checkOptions(field+".options", g.Options, 1, 100); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkOptions(field string, options []Option, min, max int) error {
+	if len(options) < min || len(options) > max {
+		return fmt.Errorf("%s: between %d and %d are required, got %d", field, min, max, len(options))
+	}
+	for i, o := range options {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		if err := checkText(f+".text", o.Text, 75, false, true); err != nil {
+			return err
+		}
+		if err := checkString(f+".value", o.Value, 150); err != nil {
+			return err
+		}
+		if err := checkText(f+".description", o.Description, 75, true, false); err != nil {
+			return err
+		}
+		if err := checkString(f+".url", o.URL, 3000); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkConfirm(c *Confirm) error {
+	if c == nil {
+		return nil
+	}
+	if err := checkText("confirm.title", c.Title, 100, true, true); err != nil {
+		return err
+	}
+	if err := checkText("confirm.text", c.Text, 300, false, true); err != nil {
+		return err
+	}
+	if err := checkText("confirm.confirm", c.Confirm, 30, true, true); err != nil {
+		return err
+	}
+	if err := checkText("confirm.deny", c.Deny, 30, true, true); err != nil {
+		return err
+	}
+	if c.Style != "" && c.Style != StylePrimary && c.Style != StyleDanger {
+		return fmt.Errorf("confirm.style: must be %q or %q, got %q", StylePrimary, StyleDanger, c.Style)
+	}
+	return nil
+}
+
+// checkText checks that t, if present, is within max characters, and is plain text if
+// plainOnly is set.
+func checkText(field string, t *Text, max int, plainOnly, required bool) error {
+	if t == nil {
+		if required {
+			return fmt.Errorf("%s: required", field)
+		}
+		return nil
+	}
+	switch t.Type {
+	case PlainTextType:
+	case MarkdownType:
+		if plainOnly {
+			return fmt.Errorf("%s: must be %s, not %s", field, PlainTextType, MarkdownType)
+		}
+	default:
+		return fmt.Errorf("%s: unknown text type %q", field, t.Type)
+	}
+	if t.Text == "" {
+		return fmt.Errorf("%s: text must not be empty", field)
+	}
+	return checkString(field, t.Text, max)
+}
+
+func checkString(field, s string, max int) error {
+	if n := utf8.RuneCountInString(s); n > max {
+		return fmt.Errorf("%s: at most %d characters are permitted, got %d", field, max, n)
+	}
+	return nil
+}
+
+func checkRequiredString(field, s string, max int) error {
+	if s == "" {
+		return fmt.Errorf("%s: required", field)
+	}
+	return checkString(field, s, max)
+}
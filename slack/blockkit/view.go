@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockkit
+
+const (
+	// ModalType is the type of views shown as modals.
+	ModalType = "modal"
+	// HomeType is the type of views shown in an app's Home tab.
+	HomeType = "home"
+)
+
+// View represents a modal or App Home view, as sent to views.open, views.push, views.update and
+// views.publish.
+type View struct {
+	Type            string  `json:"type"`
+	Title           *Text   `json:"title,omitempty"`
+	Blocks          []Block `json:"blocks"`
+	Close           *Text   `json:"close,omitempty"`
+	Submit          *Text   `json:"submit,omitempty"`
+	PrivateMetadata string  `json:"private_metadata,omitempty"`
+	CallbackID      string  `json:"callback_id,omitempty"`
+	ClearOnClose    bool    `json:"clear_on_close,omitempty"`
+	NotifyOnClose   bool    `json:"notify_on_close,omitempty"`
+	ExternalID      string  `json:"external_id,omitempty"`
+}
+
+// NewModal returns a modal with the given callback ID, plain text title and blocks.
+func NewModal(callbackID, title string, blocks ...Block) *View {
+	return &View{
+		Type:       ModalType,
+		CallbackID: callbackID,
+		Title:      PlainText(title),
+		Blocks:     blocks,
+	}
+}
+
+// ViewPayload is a view as Slack describes it to us, both in the responses to views.* methods
+// and in view_submission and view_closed interactions. Blocks are omitted; State holds whatever
+// the user entered in them.
+type ViewPayload struct {
+	ID              string `json:"id"`
+	TeamID          string `json:"team_id"`
+	Type            string `json:"type"`
+	CallbackID      string `json:"callback_id"`
+	PrivateMetadata string `json:"private_metadata"`
+	ExternalID      string `json:"external_id"`
+	Hash            string `json:"hash"`
+	RootViewID      string `json:"root_view_id"`
+	PreviousViewID  string `json:"previous_view_id"`
+	State           State  `json:"state"`
+}
+
+// ViewSubmissionResponse is a response to a view_submission interaction, which Slack accepts
+// in the body of our HTTP response.
+type ViewSubmissionResponse struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors,omitempty"`
+	View           *View             `json:"view,omitempty"`
+}
+
+// ErrorsResponse shows the given errors, keyed by block ID, next to the inputs in the
+// submitted view.
+func ErrorsResponse(errors map[string]string) ViewSubmissionResponse {
+	return ViewSubmissionResponse{ResponseAction: "errors", Errors: errors}
+}
+
+// UpdateResponse replaces the submitted view with view.
+func UpdateResponse(view *View) ViewSubmissionResponse {
+	return ViewSubmissionResponse{ResponseAction: "update", View: view}
+}
+
+// ClearResponse closes the submitted view, and any others in its stack.
+func ClearResponse() ViewSubmissionResponse {
+	return ViewSubmissionResponse{ResponseAction: "clear"}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+// Bookmark is a link bookmarked in a conversation.
+type Bookmark struct {
+	ID          string `json:"id"`
+	ChannelID   string `json:"channel_id"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	Emoji       string `json:"emoji"`
+	Type        string `json:"type"`
+	DateCreated int64  `json:"date_created"`
+	DateUpdated int64  `json:"date_updated"`
+}
+
+// BookmarkRequest describes a link bookmark to add with AddBookmark or change with EditBookmark.
+type BookmarkRequest struct {
+	// ID is the bookmark to edit. It is ignored by AddBookmark.
+	ID      string
+	Channel string
+	Title   string
+	Link    string
+	// Emoji is shown next to the bookmark, for example ":memo:".
+	Emoji string
+}
+
+// ListBookmarks returns the bookmarks in the given conversation.
+func (c *Client) ListBookmarks(ctx context.Context, channel string) ([]Bookmark, error) {
+	ret := struct {
+		Bookmarks []Bookmark `json:"bookmarks"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "bookmarks.list", map[string]string{"channel_id": channel}, &ret); err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks in %s: %w", channel, err)
+	}
+	return ret.Bookmarks, nil
+}
+
+// AddBookmark adds a link bookmark to a conversation.
+func (c *Client) AddBookmark(ctx context.Context, req BookmarkRequest) (Bookmark, error) {
+	args := map[string]string{"channel_id": req.Channel, "title": req.Title, "type": "link", "link": req.Link}
+	if req.Emoji != "" {
+		args["emoji"] = req.Emoji
+	}
+	ret := struct {
+		Bookmark Bookmark `json:"bookmark"`
+	}{}
+	if err := c.CallMethodContext(ctx, "bookmarks.add", args, &ret); err != nil {
+		return Bookmark{}, fmt.Errorf("failed to add bookmark %q to %s: %w", req.Title, req.Channel, err)
+	}
+	return ret.Bookmark, nil
+}
+
+// EditBookmark changes the title, link and emoji of a bookmark.
+func (c *Client) EditBookmark(ctx context.Context, req BookmarkRequest) (Bookmark, error) {
+	args := map[string]string{"bookmark_id": req.ID, "channel_id": req.Channel, "title": req.Title, "link": req.Link, "emoji": req.Emoji}
+	ret := struct {
+		Bookmark Bookmark `json:"bookmark"`
+	}{}
+	if err := c.CallMethodContext(ctx, "bookmarks.edit", args, &ret); err != nil {
+		return Bookmark{}, fmt.Errorf("failed to edit bookmark %s in %s: %w", req.ID, req.Channel, err)
+	}
+	return ret.Bookmark, nil
+}
+
+// RemoveBookmark removes a bookmark from a conversation.
+func (c *Client) RemoveBookmark(ctx context.Context, channel, id string) error {
+	if err := c.CallMethodContext(ctx, "bookmarks.remove", map[string]string{"channel_id": channel, "bookmark_id": id}, nil); err != nil {
+		return fmt.Errorf("failed to remove bookmark %s from %s: %w", id, channel, err)
+	}
+	return nil
+}
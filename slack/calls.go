@@ -17,9 +17,8 @@ limitations under the License.
 package slack
 
 import (
-	"fmt"
+	"context"
 	"strings"
-	"time"
 )
 
 type ConversationType string
@@ -31,52 +30,46 @@ const (
 	ConversationTypeMPIM           ConversationType = "mpip"
 )
 
+// GetConversations returns every conversation of the given types visible to the client.
 func (c *Client) GetConversations(types []ConversationType) ([]Conversation, error) {
-	t := make([]string, 0, len(types))
-	for _, v := range types {
-		t = append(t, string(v))
-	}
-
-	var conversations []Conversation
-	cursor := ""
-	for {
-		args := map[string]string{
-			"limit": "100",
-			"types": strings.Join(t, ","),
-		}
-		if cursor != "" {
-			args["cursor"] = cursor
-		}
+	return c.GetConversationsContext(context.Background(), types)
+}
 
-		ret := struct {
-			Channels []Conversation `json:"channels"`
-			Metadata struct {
-				NextCursor string `json:"next_cursor"`
-			} `json:"response_metadata"`
-		}{}
+// GetConversationsContext is like GetConversations, but gives up when the context is done.
+func (c *Client) GetConversationsContext(ctx context.Context, types []ConversationType) ([]Conversation, error) {
+	conversations, err := c.ListConversations(types).Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
 
-		for {
-			if err := c.CallOldMethod("conversations.list", args, &ret); err != nil {
-				switch e := err.(type) {
-				case ErrRateLimit:
-					time.Sleep(e.Wait)
-					continue
-				default:
-					return nil, fmt.Errorf("failed to list conversations: %v", err)
-				}
-			}
-			break
-		}
+// ListConversations returns a Pager over every conversation of the given types visible to the
+// client.
+func (c *Client) ListConversations(types []ConversationType) Pager[Conversation] {
+	return Pager[Conversation]{
+		Client:   c,
+		Method:   "conversations.list",
+		Args:     map[string]string{"types": joinConversationTypes(types)},
+		Field:    "channels",
+		PageSize: 100,
+	}
+}
 
-		conversations = append(conversations, ret.Channels...)
-		if ret.Metadata.NextCursor == "" {
-			break
-		}
-		cursor = ret.Metadata.NextCursor
+func joinConversationTypes(types []ConversationType) string {
+	t := make([]string, 0, len(types))
+	for _, v := range types {
+		t = append(t, string(v))
 	}
-	return conversations, nil
+	return strings.Join(t, ",")
 }
 
+// GetPublicChannels returns every public channel.
 func (c *Client) GetPublicChannels() ([]Conversation, error) {
-	return c.GetConversations([]ConversationType{ConversationTypePublicChannel})
+	return c.GetPublicChannelsContext(context.Background())
+}
+
+// GetPublicChannelsContext is like GetPublicChannels, but gives up when the context is done.
+func (c *Client) GetPublicChannelsContext(ctx context.Context) ([]Conversation, error) {
+	return c.GetConversationsContext(ctx, []ConversationType{ConversationTypePublicChannel})
 }
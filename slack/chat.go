@@ -0,0 +1,141 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+)
+
+// PostMessageRequest is a message to be posted with PostMessage.
+type PostMessageRequest struct {
+	// Channel is a conversation ID, or a user ID to send a direct message from the bot.
+	Channel string `json:"channel"`
+	// Text is the message itself, or the fallback text if Blocks is provided.
+	Text string `json:"text,omitempty"`
+	// Blocks is an optional list of Block Kit blocks making up the message.
+	Blocks []blockkit.Block `json:"blocks,omitempty"`
+	// Attachments is an optional list of legacy message attachments.
+	Attachments interface{} `json:"attachments,omitempty"`
+	// ThreadTS, if set, posts the message as a reply in the given thread.
+	ThreadTS string `json:"thread_ts,omitempty"`
+	// AsUser sends the message as the bot user, rather than as the app.
+	AsUser bool `json:"as_user,omitempty"`
+	// LinkNames parses @names and #names in Text.
+	LinkNames bool `json:"link_names,omitempty"`
+	// UnfurlLinks enables unfurling of text-based content.
+	UnfurlLinks bool `json:"unfurl_links,omitempty"`
+}
+
+// PostMessageResponse identifies a message posted with PostMessage.
+type PostMessageResponse struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// PostMessage posts a message to a conversation.
+func (c *Client) PostMessage(ctx context.Context, req PostMessageRequest) (PostMessageResponse, error) {
+	if err := blockkit.ValidateMessage(req.Blocks); err != nil {
+		return PostMessageResponse{}, fmt.Errorf("invalid message: %v", err)
+	}
+	ret := PostMessageResponse{}
+	if err := c.CallMethodContext(ctx, "chat.postMessage", req, &ret); err != nil {
+		return PostMessageResponse{}, fmt.Errorf("failed to post message to %s: %w", req.Channel, err)
+	}
+	return ret, nil
+}
+
+// PostEphemeralRequest is a message to be posted with PostEphemeral.
+type PostEphemeralRequest struct {
+	// Channel is the conversation the message appears in.
+	Channel string `json:"channel"`
+	// User is the only user who will see the message.
+	User string `json:"user"`
+	// Text is the message itself, or the fallback text if Blocks is provided.
+	Text string `json:"text,omitempty"`
+	// Blocks is an optional list of Block Kit blocks making up the message.
+	Blocks []blockkit.Block `json:"blocks,omitempty"`
+	// ThreadTS, if set, shows the message in the given thread.
+	ThreadTS string `json:"thread_ts,omitempty"`
+}
+
+// PostEphemeral posts a message that is only visible to a single user.
+func (c *Client) PostEphemeral(ctx context.Context, req PostEphemeralRequest) error {
+	if err := blockkit.ValidateMessage(req.Blocks); err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	if err := c.CallMethodContext(ctx, "chat.postEphemeral", req, nil); err != nil {
+		return fmt.Errorf("failed to post ephemeral message to %s in %s: %w", req.User, req.Channel, err)
+	}
+	return nil
+}
+
+// UpdateMessageRequest replaces the content of a message with UpdateMessage.
+type UpdateMessageRequest struct {
+	// Channel is the conversation the message is in.
+	Channel string `json:"channel"`
+	// TS is the timestamp of the message to update.
+	TS string `json:"ts"`
+	// Text is the new message, or the fallback text if Blocks is provided.
+	Text string `json:"text,omitempty"`
+	// Blocks, if provided, replaces the message's Block Kit blocks.
+	Blocks []blockkit.Block `json:"blocks,omitempty"`
+	// LinkNames parses @names and #names in Text.
+	LinkNames bool `json:"link_names,omitempty"`
+}
+
+// UpdateMessage replaces the content of a message the client's user posted.
+func (c *Client) UpdateMessage(ctx context.Context, req UpdateMessageRequest) error {
+	if err := blockkit.ValidateMessage(req.Blocks); err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	if err := c.CallMethodContext(ctx, "chat.update", req, nil); err != nil {
+		return fmt.Errorf("failed to update message %s in %s: %w", req.TS, req.Channel, err)
+	}
+	return nil
+}
+
+// DeleteMessage deletes the message with the given timestamp. Deleting other users' messages
+// requires a client with an admin user token.
+func (c *Client) DeleteMessage(ctx context.Context, channel, ts string) error {
+	req := struct {
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+		AsUser  bool   `json:"as_user"`
+	}{channel, ts, true}
+	if err := c.CallMethodContext(ctx, "chat.delete", req, nil); err != nil {
+		return fmt.Errorf("failed to delete message %s in %s: %w", ts, channel, err)
+	}
+	return nil
+}
+
+// GetPermalink returns a link to the message with the given timestamp.
+func (c *Client) GetPermalink(ctx context.Context, channel, ts string) (string, error) {
+	ret := struct {
+		Permalink string `json:"permalink"`
+	}{}
+	args := map[string]string{
+		"channel":    channel,
+		"message_ts": ts,
+	}
+	if err := c.CallOldMethodContext(ctx, "chat.getPermalink", args, &ret); err != nil {
+		return "", fmt.Errorf("failed to get permalink for %s in %s: %w", ts, channel, err)
+	}
+	return ret.Permalink, nil
+}
@@ -20,6 +20,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
 )
 
 // Config is the information needed to communicate with Slack.
@@ -29,17 +34,97 @@ type Config struct {
 	AccessToken     string   `json:"accessToken"`
 	AdminToken      string   `json:"adminToken"`
 	GuardedChannels []string `json:"guardedChannels"`
+
+	// AppToken is an app-level token with the connections:write scope, used for Socket Mode.
+	AppToken string `json:"appToken"`
+	// ClientID and ClientSecret identify the app when installing it with OAuth.
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// RefreshToken is set if the app uses token rotation, in which case AccessToken expires and
+	// is refreshed with this shortly before it does. Requires ClientID and ClientSecret.
+	RefreshToken string `json:"refreshToken"`
+}
+
+// ConfigSource supplies a Client's configuration when it may change while the client is in use.
+type ConfigSource interface {
+	Config() Config
 }
 
 // LoadConfig loads a Config from a JSON file.
 func LoadConfig(path string) (Config, error) {
+	return ConfigLoader{Path: path}.Load()
+}
+
+// ConfigLoader loads a Config from any combination of a JSON file, a directory of secret files
+// and environment variables. Later sources override earlier ones, in that order.
+type ConfigLoader struct {
+	// Path is a JSON file containing the config.
+	Path string
+	// SecretsDir is a directory containing a file for each setting, named after its key in the
+	// JSON file (e.g. "accessToken"), as Kubernetes mounts a Secret.
+	SecretsDir string
+	// EnvPrefix, if set, lets settings be given by environment variables named after their key
+	// in the JSON file, e.g. SLACK_ACCESS_TOKEN for accessToken with the prefix "SLACK_".
+	EnvPrefix string
+}
+
+// Load loads a Config. List settings, such as guardedChannels, are comma-separated when given in
+// a secret file or an environment variable.
+func (l ConfigLoader) Load() (Config, error) {
 	config := Config{}
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return config, fmt.Errorf("couldn't open file: %v", err)
+	if l.Path != "" {
+		content, err := ioutil.ReadFile(l.Path)
+		if err != nil {
+			return config, fmt.Errorf("couldn't open file: %v", err)
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return config, fmt.Errorf("couldn't parse config: %v", err)
+		}
 	}
-	if err := json.Unmarshal(content, &config); err != nil {
-		return config, fmt.Errorf("couldn't parse config: %v", err)
+	v := reflect.ValueOf(&config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if l.SecretsDir != "" {
+			content, err := ioutil.ReadFile(filepath.Join(l.SecretsDir, key))
+			if err == nil {
+				setConfigField(v.Field(i), strings.TrimSpace(string(content)))
+			} else if !os.IsNotExist(err) {
+				return config, fmt.Errorf("couldn't read %s from %s: %v", key, l.SecretsDir, err)
+			}
+		}
+		if l.EnvPrefix != "" {
+			if value, ok := os.LookupEnv(l.EnvPrefix + envName(key)); ok {
+				setConfigField(v.Field(i), value)
+			}
+		}
 	}
 	return config, nil
 }
+
+func setConfigField(field reflect.Value, value string) {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Slice:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	}
+}
+
+// envName converts a JSON key like "accessToken" to an environment variable name like
+// "ACCESS_TOKEN".
+func envName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
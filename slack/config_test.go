@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestConfigLoader(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	writeFile(t, configPath, `{"signingSecret": "file-secret", "accessToken": "xoxb-file", "webhook": "https://example.com/hook"}`)
+	secretsDir := filepath.Join(dir, "secrets")
+	writeFile(t, filepath.Join(secretsDir, "accessToken"), "xoxb-secret\n")
+	writeFile(t, filepath.Join(secretsDir, "guardedChannels"), "C1, C2")
+	t.Setenv("SLACKTEST_SIGNING_SECRET", "env-secret")
+	t.Setenv("SLACKTEST_CLIENT_ID", "123.456")
+
+	tests := []struct {
+		name     string
+		loader   slack.ConfigLoader
+		expected slack.Config
+	}{
+		{
+			name:     "file only",
+			loader:   slack.ConfigLoader{Path: configPath},
+			expected: slack.Config{SigningSecret: "file-secret", AccessToken: "xoxb-file", WebhookURL: "https://example.com/hook"},
+		},
+		{
+			name:   "secrets override the file",
+			loader: slack.ConfigLoader{Path: configPath, SecretsDir: secretsDir},
+			expected: slack.Config{SigningSecret: "file-secret", AccessToken: "xoxb-secret", WebhookURL: "https://example.com/hook",
+				GuardedChannels: []string{"C1", "C2"}},
+		},
+		{
+			name:   "environment overrides everything",
+			loader: slack.ConfigLoader{Path: configPath, SecretsDir: secretsDir, EnvPrefix: "SLACKTEST_"},
+			expected: slack.Config{SigningSecret: "env-secret", AccessToken: "xoxb-secret", WebhookURL: "https://example.com/hook",
+				GuardedChannels: []string{"C1", "C2"}, ClientID: "123.456"},
+		},
+		{
+			name:     "no file",
+			loader:   slack.ConfigLoader{EnvPrefix: "SLACKTEST_"},
+			expected: slack.Config{SigningSecret: "env-secret", ClientID: "123.456"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := tc.loader.Load()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(config, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, config)
+			}
+		})
+	}
+}
+
+func TestConfigWatcherReloads(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "signingSecret"), "old")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := slack.WatchConfig(ctx, slack.ConfigLoader{SecretsDir: dir}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	c := slack.New(slack.Config{})
+	c.ConfigSource = w
+
+	writeFile(t, filepath.Join(dir, "signingSecret"), "new")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if got := c.CurrentConfig().SigningSecret; got != "new" {
+		t.Errorf("Expected the new signing secret, got %q", got)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "accessToken"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Errorf("Expected reloading an unreadable config to fail")
+	}
+	if got := c.CurrentConfig().SigningSecret; got != "new" {
+		t.Errorf("Expected the previous config to be kept after a failed reload, got %q", got)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultConfigReloadInterval is how often the bots check their config for changes.
+const DefaultConfigReloadInterval = time.Minute
+
+// ConfigWatcher keeps a Config up to date with the sources it was loaded from, so that secrets can
+// be rotated without restarting. It is a ConfigSource, and is safe for concurrent use.
+type ConfigWatcher struct {
+	loader ConfigLoader
+
+	mu     sync.RWMutex
+	config Config
+}
+
+// WatchConfig loads a Config with loader, then reloads it every interval until ctx is done.
+func WatchConfig(ctx context.Context, loader ConfigLoader, interval time.Duration) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{loader: loader}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Reload(); err != nil {
+					log.Printf("Failed to reload slack config, keeping the previous one: %v", err)
+				}
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Config returns the most recently loaded Config.
+func (w *ConfigWatcher) Config() Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.config
+}
+
+// Reload loads the Config again. If that fails, the previous Config is kept.
+func (w *ConfigWatcher) Reload() error {
+	config, err := w.loader.Load()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !reflect.DeepEqual(config, w.config) && !reflect.DeepEqual(w.config, Config{}) {
+		log.Printf("Reloaded slack config")
+	}
+	w.config = config
+	return nil
+}
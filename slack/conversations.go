@@ -0,0 +1,202 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetConversationInfo returns the conversation with the given ID.
+func (c *Client) GetConversationInfo(ctx context.Context, id string) (Conversation, error) {
+	ret := struct {
+		Channel Conversation `json:"channel"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "conversations.info", map[string]string{"channel": id}, &ret); err != nil {
+		return Conversation{}, fmt.Errorf("failed to get conversation %s: %w", id, err)
+	}
+	return ret.Channel, nil
+}
+
+// CreateConversation creates a new channel with the given name.
+func (c *Client) CreateConversation(ctx context.Context, name string, private bool) (Conversation, error) {
+	req := struct {
+		Name      string `json:"name"`
+		IsPrivate bool   `json:"is_private,omitempty"`
+	}{name, private}
+	ret := struct {
+		Channel Conversation `json:"channel"`
+	}{}
+	if err := c.CallMethodContext(ctx, "conversations.create", req, &ret); err != nil {
+		return Conversation{}, fmt.Errorf("failed to create channel %s: %w", name, err)
+	}
+	return ret.Channel, nil
+}
+
+// ArchiveConversation archives the given channel.
+func (c *Client) ArchiveConversation(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "conversations.archive", map[string]string{"channel": id}, nil); err != nil {
+		return fmt.Errorf("failed to archive channel %s: %w", id, err)
+	}
+	return nil
+}
+
+// UnarchiveConversation unarchives the given channel.
+func (c *Client) UnarchiveConversation(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "conversations.unarchive", map[string]string{"channel": id}, nil); err != nil {
+		return fmt.Errorf("failed to unarchive channel %s: %w", id, err)
+	}
+	return nil
+}
+
+// RenameConversation renames the given channel.
+func (c *Client) RenameConversation(ctx context.Context, id, name string) error {
+	if err := c.CallMethodContext(ctx, "conversations.rename", map[string]string{"channel": id, "name": name}, nil); err != nil {
+		return fmt.Errorf("failed to rename channel %s to %s: %w", id, name, err)
+	}
+	return nil
+}
+
+// JoinConversation adds the client's user to the given channel.
+func (c *Client) JoinConversation(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "conversations.join", map[string]string{"channel": id}, nil); err != nil {
+		return fmt.Errorf("failed to join channel %s: %w", id, err)
+	}
+	return nil
+}
+
+// InviteToConversation invites the given users to a channel, which the client's user must be a
+// member of.
+func (c *Client) InviteToConversation(ctx context.Context, id string, users []string) error {
+	if err := c.CallMethodContext(ctx, "conversations.invite", map[string]string{"channel": id, "users": strings.Join(users, ",")}, nil); err != nil {
+		return fmt.Errorf("failed to invite %s to channel %s: %w", strings.Join(users, ", "), id, err)
+	}
+	return nil
+}
+
+// ConvertToPrivate converts a public channel to a private one. This is an admin API, which
+// requires an Enterprise Grid org and a user token with the admin.conversations:write scope.
+func (c *Client) ConvertToPrivate(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "admin.conversations.convertToPrivate", map[string]string{"channel_id": id}, nil); err != nil {
+		return fmt.Errorf("failed to convert channel %s to private: %w", id, err)
+	}
+	return nil
+}
+
+// ConvertToPublic converts a private channel to a public one. Like ConvertToPrivate, this is an
+// admin API.
+func (c *Client) ConvertToPublic(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "admin.conversations.convertToPublic", map[string]string{"channel_id": id}, nil); err != nil {
+		return fmt.Errorf("failed to convert channel %s to public: %w", id, err)
+	}
+	return nil
+}
+
+// SetTopic sets the topic of the given channel.
+func (c *Client) SetTopic(ctx context.Context, id, topic string) error {
+	if err := c.CallMethodContext(ctx, "conversations.setTopic", map[string]string{"channel": id, "topic": topic}, nil); err != nil {
+		return fmt.Errorf("failed to set topic of %s to %q: %w", id, topic, err)
+	}
+	return nil
+}
+
+// SetPurpose sets the purpose of the given channel.
+func (c *Client) SetPurpose(ctx context.Context, id, purpose string) error {
+	if err := c.CallMethodContext(ctx, "conversations.setPurpose", map[string]string{"channel": id, "purpose": purpose}, nil); err != nil {
+		return fmt.Errorf("failed to set purpose of %s to %q: %w", id, purpose, err)
+	}
+	return nil
+}
+
+// OpenDM opens a direct message conversation with the given user, returning its ID.
+func (c *Client) OpenDM(ctx context.Context, user string) (string, error) {
+	ret := struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}{}
+	if err := c.CallMethodContext(ctx, "conversations.open", map[string]string{"users": user}, &ret); err != nil {
+		return "", fmt.Errorf("failed to open a DM with %s: %w", user, err)
+	}
+	return ret.Channel.ID, nil
+}
+
+// ListConversationMembers returns a Pager over the IDs of the members of the given conversation.
+func (c *Client) ListConversationMembers(id string) Pager[string] {
+	return Pager[string]{Client: c, Method: "conversations.members", Args: map[string]string{"channel": id}, Field: "members"}
+}
+
+// HistoryRequest limits the messages listed by ListConversationHistory. Oldest and Latest are
+// message timestamps; if empty, the history isn't limited in that direction.
+type HistoryRequest struct {
+	Channel string
+	Oldest  string
+	Latest  string
+	// Inclusive includes messages with exactly the Oldest or Latest timestamp.
+	Inclusive bool
+}
+
+// ListConversationHistory returns a Pager over the messages in a conversation, newest first.
+// Replies in threads are not included; use ListConversationReplies to get them.
+func (c *Client) ListConversationHistory(req HistoryRequest) Pager[Message] {
+	args := map[string]string{"channel": req.Channel}
+	if req.Oldest != "" {
+		args["oldest"] = req.Oldest
+	}
+	if req.Latest != "" {
+		args["latest"] = req.Latest
+	}
+	if req.Inclusive {
+		args["inclusive"] = "true"
+	}
+	return Pager[Message]{Client: c, Method: "conversations.history", Args: args, Field: "messages"}
+}
+
+// ListConversationReplies returns a Pager over a thread: the message with timestamp ts, followed
+// by its replies.
+func (c *Client) ListConversationReplies(channel, ts string) Pager[Message] {
+	return Pager[Message]{Client: c, Method: "conversations.replies", Args: map[string]string{"channel": channel, "ts": ts}, Field: "messages"}
+}
+
+// GetMessage returns the message with timestamp ts, which may be a reply in a thread. If there is
+// no such message, it returns ErrMessageNotFound.
+func (c *Client) GetMessage(ctx context.Context, channel, ts string) (Message, error) {
+	for m, err := range c.ListConversationReplies(channel, ts).All(ctx) {
+		if err != nil {
+			return Message{}, err
+		}
+		if m.TS == ts {
+			return m, nil
+		}
+	}
+	return Message{}, ErrMessageNotFound
+}
+
+// ListUserConversations returns a Pager over the conversations of the given types that a user is
+// a member of. If user is empty, the client's own user is used. If types is empty, Slack only
+// lists public channels.
+func (c *Client) ListUserConversations(user string, types []ConversationType) Pager[Conversation] {
+	args := map[string]string{}
+	if user != "" {
+		args["user"] = user
+	}
+	if len(types) > 0 {
+		args["types"] = joinConversationTypes(types)
+	}
+	return Pager[Conversation]{Client: c, Method: "users.conversations", Args: args, Field: "channels"}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDirectoryTTL is how long a Directory trusts what it knows about a user by default.
+const DefaultDirectoryTTL = time.Hour
+
+// Directory is an in-memory cache of the users in a workspace, so that looking up a user doesn't
+// cost a call to Slack every time. It is filled in bulk from users.list by Load, kept up to date
+// with Update, which events.Router.UpdateDirectory calls for team_join and user_change events,
+// and falls back to calling Slack for users it doesn't know or last heard about more than TTL ago.
+//
+// A Directory holds the users of a single workspace.
+type Directory struct {
+	client *Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	users   map[string]directoryEntry
+	byEmail map[string]string
+}
+
+type directoryEntry struct {
+	user    User
+	updated time.Time
+}
+
+// NewDirectory returns an empty Directory that looks up users with client. A ttl of zero means
+// DefaultDirectoryTTL.
+func NewDirectory(client *Client, ttl time.Duration) *Directory {
+	if ttl == 0 {
+		ttl = DefaultDirectoryTTL
+	}
+	return &Directory{
+		client:  client,
+		ttl:     ttl,
+		now:     time.Now,
+		users:   map[string]directoryEntry{},
+		byEmail: map[string]string{},
+	}
+}
+
+// Load replaces the contents of the directory with every user in the workspace.
+func (d *Directory) Load(ctx context.Context) error {
+	users, err := d.client.ListUsers().Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load user directory: %w", err)
+	}
+	now := d.now()
+	entries := make(map[string]directoryEntry, len(users))
+	byEmail := make(map[string]string, len(users))
+	for _, u := range users {
+		entries[u.ID] = directoryEntry{user: u, updated: now}
+		if u.Profile.Email != "" {
+			byEmail[strings.ToLower(u.Profile.Email)] = u.ID
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users = entries
+	d.byEmail = byEmail
+	return nil
+}
+
+// Run loads the directory, and then reloads it every TTL until ctx is done. If loading fails,
+// the directory keeps what it had and tries again at the next interval.
+func (d *Directory) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.ttl)
+	defer ticker.Stop()
+	for {
+		if err := d.Load(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to load the user directory, will try again in %s: %v", d.ttl, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update records what Slack has said about a user, e.g. in a user_change event.
+func (d *Directory) Update(u User) {
+	if u.ID == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.users[u.ID]; ok && old.user.Profile.Email != "" {
+		delete(d.byEmail, strings.ToLower(old.user.Profile.Email))
+	}
+	d.users[u.ID] = directoryEntry{user: u, updated: d.now()}
+	if u.Profile.Email != "" {
+		d.byEmail[strings.ToLower(u.Profile.Email)] = u.ID
+	}
+}
+
+// cached returns the user with the given ID if the directory heard about them recently enough.
+// d.mu must be held.
+func (d *Directory) cached(id string) (User, bool) {
+	e, ok := d.users[id]
+	if !ok || d.now().Sub(e.updated) >= d.ttl {
+		return User{}, false
+	}
+	return e.user, true
+}
+
+// User returns the user with the given ID.
+func (d *Directory) User(ctx context.Context, id string) (User, error) {
+	d.mu.RLock()
+	u, ok := d.cached(id)
+	d.mu.RUnlock()
+	if ok {
+		return u, nil
+	}
+	u, err := d.client.GetUser(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+	d.Update(u)
+	return u, nil
+}
+
+// UserByEmail returns the user with the given email address, ignoring case. Email addresses are
+// only known if the client has the users:read.email scope.
+func (d *Directory) UserByEmail(ctx context.Context, email string) (User, error) {
+	d.mu.RLock()
+	u, ok := d.cached(d.byEmail[strings.ToLower(email)])
+	d.mu.RUnlock()
+	if ok {
+		return u, nil
+	}
+	u, err := d.client.LookupUserByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+	d.Update(u)
+	return u, nil
+}
+
+// UsersByName returns the users whose display name, or username if they have no display name,
+// is name, ignoring case and a leading "@", ordered by ID. Display names aren't unique, so there
+// may be several.
+// Slack has no way to look users up by name, so only users already in the directory are found.
+func (d *Directory) UsersByName(name string) []User {
+	name = strings.TrimPrefix(name, "@")
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var users []User
+	for _, e := range d.users {
+		shown := e.user.Profile.DisplayName
+		if shown == "" {
+			shown = e.user.Name
+		}
+		if strings.EqualFold(shown, name) {
+			users = append(users, e.user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
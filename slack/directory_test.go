@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func newUser(name, displayName, email string, admin bool) slack.User {
+	u := slack.User{Name: name, IsAdmin: admin}
+	u.Profile.DisplayName = displayName
+	u.Profile.Email = email
+	return u
+}
+
+func TestDirectoryServesLookupsFromCache(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	alice := s.AddUser(newUser("alice", "Alice", "alice@example.com", true))
+	bob := s.AddUser(newUser("bob", "", "bob@example.com", false))
+	s.AddUser(newUser("robert", "bob", "", false))
+	d := slack.NewDirectory(s.Client(slack.Config{}), 0)
+	ctx := context.Background()
+
+	if err := d.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if u, err := d.User(ctx, alice); err != nil || !u.IsModerator() {
+		t.Errorf("Expected alice to be found as a moderator, got %+v, %v", u, err)
+	}
+	if u, err := d.UserByEmail(ctx, "BOB@example.com"); err != nil || u.ID != bob {
+		t.Errorf("Expected bob to be found by email, got %+v, %v", u, err)
+	}
+	if users := d.UsersByName("@Bob"); len(users) != 2 {
+		t.Errorf("Expected two users to be called bob, got %+v", users)
+	}
+	for _, method := range []string{"users.info", "users.lookupByEmail"} {
+		if calls := s.CallsTo(method); len(calls) != 0 {
+			t.Errorf("Expected no calls to %s, got %d", method, len(calls))
+		}
+	}
+}
+
+func TestDirectoryFallsBackToSlack(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	d := slack.NewDirectory(s.Client(slack.Config{}), 0)
+	ctx := context.Background()
+	if err := d.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	carol := s.AddUser(newUser("carol", "Carol", "carol@example.com", false))
+	if u, err := d.User(ctx, carol); err != nil || u.Name != "carol" {
+		t.Fatalf("Expected a new user to be looked up, got %+v, %v", u, err)
+	}
+	if _, err := d.User(ctx, carol); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := s.CallsTo("users.info"); len(calls) != 1 {
+		t.Errorf("Expected one call to users.info, got %d", len(calls))
+	}
+	if u, err := d.UserByEmail(ctx, "carol@example.com"); err != nil || u.ID != carol {
+		t.Errorf("Expected carol to be found by email, got %+v, %v", u, err)
+	}
+	if calls := s.CallsTo("users.lookupByEmail"); len(calls) != 0 {
+		t.Errorf("Expected carol's email to be cached, got %d calls to users.lookupByEmail", len(calls))
+	}
+	if _, err := d.User(ctx, "U404"); err == nil {
+		t.Errorf("Expected an error looking up an unknown user")
+	}
+}
+
+func TestDirectoryEntriesExpire(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	alice := s.AddUser(newUser("alice", "Alice", "", false))
+	d := slack.NewDirectory(s.Client(slack.Config{}), time.Nanosecond)
+	ctx := context.Background()
+	if err := d.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := d.User(ctx, alice); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := s.CallsTo("users.info"); len(calls) != 1 {
+		t.Errorf("Expected an expired user to be looked up again, got %d calls to users.info", len(calls))
+	}
+}
+
+func TestDirectoryUpdate(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	d := slack.NewDirectory(s.Client(slack.Config{}), 0)
+	ctx := context.Background()
+
+	u := newUser("dave", "Dave", "dave@example.com", false)
+	u.ID = "U1"
+	d.Update(u)
+	u.IsAdmin = true
+	u.Profile.Email = "david@example.com"
+	d.Update(u)
+
+	if got, err := d.User(ctx, "U1"); err != nil || !got.IsModerator() {
+		t.Errorf("Expected the update to be seen, got %+v, %v", got, err)
+	}
+	if _, err := d.UserByEmail(ctx, "dave@example.com"); err == nil {
+		t.Errorf("Expected the old email address to be forgotten")
+	}
+	if got, err := d.UserByEmail(ctx, "david@example.com"); err != nil || got.ID != "U1" {
+		t.Errorf("Expected the new email address to be found, got %+v, %v", got, err)
+	}
+}
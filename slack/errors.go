@@ -17,10 +17,15 @@ limitations under the License.
 package slack
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"time"
 )
 
+// ErrRateLimit is returned when Slack rejects a call with HTTP 429 and the client gives up waiting.
 type ErrRateLimit struct {
 	Wait time.Duration
 }
@@ -29,6 +34,16 @@ func (e ErrRateLimit) Error() string {
 	return fmt.Sprintf("slack has rate limited us for the next %s", e.Wait)
 }
 
+// Retryable reports that the call can be made again once Wait has passed.
+func (e ErrRateLimit) Retryable() bool {
+	return true
+}
+
+// ErrSlack is returned when Slack responds to a call with "ok": false. Type is Slack's error code,
+// e.g. "channel_not_found".
+//
+// ErrSlack errors match the sentinels below with errors.Is by Type, so callers can write
+// errors.Is(err, slack.ErrChannelNotFound) and still use errors.As to get at the warnings.
 type ErrSlack struct {
 	Type     string
 	Warnings []string
@@ -37,3 +52,136 @@ type ErrSlack struct {
 func (e ErrSlack) Error() string {
 	return fmt.Sprintf("slack call failed: %s (%v)", e.Type, e.Warnings)
 }
+
+// Is reports whether target is an ErrSlack with the same Type.
+func (e ErrSlack) Is(target error) bool {
+	t, ok := target.(ErrSlack)
+	return ok && t.Type == e.Type
+}
+
+// Retryable reports whether the same call might succeed if made again, which is only true of
+// errors that Slack reports when it is having trouble of its own.
+func (e ErrSlack) Retryable() bool {
+	switch e.Type {
+	case "internal_error", "fatal_error", "service_unavailable", "request_timeout", "ratelimited":
+		return true
+	}
+	return false
+}
+
+// Errors that Slack commonly returns, for use with errors.Is.
+var (
+	// Authentication and authorisation.
+	ErrInvalidAuth         = ErrSlack{Type: "invalid_auth"}
+	ErrNotAuthed           = ErrSlack{Type: "not_authed"}
+	ErrAccountInactive     = ErrSlack{Type: "account_inactive"}
+	ErrTokenRevoked        = ErrSlack{Type: "token_revoked"}
+	ErrTokenExpired        = ErrSlack{Type: "token_expired"}
+	ErrMissingScope        = ErrSlack{Type: "missing_scope"}
+	ErrNotAllowedTokenType = ErrSlack{Type: "not_allowed_token_type"}
+
+	// Conversations.
+	ErrChannelNotFound  = ErrSlack{Type: "channel_not_found"}
+	ErrNotInChannel     = ErrSlack{Type: "not_in_channel"}
+	ErrAlreadyInChannel = ErrSlack{Type: "already_in_channel"}
+	ErrNameTaken        = ErrSlack{Type: "name_taken"}
+	ErrInvalidName      = ErrSlack{Type: "invalid_name"}
+	ErrAlreadyArchived  = ErrSlack{Type: "already_archived"}
+	ErrNotArchived      = ErrSlack{Type: "not_archived"}
+	ErrIsArchived       = ErrSlack{Type: "is_archived"}
+
+	// Messages, files and pins.
+	ErrMessageNotFound = ErrSlack{Type: "message_not_found"}
+	ErrCantDelete      = ErrSlack{Type: "cant_delete_message"}
+	ErrFileNotFound    = ErrSlack{Type: "file_not_found"}
+	ErrFileDeleted     = ErrSlack{Type: "file_deleted"}
+	ErrAlreadyPinned   = ErrSlack{Type: "already_pinned"}
+	ErrNoPin           = ErrSlack{Type: "no_pin"}
+
+	// Users and usergroups.
+	ErrUserNotFound     = ErrSlack{Type: "user_not_found"}
+	ErrUsersNotFound    = ErrSlack{Type: "users_not_found"}
+	ErrNoSuchSubteam    = ErrSlack{Type: "no_such_subteam"}
+	ErrPermissionDenied = ErrSlack{Type: "permission_denied"}
+	ErrCantUpdateUser   = ErrSlack{Type: "cant_update_user"}
+	ErrUserIsRestricted = ErrSlack{Type: "user_is_restricted"}
+	ErrUserIsBot        = ErrSlack{Type: "user_is_bot"}
+
+	// Problems on Slack's side, which are retryable.
+	ErrRatelimited        = ErrSlack{Type: "ratelimited"}
+	ErrInternalError      = ErrSlack{Type: "internal_error"}
+	ErrFatalError         = ErrSlack{Type: "fatal_error"}
+	ErrServiceUnavailable = ErrSlack{Type: "service_unavailable"}
+	ErrRequestTimeout     = ErrSlack{Type: "request_timeout"}
+)
+
+// maxErrorBodyLength is how much of the body of a failed HTTP response ErrHTTP keeps.
+const maxErrorBodyLength = 1024
+
+// ErrHTTP is returned when Slack responds to a call with an HTTP status other than 200 or 429.
+type ErrHTTP struct {
+	StatusCode int
+	// Body is the start of the response body, which may explain the failure.
+	Body string
+}
+
+func (e ErrHTTP) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("slack call failed: HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("slack call failed: HTTP %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Retryable reports whether the status suggests a problem on Slack's side or in between.
+func (e ErrHTTP) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout
+}
+
+// ErrTransport is returned when a call to Slack fails before a response is received, e.g.
+// because the connection could not be made or was reset.
+type ErrTransport struct {
+	Err error
+}
+
+func (e ErrTransport) Error() string {
+	return fmt.Sprintf("failed to reach Slack: %v", e.Err)
+}
+
+func (e ErrTransport) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the call might succeed if made again, which is true unless it
+// failed because the caller gave up.
+func (e ErrTransport) Retryable() bool {
+	return !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// IsRetryable reports whether the call that returned err might succeed if made again, unchanged.
+// Errors that are the caller's fault, like a missing channel or scope, are not retryable.
+func IsRetryable(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// Retry calls f until it succeeds, fails with an error that isn't IsRetryable, or has been retried
+// the given number of times, waiting delay between attempts. It gives up early, returning the
+// last error, if ctx is done.
+//
+// The Client only retries calls Slack rate limited, since it can't tell whether repeating any other
+// failed call is safe: Slack may have acted before the call failed. Use Retry for calls that can be
+// repeated without harm, like deleting or archiving something.
+func Retry(ctx context.Context, retries int, delay time.Duration, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= retries || !IsRetryable(err) {
+			return err
+		}
+		log.Printf("Failed: %v. Trying again in %s...", err, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestErrorsMatchSentinels(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+
+	err := c.ArchiveConversation(context.Background(), "C404")
+	if !errors.Is(err, slack.ErrChannelNotFound) {
+		t.Errorf("Expected %v to be ErrChannelNotFound", err)
+	}
+	if errors.Is(err, slack.ErrNotInChannel) {
+		t.Errorf("Expected %v not to be ErrNotInChannel", err)
+	}
+	var e slack.ErrSlack
+	if !errors.As(err, &e) || e.Type != "channel_not_found" {
+		t.Errorf("Expected %v to be an ErrSlack of type channel_not_found", err)
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream connect error", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := slack.New(slack.Config{AccessToken: "xoxb-test"})
+	c.APIURL = srv.URL
+
+	err := c.CallMethodContext(context.Background(), "chat.postMessage", map[string]string{}, nil)
+	var e slack.ErrHTTP
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected an ErrHTTP, got %v", err)
+	}
+	if e.StatusCode != http.StatusServiceUnavailable || e.Body != "upstream connect error\n" {
+		t.Errorf("Expected status 503 with the body, got %d and %q", e.StatusCode, e.Body)
+	}
+	if !slack.IsRetryable(err) {
+		t.Errorf("Expected %v to be retryable", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("something"), want: false},
+		{err: slack.ErrRateLimit{}, want: true},
+		{err: slack.ErrInternalError, want: true},
+		{err: fmt.Errorf("wrapped: %w", slack.ErrServiceUnavailable), want: true},
+		{err: slack.ErrChannelNotFound, want: false},
+		{err: fmt.Errorf("wrapped: %w", slack.ErrMissingScope), want: false},
+		{err: slack.ErrHTTP{StatusCode: http.StatusBadGateway}, want: true},
+		{err: slack.ErrHTTP{StatusCode: http.StatusNotFound}, want: false},
+		{err: slack.ErrTransport{Err: errors.New("connection reset")}, want: true},
+		{err: slack.ErrTransport{Err: context.DeadlineExceeded}, want: false},
+	}
+	for _, tc := range tests {
+		if got := slack.IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	retryable := slack.ErrTransport{Err: errors.New("connection reset")}
+	tests := []struct {
+		name      string
+		errs      []error
+		cancelled bool
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "success",
+			wantCalls: 1,
+		},
+		{
+			name:      "retryable failure is retried",
+			errs:      []error{retryable},
+			wantCalls: 2,
+		},
+		{
+			name:      "retries are limited",
+			errs:      []error{retryable, retryable, retryable},
+			wantCalls: 3,
+			wantErr:   retryable,
+		},
+		{
+			name:      "permanent failure is not retried",
+			errs:      []error{slack.ErrChannelNotFound},
+			wantCalls: 1,
+			wantErr:   slack.ErrChannelNotFound,
+		},
+		{
+			name:      "gives up when cancelled",
+			errs:      []error{retryable},
+			cancelled: true,
+			wantCalls: 1,
+			wantErr:   retryable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			delay := time.Duration(0)
+			if tc.cancelled {
+				cancel()
+				delay = time.Hour
+			}
+			calls := 0
+			err := slack.Retry(ctx, 2, delay, func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tc.wantErr) || (err == nil) != (tc.wantErr == nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// DefaultDedupeTTL is how long a Router remembers events by default. Slack gives up retrying an
+// event well within this time.
+const DefaultDedupeTTL = time.Hour
+
+// DedupeStore remembers which events are being or have been handled, so that an event Slack
+// delivers more than once is only handled once. Implementations must be safe for concurrent use;
+// a store shared between replicas of a bot deduplicates across all of them.
+type DedupeStore interface {
+	// Claim records that the event with the given ID is being handled, and reports whether it
+	// was unclaimed. A claim lasts for ttl.
+	Claim(ctx context.Context, eventID string, ttl time.Duration) (bool, error)
+	// Release forgets the claim on an event, so that the next delivery of it is handled.
+	Release(ctx context.Context, eventID string) error
+}
+
+// MemoryDedupeStore is a DedupeStore that keeps claims in memory.
+type MemoryDedupeStore struct {
+	mu        sync.Mutex
+	claims    map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryDedupeStore returns an empty MemoryDedupeStore.
+func NewMemoryDedupeStore() *MemoryDedupeStore {
+	return &MemoryDedupeStore{claims: map[string]time.Time{}, now: time.Now}
+}
+
+// Claim implements DedupeStore.
+func (s *MemoryDedupeStore) Claim(ctx context.Context, eventID string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for id, expiry := range s.claims {
+			if !now.Before(expiry) {
+				delete(s.claims, id)
+			}
+		}
+		s.nextSweep = now.Add(ttl)
+	}
+	if expiry, ok := s.claims[eventID]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	s.claims[eventID] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements DedupeStore.
+func (s *MemoryDedupeStore) Release(ctx context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, eventID)
+	return nil
+}
+
+// RetryPolicy decides whether to handle an event that Slack is delivering again.
+type RetryPolicy int
+
+const (
+	// HandleRetries handles retried events like any other. If the Router deduplicates events,
+	// a retry is only handled if no earlier delivery is being or has been handled successfully.
+	HandleRetries RetryPolicy = iota
+	// IgnoreRetries acknowledges retried events without handling them, even if the earlier
+	// delivery failed. Use it for events where acting twice is worse than not acting at all.
+	IgnoreRetries
+	// IgnoreTimeoutRetries ignores events retried because the earlier delivery timed out, since
+	// that delivery is probably still being handled, but handles other retries.
+	IgnoreTimeoutRetries
+)
+
+func (p RetryPolicy) handles(r slack.Redelivery) bool {
+	switch p {
+	case IgnoreRetries:
+		return false
+	case IgnoreTimeoutRetries:
+		return r.Reason != "http_timeout"
+	default:
+		return true
+	}
+}
+
+// EventOption configures how a Router handles an event type.
+type EventOption func(*eventRoute)
+
+// WithRetryPolicy sets the RetryPolicy for an event type. The default is HandleRetries.
+func WithRetryPolicy(p RetryPolicy) EventOption {
+	return func(e *eventRoute) {
+		e.retries = p
+	}
+}
+
+// DedupeStats counts the deliveries a Router acknowledged without handling.
+type DedupeStats struct {
+	// Duplicates counts deliveries of events that had already been claimed.
+	Duplicates int64
+	// IgnoredRetries counts retried deliveries that an event's RetryPolicy turned away.
+	IgnoredRetries int64
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+type delivery struct {
+	eventType   string
+	eventID     string
+	retry       int
+	retryReason string
+}
+
+func TestDeduplication(t *testing.T) {
+	tests := []struct {
+		name           string
+		dedupe         bool
+		deliveries     []delivery
+		failFirst      bool
+		wantHandled    int
+		wantStatus     []int
+		wantDuplicates int64
+		wantIgnored    int64
+	}{
+		{
+			name:        "without deduplication, every delivery is handled",
+			deliveries:  []delivery{{eventID: "Ev1"}, {eventID: "Ev1", retry: 1, retryReason: "http_error"}},
+			wantHandled: 2,
+			wantStatus:  []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:           "duplicates are dropped",
+			dedupe:         true,
+			deliveries:     []delivery{{eventID: "Ev1"}, {eventID: "Ev1", retry: 1, retryReason: "http_timeout"}, {eventID: "Ev2"}},
+			wantHandled:    2,
+			wantStatus:     []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantDuplicates: 1,
+		},
+		{
+			name:        "failed events are handled again when retried",
+			dedupe:      true,
+			failFirst:   true,
+			deliveries:  []delivery{{eventID: "Ev1"}, {eventID: "Ev1", retry: 1, retryReason: "http_error"}},
+			wantHandled: 2,
+			wantStatus:  []int{http.StatusInternalServerError, http.StatusOK},
+		},
+		{
+			name:        "events without IDs are not deduplicated",
+			dedupe:      true,
+			deliveries:  []delivery{{}, {}},
+			wantHandled: 2,
+			wantStatus:  []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:        "IgnoreRetries drops retries even if the first delivery failed",
+			dedupe:      true,
+			failFirst:   true,
+			deliveries:  []delivery{{eventType: "team_join", eventID: "Ev1"}, {eventType: "team_join", eventID: "Ev1", retry: 1, retryReason: "http_error"}},
+			wantHandled: 1,
+			wantStatus:  []int{http.StatusInternalServerError, http.StatusOK},
+			wantIgnored: 1,
+		},
+		{
+			name: "IgnoreTimeoutRetries only drops timeouts",
+			deliveries: []delivery{
+				{eventType: "member_joined_channel", eventID: "Ev1"},
+				{eventType: "member_joined_channel", eventID: "Ev1", retry: 1, retryReason: "http_timeout"},
+				{eventType: "member_joined_channel", eventID: "Ev1", retry: 2, retryReason: "http_error"},
+			},
+			wantHandled: 2,
+			wantStatus:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantIgnored: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handled := 0
+			h := func(ctx context.Context, cb *events.Callback) error {
+				handled++
+				if tc.failFirst && handled == 1 {
+					return errors.New("broken")
+				}
+				return nil
+			}
+			r := events.NewRouter()
+			r.Event("channel_created", h)
+			r.Event("team_join", h, events.WithRetryPolicy(events.IgnoreRetries))
+			r.Event("member_joined_channel", h, events.WithRetryPolicy(events.IgnoreTimeoutRetries))
+			if tc.dedupe {
+				r.Deduplicate(events.NewMemoryDedupeStore(), 0)
+			}
+
+			for i, d := range tc.deliveries {
+				if d.eventType == "" {
+					d.eventType = "channel_created"
+				}
+				body := fmt.Sprintf(`{"type": "event_callback", "event_id": %q, "event": {"type": %q}}`, d.eventID, d.eventType)
+				req := slacktest.NewEventRequest(testSigningSecret, []byte(body))
+				if d.retry > 0 {
+					req.Header.Set("X-Slack-Retry-Num", strconv.Itoa(d.retry))
+					req.Header.Set("X-Slack-Retry-Reason", d.retryReason)
+				}
+				if rr := serve(r, req); rr.Code != tc.wantStatus[i] {
+					t.Errorf("Expected delivery %d to get status %d, got %d", i, tc.wantStatus[i], rr.Code)
+				}
+			}
+			if handled != tc.wantHandled {
+				t.Errorf("Expected %d deliveries to be handled, got %d", tc.wantHandled, handled)
+			}
+			stats := r.DedupeStats()
+			if stats.Duplicates != tc.wantDuplicates {
+				t.Errorf("Expected %d duplicates, got %d", tc.wantDuplicates, stats.Duplicates)
+			}
+			if stats.IgnoredRetries != tc.wantIgnored {
+				t.Errorf("Expected %d ignored retries, got %d", tc.wantIgnored, stats.IgnoredRetries)
+			}
+		})
+	}
+}
+
+func TestMemoryDedupeStoreExpiresClaims(t *testing.T) {
+	s := events.NewMemoryDedupeStore()
+	ctx := context.Background()
+	if ok, _ := s.Claim(ctx, "Ev1", 0); !ok {
+		t.Fatalf("Expected the first claim to succeed")
+	}
+	if ok, _ := s.Claim(ctx, "Ev1", time.Hour); !ok {
+		t.Errorf("Expected a claim to be available again once it has expired")
+	}
+	if ok, _ := s.Claim(ctx, "Ev1", time.Hour); ok {
+		t.Errorf("Expected a second claim within the TTL to fail")
+	}
+	if err := s.Release(ctx, "Ev1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if ok, _ := s.Claim(ctx, "Ev1", time.Hour); !ok {
+		t.Errorf("Expected a released event to be claimable")
+	}
+}
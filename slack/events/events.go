@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events receives requests from Slack's Events API and interactivity features, and
+// routes them to handlers.
+package events
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// Callback is the envelope in which Slack delivers Events API events.
+type Callback struct {
+	Token        string          `json:"token"`
+	TeamID       string          `json:"team_id"`
+	EnterpriseID string          `json:"enterprise_id"`
+	APIAppID     string          `json:"api_app_id"`
+	Type         string          `json:"type"`
+	EventID      string          `json:"event_id"`
+	EventTime    int64           `json:"event_time"`
+	Challenge    string          `json:"challenge,omitempty"`
+	Event        json.RawMessage `json:"event,omitempty"`
+
+	// eventType is the type of Event.
+	eventType string
+}
+
+// EventType returns the type of the event in the callback, e.g. "team_join".
+func (c *Callback) EventType() string {
+	return c.eventType
+}
+
+// InstalledTeam returns the workspace the event was sent for.
+func (c *Callback) InstalledTeam() slack.Team {
+	return slack.Team{EnterpriseID: c.EnterpriseID, ID: c.TeamID}
+}
+
+// Decode unmarshals the event in the callback into v, which should usually be one of the event
+// types in this package.
+func (c *Callback) Decode(v interface{}) error {
+	return json.Unmarshal(c.Event, v)
+}
+
+// MessageEvent is sent for the message event, and is the message in message shortcuts.
+type MessageEvent struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+	ChannelType string `json:"channel_type,omitempty"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts,omitempty"`
+	EventTS     string `json:"event_ts,omitempty"`
+	// Blocks holds the message's Block Kit blocks, if any, for slack.ParseMessage.
+	Blocks json.RawMessage `json:"blocks,omitempty"`
+}
+
+// IsThreadReply returns true if the message is a reply in a thread, rather than the message
+// starting it or one not in a thread at all.
+func (m MessageEvent) IsThreadReply() bool {
+	return m.ThreadTS != "" && m.ThreadTS != m.TS
+}
+
+// IsFromBot returns true if the message was posted by a bot.
+func (m MessageEvent) IsFromBot() bool {
+	return m.BotID != "" || m.Subtype == "bot_message"
+}
+
+// UserEvent is sent for the team_join and user_change events.
+type UserEvent struct {
+	Type string     `json:"type"`
+	User slack.User `json:"user"`
+}
+
+// Channel is the channel in the channel_created and channel_rename events.
+type Channel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Created int64  `json:"created"`
+	Creator string `json:"creator,omitempty"`
+}
+
+// ChannelCreatedEvent is sent for the channel_created and channel_rename events.
+type ChannelCreatedEvent struct {
+	Type    string  `json:"type"`
+	Channel Channel `json:"channel"`
+}
+
+// ChannelEvent is sent for the channel_archive, channel_unarchive and channel_deleted events.
+type ChannelEvent struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	User    string `json:"user,omitempty"`
+}
+
+// EmojiChangedEvent is sent for the emoji_changed event.
+type EmojiChangedEvent struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	// Name and Value are set when an emoji is added. Value is either a URL or "alias:" followed
+	// by the name of another emoji.
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+	// Names is set when an emoji is removed, and contains every name it had.
+	Names []string `json:"names,omitempty"`
+}
+
+// SubteamEvent is sent for the subteam_created and subteam_updated events.
+type SubteamEvent struct {
+	Type    string        `json:"type"`
+	Subteam slack.Subteam `json:"subteam"`
+}
+
+// TeamRenameEvent is sent for the team_rename event.
+type TeamRenameEvent struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// TeamDomainChangeEvent is sent for the team_domain_change event.
+type TeamDomainChangeEvent struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Domain string `json:"domain"`
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+)
+
+// Interaction is the payload Slack sends when a user interacts with an app, e.g. through a
+// shortcut, a button or a modal.
+type Interaction struct {
+	Type        string `json:"type"`
+	Token       string `json:"token"`
+	CallbackID  string `json:"callback_id"`
+	TriggerID   string `json:"trigger_id"`
+	ResponseURL string `json:"response_url"`
+	ActionTS    string `json:"action_ts"`
+	Team        struct {
+		ID     string `json:"id"`
+		Domain string `json:"domain"`
+	} `json:"team"`
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+	User struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Username string `json:"username"`
+		TeamID   string `json:"team_id"`
+	} `json:"user"`
+	// Enterprise is set if the workspace is part of an Enterprise Grid organisation.
+	Enterprise *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"enterprise"`
+	// IsEnterpriseInstall is set if the app was installed across the whole organisation.
+	IsEnterpriseInstall bool `json:"is_enterprise_install"`
+
+	// Message is the message a message shortcut was used on.
+	Message MessageEvent `json:"message"`
+	// View is the view that was submitted, or that contained the actions.
+	View blockkit.ViewPayload `json:"view"`
+	// Actions are the elements that were used in a block_actions interaction.
+	Actions []Action `json:"actions"`
+}
+
+// InstalledTeam returns the workspace the interaction happened in, or only the organisation if
+// the app was installed across the whole organisation.
+func (i *Interaction) InstalledTeam() slack.Team {
+	team := slack.Team{ID: i.Team.ID}
+	if i.Enterprise != nil {
+		team.EnterpriseID = i.Enterprise.ID
+	}
+	if i.IsEnterpriseInstall {
+		team.ID = ""
+	}
+	return team
+}
+
+// Action is an interactive element that was used in a block_actions interaction.
+type Action struct {
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	ActionTS string `json:"action_ts"`
+	blockkit.Value
+}
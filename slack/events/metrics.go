@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	handled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_requests_handled_total",
+		Help: "Requests from Slack passed to a handler, by kind (event, shortcut, message_shortcut, block_action or view_submission), event type or ID, and outcome (ok or error).",
+	}, []string{"kind", "id", "outcome"})
+	handlingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "slack_request_handling_duration_seconds",
+		Help:    "How long handlers took to handle requests from Slack, by kind and event type or ID.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind", "id"})
+	dropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_events_dropped_total",
+		Help: "Events acknowledged without being handled, by event type and reason (duplicate or ignored_retry).",
+	}, []string{"event_type", "reason"})
+)
+
+func init() {
+	prometheus.MustRegister(handled, handlingDuration, dropped)
+}
+
+// observe records that a handler for the given kind of request and ID, which started at start,
+// returned err.
+func observe(kind, id string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	handled.WithLabelValues(kind, id, outcome).Inc()
+	handlingDuration.WithLabelValues(kind, id).Observe(time.Since(start).Seconds())
+}
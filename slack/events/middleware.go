@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// MaxBodySize is the size of the largest request body Handler accepts. Slack's requests are
+// much smaller than this.
+const MaxBodySize = 1 << 20
+
+// Middleware wraps an http.Handler to add behaviour to it.
+type Middleware func(http.Handler) http.Handler
+
+// Verifier checks that a request really came from Slack. *slack.Client is a Verifier.
+type Verifier interface {
+	VerifySignature(body []byte, headers http.Header) error
+}
+
+// Handler wraps h, which is usually a Router, with the middleware every Slack request handler
+// needs: recovering from panics, limiting the body size, and verifying the signature.
+func Handler(v Verifier, h http.Handler) http.Handler {
+	return Chain(h, Recover, LimitBody(MaxBodySize), VerifySignature(v))
+}
+
+// Chain wraps h in the given middleware. The first middleware sees the request first.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Recover responds with an error when the next handler panics, instead of crashing.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("Panic while handling request: %v\n%s", p, debug.Stack())
+				http.Error(rw, "internal error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// LimitBody rejects requests with bodies larger than n bytes.
+func LimitBody(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(rw, r.Body, n)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// VerifySignature rejects requests that v can't verify came from Slack.
+func VerifySignature(v Verifier) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				log.Printf("Failed to read incoming request body: %v", err)
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(rw, "request body too large", http.StatusRequestEntityTooLarge)
+				} else {
+					http.Error(rw, "failed to read body", http.StatusBadRequest)
+				}
+				return
+			}
+			_ = r.Body.Close()
+			if err := v.VerifySignature(body, r.Header); err != nil {
+				log.Printf("Failed validation: %v", err)
+				http.Error(rw, "signature verification failed", http.StatusForbidden)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// Timeout cancels the request's context after d.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
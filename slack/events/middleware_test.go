@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestHandlerMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    func() *http.Request
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name:       "signed request",
+			request:    func() *http.Request { return slacktest.NewEventRequest(testSigningSecret, []byte(`{}`)) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong signing secret",
+			request:    func() *http.Request { return slacktest.NewEventRequest("wrong", []byte(`{}`)) },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "unsigned request",
+			request: func() *http.Request {
+				return httptest.NewRequest("POST", "/webhook", bytes.NewReader([]byte(`{}`)))
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "oversized body",
+			request: func() *http.Request {
+				return slacktest.NewEventRequest(testSigningSecret, bytes.Repeat([]byte(" "), events.MaxBodySize+1))
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "panicking handler",
+			request:    func() *http.Request { return slacktest.NewEventRequest(testSigningSecret, []byte(`{}`)) },
+			handler:    func(rw http.ResponseWriter, r *http.Request) { panic("oops") },
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tc.handler
+			if h == nil {
+				h = func(rw http.ResponseWriter, r *http.Request) {}
+			}
+			if rr := serve(h, tc.request()); rr.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestVerifiedBodyIsPassedOn(t *testing.T) {
+	var got []byte
+	h := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = make([]byte, 2)
+		_, _ = r.Body.Read(got)
+	})
+	serve(h, slacktest.NewEventRequest(testSigningSecret, []byte(`{}`)))
+	if string(got) != `{}` {
+		t.Errorf("Expected the handler to read the body, got %q", got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	h := events.Chain(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	}), events.Timeout(time.Minute))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("Expected a deadline within a minute, got one in %s", remaining)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+)
+
+// ErrMalformed is returned when Slack sends a request that can't be parsed.
+var ErrMalformed = errors.New("malformed request")
+
+// EventHandler handles an Events API event.
+type EventHandler func(ctx context.Context, cb *Callback) error
+
+// ShortcutHandler handles a global or message shortcut.
+type ShortcutHandler func(ctx context.Context, interaction *Interaction) error
+
+// BlockActionHandler handles a use of an interactive element in a message or view.
+type BlockActionHandler func(ctx context.Context, interaction *Interaction, action Action) error
+
+// ViewSubmissionHandler handles the submission of a view. If it returns a response with a
+// ResponseAction, the response is sent back to Slack; otherwise the view is closed.
+type ViewSubmissionHandler func(ctx context.Context, interaction *Interaction) (blockkit.ViewSubmissionResponse, error)
+
+// Router routes Slack requests to handlers registered by event type, action ID or callback ID.
+// Requests that have no handler are acknowledged and otherwise ignored.
+//
+// Router expects requests to have already been verified; use Handler to do that.
+type Router struct {
+	events           map[string]*eventRoute
+	globalShortcuts  map[string]ShortcutHandler
+	messageShortcuts map[string]ShortcutHandler
+	blockActions     map[string]BlockActionHandler
+	viewSubmissions  map[string]ViewSubmissionHandler
+
+	directory      *slack.Directory
+	timeout        time.Duration
+	dedupe         DedupeStore
+	dedupeTTL      time.Duration
+	duplicates     atomic.Int64
+	ignoredRetries atomic.Int64
+}
+
+// eventRoute is the handler for an event type and how to treat retried deliveries of it.
+type eventRoute struct {
+	handler EventHandler
+	retries RetryPolicy
+}
+
+// NewRouter returns a Router with no handlers.
+func NewRouter() *Router {
+	return &Router{
+		events:           map[string]*eventRoute{},
+		globalShortcuts:  map[string]ShortcutHandler{},
+		messageShortcuts: map[string]ShortcutHandler{},
+		blockActions:     map[string]BlockActionHandler{},
+		viewSubmissions:  map[string]ViewSubmissionHandler{},
+	}
+}
+
+func register(kind string, registered bool, key string) {
+	if key == "" {
+		panic(fmt.Sprintf("events: empty %s", kind))
+	}
+	if registered {
+		panic(fmt.Sprintf("events: multiple handlers for %s %q", kind, key))
+	}
+}
+
+// Event registers a handler for events of the given type, e.g. "team_join".
+func (r *Router) Event(eventType string, h EventHandler, opts ...EventOption) {
+	_, ok := r.events[eventType]
+	register("event type", ok, eventType)
+	route := &eventRoute{handler: h}
+	for _, opt := range opts {
+		opt(route)
+	}
+	r.events[eventType] = route
+}
+
+// Deduplicate makes the Router claim each event in store before handling it, and acknowledge
+// events that have already been claimed without handling them again. If handling an event fails,
+// its claim is released so that Slack's retry is handled. A ttl of zero means DefaultDedupeTTL.
+func (r *Router) Deduplicate(store DedupeStore, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = DefaultDedupeTTL
+	}
+	r.dedupe = store
+	r.dedupeTTL = ttl
+}
+
+// UpdateDirectory makes the Router record the users in every team_join and user_change event it
+// receives in d, whether or not a handler is registered for them.
+func (r *Router) UpdateDirectory(d *slack.Directory) {
+	r.directory = d
+}
+
+// Timeout makes the Router cancel the context passed to its handlers after d, whether the request
+// arrived over HTTP or Socket Mode.
+func (r *Router) Timeout(d time.Duration) {
+	r.timeout = d
+}
+
+// withTimeout applies the Router's timeout, if any, to ctx.
+func (r *Router) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+// DedupeStats returns how many deliveries the Router has acknowledged without handling.
+func (r *Router) DedupeStats() DedupeStats {
+	return DedupeStats{
+		Duplicates:     r.duplicates.Load(),
+		IgnoredRetries: r.ignoredRetries.Load(),
+	}
+}
+
+// GlobalShortcut registers a handler for the global shortcut with the given callback ID.
+func (r *Router) GlobalShortcut(callbackID string, h ShortcutHandler) {
+	_, ok := r.globalShortcuts[callbackID]
+	register("shortcut callback ID", ok, callbackID)
+	r.globalShortcuts[callbackID] = h
+}
+
+// MessageShortcut registers a handler for the message shortcut with the given callback ID.
+func (r *Router) MessageShortcut(callbackID string, h ShortcutHandler) {
+	_, ok := r.messageShortcuts[callbackID]
+	register("message shortcut callback ID", ok, callbackID)
+	r.messageShortcuts[callbackID] = h
+}
+
+// BlockAction registers a handler for uses of interactive elements with the given action ID.
+func (r *Router) BlockAction(actionID string, h BlockActionHandler) {
+	_, ok := r.blockActions[actionID]
+	register("action ID", ok, actionID)
+	r.blockActions[actionID] = h
+}
+
+// ViewSubmission registers a handler for submissions of views with the given callback ID.
+func (r *Router) ViewSubmission(callbackID string, h ViewSubmissionHandler) {
+	_, ok := r.viewSubmissions[callbackID]
+	register("view callback ID", ok, callbackID)
+	r.viewSubmissions[callbackID] = h
+}
+
+// ServeHTTP handles an Events API or interactivity request from Slack.
+func (r *Router) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		log.Printf("Failed to read incoming request body: %v", err)
+		http.Error(rw, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var response []byte
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		var f url.Values
+		f, err = url.ParseQuery(string(body))
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrMalformed, err)
+		} else {
+			response, err = r.HandleInteraction(req.Context(), []byte(f.Get("payload")))
+		}
+	} else {
+		ctx := req.Context()
+		if n, err := strconv.Atoi(req.Header.Get("X-Slack-Retry-Num")); err == nil && n > 0 {
+			ctx = slack.WithRedelivery(ctx, slack.Redelivery{Attempt: n, Reason: req.Header.Get("X-Slack-Retry-Reason")})
+		}
+		response, err = r.HandleEvent(ctx, body)
+	}
+	if err != nil {
+		log.Printf("Failed to handle request: %v", err)
+		if errors.Is(err, ErrMalformed) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if response != nil {
+		rw.Header().Set("Content-Type", "application/json")
+	}
+	_, _ = rw.Write(response)
+}
+
+// HandleEvent handles the body of an Events API request, returning the body of the response to
+// send to Slack, if any.
+func (r *Router) HandleEvent(ctx context.Context, body []byte) ([]byte, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	cb := &Callback{}
+	if err := json.Unmarshal(body, cb); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	switch cb.Type {
+	case "url_verification":
+		return json.Marshal(map[string]string{"challenge": cb.Challenge})
+	case "event_callback":
+		t := struct {
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(cb.Event, &t); err != nil {
+			return nil, fmt.Errorf("%w: couldn't parse event: %v", ErrMalformed, err)
+		}
+		cb.eventType = t.Type
+		if r.directory != nil && (t.Type == "team_join" || t.Type == "user_change") {
+			e := UserEvent{}
+			if err := cb.Decode(&e); err != nil {
+				return nil, fmt.Errorf("%w: couldn't parse %s event: %v", ErrMalformed, t.Type, err)
+			}
+			r.directory.Update(e.User)
+		}
+		route, ok := r.events[t.Type]
+		if !ok {
+			return nil, nil
+		}
+		if retry, ok := slack.RedeliveryFromContext(ctx); ok && !route.retries.handles(retry) {
+			log.Printf("Ignoring retry %d of %s event %s (%s)", retry.Attempt, t.Type, cb.EventID, retry.Reason)
+			r.ignoredRetries.Add(1)
+			dropped.WithLabelValues(t.Type, "ignored_retry").Inc()
+			return nil, nil
+		}
+		claimed := false
+		if r.dedupe != nil && cb.EventID != "" {
+			var err error
+			claimed, err = r.dedupe.Claim(ctx, cb.EventID, r.dedupeTTL)
+			if err != nil {
+				// Handling an event twice is better than not handling it at all.
+				log.Printf("Failed to claim %s event %s, handling it anyway: %v", t.Type, cb.EventID, err)
+			} else if !claimed {
+				log.Printf("Ignoring duplicate %s event %s", t.Type, cb.EventID)
+				r.duplicates.Add(1)
+				dropped.WithLabelValues(t.Type, "duplicate").Inc()
+				return nil, nil
+			}
+		}
+		ctx = slack.WithTeam(ctx, cb.InstalledTeam())
+		start := time.Now()
+		err := route.handler(ctx, cb)
+		observe("event", t.Type, start, err)
+		if err != nil {
+			if claimed {
+				if err := r.dedupe.Release(context.WithoutCancel(ctx), cb.EventID); err != nil {
+					log.Printf("Failed to release %s event %s: %v", t.Type, cb.EventID, err)
+				}
+			}
+			return nil, fmt.Errorf("%s: %w", t.Type, err)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown request type %q", ErrMalformed, cb.Type)
+	}
+}
+
+// HandleInteraction handles an interaction payload, returning the body of the response to send to
+// Slack, if any.
+func (r *Router) HandleInteraction(ctx context.Context, payload []byte) ([]byte, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("%w: payload was blank", ErrMalformed)
+	}
+	interaction := &Interaction{}
+	if err := json.Unmarshal(payload, interaction); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	ctx = slack.WithTeam(ctx, interaction.InstalledTeam())
+	switch interaction.Type {
+	case "shortcut":
+		if h, ok := r.globalShortcuts[interaction.CallbackID]; ok {
+			start := time.Now()
+			err := h(ctx, interaction)
+			observe("shortcut", interaction.CallbackID, start, err)
+			if err != nil {
+				return nil, fmt.Errorf("shortcut %s: %w", interaction.CallbackID, err)
+			}
+		}
+	case "message_action":
+		if h, ok := r.messageShortcuts[interaction.CallbackID]; ok {
+			start := time.Now()
+			err := h(ctx, interaction)
+			observe("message_shortcut", interaction.CallbackID, start, err)
+			if err != nil {
+				return nil, fmt.Errorf("message shortcut %s: %w", interaction.CallbackID, err)
+			}
+		}
+	case "block_actions":
+		for _, action := range interaction.Actions {
+			if h, ok := r.blockActions[action.ActionID]; ok {
+				start := time.Now()
+				err := h(ctx, interaction, action)
+				observe("block_action", action.ActionID, start, err)
+				if err != nil {
+					return nil, fmt.Errorf("action %s: %w", action.ActionID, err)
+				}
+			}
+		}
+	case "view_submission":
+		h, ok := r.viewSubmissions[interaction.View.CallbackID]
+		if !ok {
+			return nil, nil
+		}
+		start := time.Now()
+		response, err := h(ctx, interaction)
+		observe("view_submission", interaction.View.CallbackID, start, err)
+		if err != nil {
+			return nil, fmt.Errorf("view submission %s: %w", interaction.View.CallbackID, err)
+		}
+		if response.ResponseAction == "" {
+			return nil, nil
+		}
+		return json.Marshal(response)
+	}
+	return nil, nil
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+const testSigningSecret = "secret"
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	events.Handler(slack.New(slack.Config{SigningSecret: testSigningSecret}), h).ServeHTTP(rr, r)
+	return rr
+}
+
+func TestURLVerification(t *testing.T) {
+	rr := serve(events.NewRouter(), slacktest.NewEventRequest(testSigningSecret, []byte(`{"type": "url_verification", "challenge": "abc"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != `{"challenge":"abc"}` {
+		t.Errorf("Expected the challenge to be echoed, got %s", body)
+	}
+}
+
+func TestEventRouting(t *testing.T) {
+	var joined []string
+	r := events.NewRouter()
+	r.Event("team_join", func(ctx context.Context, cb *events.Callback) error {
+		e := events.UserEvent{}
+		if err := cb.Decode(&e); err != nil {
+			return err
+		}
+		joined = append(joined, e.User.ID)
+		return nil
+	})
+	r.Event("channel_created", func(ctx context.Context, cb *events.Callback) error {
+		return errors.New("broken")
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantJoined []string
+	}{
+		{
+			name:       "registered event",
+			body:       `{"type": "event_callback", "event": {"type": "team_join", "user": {"id": "U1"}}}`,
+			wantStatus: http.StatusOK,
+			wantJoined: []string{"U1"},
+		},
+		{
+			name:       "unregistered event",
+			body:       `{"type": "event_callback", "event": {"type": "emoji_changed"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "failing handler",
+			body:       `{"type": "event_callback", "event": {"type": "channel_created"}}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"type": "event_callback", "event": `,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			joined = nil
+			rr := serve(r, slacktest.NewEventRequest(testSigningSecret, []byte(tc.body)))
+			if rr.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d", tc.wantStatus, rr.Code)
+			}
+			if strings.Join(joined, ",") != strings.Join(tc.wantJoined, ",") {
+				t.Errorf("Expected %v to be handled, got %v", tc.wantJoined, joined)
+			}
+		})
+	}
+}
+
+func TestInteractionRouting(t *testing.T) {
+	var handled []string
+	record := func(name string) events.ShortcutHandler {
+		return func(ctx context.Context, i *events.Interaction) error {
+			handled = append(handled, name+":"+i.User.ID)
+			return nil
+		}
+	}
+	r := events.NewRouter()
+	r.GlobalShortcut("write", record("global"))
+	r.MessageShortcut("report", record("message"))
+	r.BlockAction("approve", func(ctx context.Context, i *events.Interaction, a events.Action) error {
+		handled = append(handled, "action:"+a.Value.Value)
+		return nil
+	})
+	r.ViewSubmission("form", func(ctx context.Context, i *events.Interaction) (blockkit.ViewSubmissionResponse, error) {
+		if i.View.State.StringValue("name", "name") == "" {
+			return blockkit.ErrorsResponse(map[string]string{"name": "Required"}), nil
+		}
+		handled = append(handled, "view:"+i.View.State.StringValue("name", "name"))
+		return blockkit.ViewSubmissionResponse{}, nil
+	})
+
+	tests := []struct {
+		name        string
+		payload     string
+		wantHandled string
+		wantBody    string
+	}{
+		{
+			name:        "global shortcut",
+			payload:     `{"type": "shortcut", "callback_id": "write", "user": {"id": "U1"}}`,
+			wantHandled: "global:U1",
+		},
+		{
+			name:        "message shortcut",
+			payload:     `{"type": "message_action", "callback_id": "report", "user": {"id": "U2"}, "message": {"ts": "1.2"}}`,
+			wantHandled: "message:U2",
+		},
+		{
+			name:        "message shortcut with a global shortcut's ID",
+			payload:     `{"type": "message_action", "callback_id": "write", "user": {"id": "U1"}}`,
+			wantHandled: "",
+		},
+		{
+			name:        "block action",
+			payload:     `{"type": "block_actions", "actions": [{"action_id": "other", "value": "no"}, {"action_id": "approve", "type": "button", "value": "yes"}]}`,
+			wantHandled: "action:yes",
+		},
+		{
+			name:        "view submission",
+			payload:     `{"type": "view_submission", "view": {"callback_id": "form", "state": {"values": {"name": {"name": {"type": "plain_text_input", "value": "Ada"}}}}}}`,
+			wantHandled: "view:Ada",
+		},
+		{
+			name:     "view submission with response",
+			payload:  `{"type": "view_submission", "view": {"callback_id": "form", "state": {"values": {}}}}`,
+			wantBody: `{"response_action":"errors","errors":{"name":"Required"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handled = nil
+			rr := serve(r, slacktest.NewInteractionRequest(testSigningSecret, []byte(tc.payload)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if got := strings.Join(handled, ","); got != tc.wantHandled {
+				t.Errorf("Expected %q to be handled, got %q", tc.wantHandled, got)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.wantBody {
+				t.Errorf("Expected response %s, got %s", tc.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestInteractionPayloadParsing(t *testing.T) {
+	var got *events.Interaction
+	r := events.NewRouter()
+	r.MessageShortcut("report", func(ctx context.Context, i *events.Interaction) error {
+		got = i
+		return nil
+	})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":        "message_action",
+		"callback_id": "report",
+		"trigger_id":  "trigger",
+		"team":        map[string]string{"id": "T1", "domain": "example"},
+		"channel":     map[string]string{"id": "C1", "name": "general"},
+		"user":        map[string]string{"id": "U1", "name": "someone"},
+		"message":     map[string]string{"user": "U2", "ts": "1.2", "text": "hello"},
+	})
+	if _, err := r.HandleInteraction(context.Background(), payload); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got == nil {
+		t.Fatalf("Expected the shortcut to be handled")
+	}
+	if got.TriggerID != "trigger" || got.Team.Domain != "example" || got.Channel.Name != "general" || got.User.Name != "someone" {
+		t.Errorf("Interaction was not parsed correctly: %+v", got)
+	}
+	if got.Message.User != "U2" || got.Message.TS != "1.2" || got.Message.Text != "hello" {
+		t.Errorf("Message was not parsed correctly: %+v", got.Message)
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	r := events.NewRouter()
+	r.Event("message", func(ctx context.Context, cb *events.Callback) error { return nil })
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a second handler to panic")
+		}
+	}()
+	r.Event("message", func(ctx context.Context, cb *events.Callback) error { return nil })
+}
+
+func TestRouterUpdatesDirectory(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	d := slack.NewDirectory(s.Client(slack.Config{}), 0)
+	r := events.NewRouter()
+	r.UpdateDirectory(d)
+
+	body := `{"type": "event_callback", "event": {"type": "user_change", "user": {"id": "U1", "name": "alice", "is_admin": true}}}`
+	if rr := serve(r, slacktest.NewEventRequest(testSigningSecret, []byte(body))); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	u, err := d.User(context.Background(), "U1")
+	if err != nil {
+		t.Fatalf("Expected the user to be in the directory, got %v", err)
+	}
+	if !u.IsModerator() {
+		t.Errorf("Expected the user to be a moderator, got %+v", u)
+	}
+	if calls := s.CallsTo("users.info"); len(calls) != 0 {
+		t.Errorf("Expected no calls to users.info, got %d", len(calls))
+	}
+}
+
+func TestRouterTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(r *events.Router) error
+	}{
+		{
+			name: "event",
+			handle: func(r *events.Router) error {
+				_, err := r.HandleEvent(context.Background(), []byte(`{"type": "event_callback", "event": {"type": "team_join"}}`))
+				return err
+			},
+		},
+		{
+			name: "interaction",
+			handle: func(r *events.Router) error {
+				_, err := r.HandleInteraction(context.Background(), []byte(`{"type": "shortcut", "callback_id": "report"}`))
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var deadline time.Time
+			var ok bool
+			r := events.NewRouter()
+			r.Timeout(time.Minute)
+			r.Event("team_join", func(ctx context.Context, cb *events.Callback) error {
+				deadline, ok = ctx.Deadline()
+				return nil
+			})
+			r.GlobalShortcut("report", func(ctx context.Context, interaction *events.Interaction) error {
+				deadline, ok = ctx.Deadline()
+				return nil
+			})
+			if err := tc.handle(r); err != nil {
+				t.Fatalf("Failed to handle request: %v", err)
+			}
+			if !ok || time.Until(deadline) > time.Minute {
+				t.Errorf("Expected a deadline within a minute, got %v (%v)", deadline, ok)
+			}
+		})
+	}
+}
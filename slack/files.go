@@ -0,0 +1,228 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// File is a file shared in Slack.
+type File struct {
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Title              string   `json:"title"`
+	Mimetype           string   `json:"mimetype"`
+	Filetype           string   `json:"filetype"`
+	Size               int64    `json:"size"`
+	User               string   `json:"user"`
+	Created            int64    `json:"created"`
+	URLPrivate         string   `json:"url_private"`
+	URLPrivateDownload string   `json:"url_private_download"`
+	Permalink          string   `json:"permalink"`
+	Channels           []string `json:"channels"`
+	Groups             []string `json:"groups"`
+	IMs                []string `json:"ims"`
+}
+
+// UploadFileRequest describes a file to upload with UploadFile.
+type UploadFileRequest struct {
+	// Filename is the name of the file, which Slack uses to guess its type.
+	Filename string
+	// Title is the title shown for the file. If empty, Slack uses the filename.
+	Title string
+	// Content is the content of the file.
+	Content io.Reader
+	// Length is the length of Content in bytes. If zero, Content is read into memory first to
+	// find out.
+	Length int64
+	// AltText is a description of an image, for screen readers.
+	AltText string
+	// Channel, if set, is the conversation to share the file in. Otherwise the file is private
+	// to the uploader until shared.
+	Channel string
+	// ThreadTS, if set, shares the file as a reply in that thread of Channel.
+	ThreadTS string
+	// InitialComment is the text of the message the file is shared with, in mrkdwn.
+	InitialComment string
+}
+
+// UploadFile uploads a file using Slack's external upload flow: it asks Slack for an upload URL,
+// sends the content there, then completes the upload, sharing the file if the request names a
+// channel. It returns the new file, of which only the ID and title are guaranteed to be set.
+func (c *Client) UploadFile(ctx context.Context, req UploadFileRequest) (File, error) {
+	content, length := req.Content, req.Length
+	if content == nil {
+		content = bytes.NewReader(nil)
+	}
+	if length <= 0 {
+		b, err := ioutil.ReadAll(content)
+		if err != nil {
+			return File{}, fmt.Errorf("failed to read %s: %v", req.Filename, err)
+		}
+		if len(b) == 0 {
+			return File{}, fmt.Errorf("can't upload %s: it is empty", req.Filename)
+		}
+		content, length = bytes.NewReader(b), int64(len(b))
+	}
+
+	args := map[string]string{"filename": req.Filename, "length": strconv.FormatInt(length, 10)}
+	if req.AltText != "" {
+		args["alt_txt"] = req.AltText
+	}
+	target := struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "files.getUploadURLExternal", args, &target); err != nil {
+		return File{}, fmt.Errorf("failed to start uploading %s: %w", req.Filename, err)
+	}
+	if err := c.sendUpload(ctx, target.UploadURL, content, length); err != nil {
+		return File{}, fmt.Errorf("failed to upload %s: %w", req.Filename, err)
+	}
+
+	type uploadedFile struct {
+		ID    string `json:"id"`
+		Title string `json:"title,omitempty"`
+	}
+	files, err := json.Marshal([]uploadedFile{{ID: target.FileID, Title: req.Title}})
+	if err != nil {
+		return File{}, fmt.Errorf("failed to marshal file list: %v", err)
+	}
+	args = map[string]string{"files": string(files)}
+	if req.Channel != "" {
+		args["channel_id"] = req.Channel
+	}
+	if req.ThreadTS != "" {
+		args["thread_ts"] = req.ThreadTS
+	}
+	if req.InitialComment != "" {
+		args["initial_comment"] = req.InitialComment
+	}
+	ret := struct {
+		Files []File `json:"files"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "files.completeUploadExternal", args, &ret); err != nil {
+		return File{}, fmt.Errorf("failed to complete upload of %s: %w", req.Filename, err)
+	}
+	if len(ret.Files) == 0 {
+		return File{ID: target.FileID, Title: req.Title}, nil
+	}
+	return ret.Files[0], nil
+}
+
+// sendUpload sends file content to an upload URL returned by files.getUploadURLExternal. The URL
+// itself authorizes the upload, so no token is sent. Since content can only be read once, the
+// upload is not retried.
+func (c *Client) sendUpload(ctx context.Context, uploadURL string, content io.Reader, length int64) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, content)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.ContentLength = length
+	req.Header.Set("Content-Type", "application/octet-stream")
+	response, err := c.httpClient().Do(req)
+	if err != nil {
+		return ErrTransport{Err: err}
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodyLength))
+		return ErrHTTP{StatusCode: response.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// GetFileInfo returns information about the file with the given ID.
+func (c *Client) GetFileInfo(ctx context.Context, id string) (File, error) {
+	ret := struct {
+		File File `json:"file"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "files.info", map[string]string{"file": id}, &ret); err != nil {
+		return File{}, fmt.Errorf("failed to get file %s: %w", id, err)
+	}
+	return ret.File, nil
+}
+
+// DeleteFile deletes the file with the given ID.
+func (c *Client) DeleteFile(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "files.delete", map[string]string{"file": id}, nil); err != nil {
+		return fmt.Errorf("failed to delete file %s: %w", id, err)
+	}
+	return nil
+}
+
+// DownloadFile writes the content of a private file URL, such as File.URLPrivateDownload, to w,
+// returning the number of bytes written. The client's token is needed to fetch the URL, so it
+// must point at Slack (or the client's APIURL host); other URLs are refused rather than leak the
+// token. Downloading requires the files:read scope.
+func (c *Client) DownloadFile(ctx context.Context, fileURL string, w io.Writer) (int64, error) {
+	if err := c.checkDownloadURL(fileURL); err != nil {
+		return 0, err
+	}
+	token, err := c.accessToken(ctx, "files.info")
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	response, err := c.httpClient().Do(req)
+	if err != nil {
+		return 0, ErrTransport{Err: err}
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodyLength))
+		return 0, ErrHTTP{StatusCode: response.StatusCode, Body: string(body)}
+	}
+	// Rather than failing, Slack serves its login page when the token can't read the file.
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/html") {
+		return 0, fmt.Errorf("slack returned a web page instead of the file at %s; is the token missing the files:read scope?", fileURL)
+	}
+	n, err := io.Copy(w, response.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to download %s: %v", fileURL, err)
+	}
+	return n, nil
+}
+
+// checkDownloadURL returns an error unless u is a URL the client will send its token to.
+func (c *Client) checkDownloadURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid file URL %q: %v", u, err)
+	}
+	host := parsed.Hostname()
+	if parsed.Scheme == "https" && (host == "slack.com" || strings.HasSuffix(host, ".slack.com")) {
+		return nil
+	}
+	if api, err := url.Parse(c.methodURL("")); err == nil && parsed.Scheme == api.Scheme && parsed.Host == api.Host {
+		return nil
+	}
+	return fmt.Errorf("refusing to download %s: it is not a Slack URL", u)
+}
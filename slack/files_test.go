@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestUploadAndDownloadFile(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "moderation", IsMember: true})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	uploaded, err := c.UploadFile(ctx, slack.UploadFileRequest{
+		Filename:       "evidence.txt",
+		Title:          "Evidence",
+		Content:        strings.NewReader("some evidence"),
+		Channel:        channel,
+		InitialComment: "Here it is",
+	})
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+	if uploaded.ID == "" || uploaded.Title != "Evidence" {
+		t.Errorf("Expected a file titled Evidence, got %+v", uploaded)
+	}
+	if calls := s.CallsTo("files.getUploadURLExternal"); len(calls) != 1 || calls[0].Arg("length") != "13" {
+		t.Errorf("Expected one upload URL request for 13 bytes, got %+v", calls)
+	}
+	messages := s.Messages(channel)
+	if len(messages) != 1 || messages[0].Text != "Here it is" || len(messages[0].Files) != 1 || messages[0].Files[0].ID != uploaded.ID {
+		t.Errorf("Expected the file to be shared with its comment, got %+v", messages)
+	}
+
+	info, err := c.GetFileInfo(ctx, uploaded.ID)
+	if err != nil {
+		t.Fatalf("Failed to get file info: %v", err)
+	}
+	if info.Name != "evidence.txt" || info.Size != 13 || len(info.Channels) != 1 || info.Channels[0] != channel {
+		t.Errorf("Expected evidence.txt in %s, got %+v", channel, info)
+	}
+
+	var buf bytes.Buffer
+	n, err := c.DownloadFile(ctx, info.URLPrivateDownload, &buf)
+	if err != nil {
+		t.Fatalf("Failed to download file: %v", err)
+	}
+	if n != 13 || buf.String() != "some evidence" {
+		t.Errorf("Expected to download %q, got %d bytes: %q", "some evidence", n, buf.String())
+	}
+
+	if err := c.DeleteFile(ctx, uploaded.ID); err != nil {
+		t.Errorf("Failed to delete file: %v", err)
+	}
+	if _, err := c.GetFileInfo(ctx, uploaded.ID); !errors.Is(err, slack.ErrFileNotFound) {
+		t.Errorf("Expected file_not_found after deleting, got %v", err)
+	}
+}
+
+func TestUploadFileWithoutChannelIsPrivate(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+
+	uploaded, err := c.UploadFile(context.Background(), slack.UploadFileRequest{Filename: "report.csv", Content: bytes.NewReader([]byte("a,b\n")), Length: 4})
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+	files := s.Files()
+	if len(files) != 1 || files[0].ID != uploaded.ID || len(files[0].Channels) != 0 || string(files[0].Content) != "a,b\n" {
+		t.Errorf("Expected one unshared file, got %+v", files)
+	}
+}
+
+func TestUploadFileFailures(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "elsewhere"})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	if _, err := c.UploadFile(ctx, slack.UploadFileRequest{Filename: "empty.txt", Content: strings.NewReader("")}); err == nil {
+		t.Errorf("Expected uploading an empty file to fail")
+	}
+	_, err := c.UploadFile(ctx, slack.UploadFileRequest{Filename: "a.txt", Content: strings.NewReader("a"), Channel: channel})
+	if !errors.Is(err, slack.ErrNotInChannel) {
+		t.Errorf("Expected not_in_channel sharing to a channel the bot isn't in, got %v", err)
+	}
+	if len(s.Files()) != 0 {
+		t.Errorf("Expected no files after a failed upload, got %+v", s.Files())
+	}
+}
+
+func TestDownloadFileRefusesOtherHosts(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+
+	for _, u := range []string{
+		"https://example.com/files/F1/a.txt",
+		"https://slack.com.example.com/files/F1/a.txt",
+		"http://files.slack.com/files-pri/T1-F1/a.txt",
+		"not a url\x7f",
+	} {
+		if _, err := c.DownloadFile(context.Background(), u, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected downloading %q to be refused", u)
+		}
+	}
+	if calls := s.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no requests, got %+v", calls)
+	}
+}
+
+func TestDownloadFileDetectsLoginPage(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	id := s.AddFile(slacktest.File{Name: "a.txt", Content: []byte("a")})
+	c := s.Client(slack.Config{}).WithToken("")
+
+	url := s.Files()[0].URLPrivate
+	if _, err := c.DownloadFile(context.Background(), url, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "files:read") {
+		t.Errorf("Expected downloading %s without a token to mention files:read, got %v", id, err)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	apiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "slack_api_calls_total",
+		Help: "Calls made to the Slack API, by method and outcome: ok, the Slack error type, rate_limited, http_error or transport_error.",
+	}, []string{"method", "outcome"})
+	apiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "slack_api_call_duration_seconds",
+		Help:    "How long calls to the Slack API took, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	rateLimitWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "slack_api_rate_limit_wait_seconds",
+		Help:    "How long calls to the Slack API waited because of rate limits, by method.",
+		Buckets: []float64{.01, .1, .5, 1, 5, 10, 30, 60, 120, 300},
+	}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(apiCalls, apiCallDuration, rateLimitWait)
+}
+
+// methodLabel returns the label to record calls to api under. Webhook URLs are secret, so they
+// are all recorded as "webhook".
+func methodLabel(api string) string {
+	if !isMethodName(api) {
+		return "webhook"
+	}
+	return api
+}
+
+// outcomeLabel returns the label describing the result of a call that returned err.
+func outcomeLabel(err error) string {
+	var slackErr ErrSlack
+	var rateLimit ErrRateLimit
+	var httpErr ErrHTTP
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &rateLimit):
+		return "rate_limited"
+	case errors.As(err, &slackErr):
+		return slackErr.Type
+	case errors.As(err, &httpErr):
+		return "http_error"
+	default:
+		return "transport_error"
+	}
+}
+
+// observeCall records a single attempt to call api.
+func observeCall(api string, start time.Time, err error) {
+	method := methodLabel(api)
+	apiCalls.WithLabelValues(method, outcomeLabel(err)).Inc()
+	apiCallDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// observeRateLimitWait records time spent waiting before calling api.
+func observeRateLimitWait(api string, wait time.Duration) {
+	rateLimitWait.WithLabelValues(methodLabel(api)).Observe(wait.Seconds())
+}
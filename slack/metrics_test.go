@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+// metricValue returns the value of the counter or the sample count of the histogram with the
+// given name and labels in the default registry.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return value(m)
+		}
+	}
+	return 0
+}
+
+func value(m *dto.Metric) float64 {
+	if m.GetHistogram() != nil {
+		return float64(m.GetHistogram().GetSampleCount())
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestClientMetrics(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	id := s.AddUser(slack.User{Name: "user"})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	outcomes := []string{"ok", "user_not_found", "rate_limited"}
+	before := map[string]float64{}
+	for _, o := range outcomes {
+		before[o] = metricValue(t, "slack_api_calls_total", map[string]string{"method": "users.info", "outcome": o})
+	}
+	beforeWaits := metricValue(t, "slack_api_rate_limit_wait_seconds", map[string]string{"method": "users.info"})
+
+	if _, err := c.GetUser(ctx, id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.InjectError("users.info", "user_not_found")
+	if _, err := c.GetUser(ctx, id); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	s.InjectRateLimit("users.info", 0)
+	if _, err := c.GetUser(ctx, id); err != nil {
+		t.Fatalf("Expected the rate limited call to be retried, got %v", err)
+	}
+
+	want := map[string]float64{"ok": 2, "user_not_found": 1, "rate_limited": 1}
+	for _, o := range outcomes {
+		got := metricValue(t, "slack_api_calls_total", map[string]string{"method": "users.info", "outcome": o}) - before[o]
+		if got != want[o] {
+			t.Errorf("Expected %v calls with outcome %q, got %v", want[o], o, got)
+		}
+	}
+	if got := metricValue(t, "slack_api_rate_limit_wait_seconds", map[string]string{"method": "users.info"}) - beforeWaits; got != 1 {
+		t.Errorf("Expected one rate limit wait to be recorded, got %v", got)
+	}
+}
+
+func TestWebhookURLsAreNotMetricLabels(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+	before := metricValue(t, "slack_api_calls_total", map[string]string{"method": "webhook", "outcome": "ok"})
+	if err := c.SendMessage("hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := metricValue(t, "slack_api_calls_total", map[string]string{"method": "webhook", "outcome": "ok"}) - before; got != 1 {
+		t.Errorf("Expected the webhook call to be recorded as method \"webhook\", got %v calls", got)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package moderators reads and writes the list of channel moderators that Tempelis exports for
+// the moderator bot, and keeps it up to date as it changes.
+package moderators
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// List maps channel IDs to the IDs of the users who moderate them.
+type List struct {
+	Channels map[string][]string `json:"channels"`
+}
+
+// IsModerator returns true if user moderates the given channel.
+func (l List) IsModerator(channel, user string) bool {
+	for _, u := range l.Channels[channel] {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reads a List written by Write.
+func Read(r io.Reader) (List, error) {
+	var l List
+	if err := json.NewDecoder(r).Decode(&l); err != nil {
+		return List{}, fmt.Errorf("failed to parse moderators: %v", err)
+	}
+	return l, nil
+}
+
+// Load reads the List in the file at path.
+func Load(path string) (List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return List{}, fmt.Errorf("failed to open moderators: %v", err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Write writes l as indented JSON, with each channel's moderators sorted, so that the output only
+// changes when the moderators do.
+func Write(w io.Writer, l List) error {
+	sorted := List{Channels: map[string][]string{}}
+	for channel, users := range l.Channels {
+		if len(users) == 0 {
+			continue
+		}
+		users = append([]string{}, users...)
+		sort.Strings(users)
+		sorted.Channels[channel] = users
+	}
+	b, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal moderators: %v", err)
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write moderators: %v", err)
+	}
+	return nil
+}
+
+// Save writes l to the file at path, replacing it only once it has been written completely.
+func Save(path string, l List) error {
+	var b bytes.Buffer
+	if err := Write(&b, l); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to save moderators: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save moderators: %v", err)
+	}
+	return nil
+}
+
+// Watcher keeps a List up to date with the file it was loaded from. It is safe for concurrent
+// use.
+type Watcher struct {
+	path string
+
+	mu   sync.RWMutex
+	list List
+}
+
+// Watch loads the List at path, then reloads it every interval until ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration) (*Watcher, error) {
+	w := &Watcher{path: path}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Reload(); err != nil {
+					log.Printf("Failed to reload channel moderators, keeping the previous ones: %v", err)
+				}
+			}
+		}
+	}()
+	return w, nil
+}
+
+// List returns the most recently loaded List. A nil Watcher has an empty List.
+func (w *Watcher) List() List {
+	if w == nil {
+		return List{}
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.list
+}
+
+// Reload loads the List again. If that fails, the previous List is kept.
+func (w *Watcher) Reload() error {
+	list, err := Load(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !reflect.DeepEqual(list, w.list) && w.list.Channels != nil {
+		log.Printf("Reloaded channel moderators")
+	}
+	w.list = list
+	return nil
+}
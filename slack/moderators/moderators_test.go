@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package moderators_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack/moderators"
+)
+
+func TestSaveAndWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moderators.json")
+	original := moderators.List{Channels: map[string][]string{
+		"C00000001": {"U00000002", "U00000001"},
+		"C00000002": {},
+	}}
+	if err := moderators.Save(path, original); err != nil {
+		t.Fatalf("Failed to save moderators: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := moderators.Watch(ctx, path, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to load moderators: %v", err)
+	}
+	want := map[string][]string{"C00000001": {"U00000001", "U00000002"}}
+	if got := w.List().Channels; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected sorted moderators without empty channels %v, got %v", want, got)
+	}
+	if !w.List().IsModerator("C00000001", "U00000002") || w.List().IsModerator("C00000002", "U00000002") {
+		t.Errorf("Expected U00000002 to moderate only C00000001")
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("Failed to write moderators: %v", err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Errorf("Expected reloading invalid moderators to fail")
+	}
+	if !w.List().IsModerator("C00000001", "U00000001") {
+		t.Errorf("Expected the previous moderators to be kept after a failed reload")
+	}
+
+	var nilWatcher *moderators.Watcher
+	if nilWatcher.List().IsModerator("C00000001", "U00000001") {
+		t.Errorf("Expected a nil Watcher to have no moderators")
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mrkdwn builds text in Slack's mrkdwn format. Everything interpolated into a message is
+// escaped unless it is a Text, so names and other text chosen by users can't add mentions or
+// links of their own.
+package mrkdwn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Text is mrkdwn that is safe to include in a message as it is.
+type Text string
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape returns s as mrkdwn that displays s as it is.
+func Escape(s string) Text {
+	return Text(escaper.Replace(s))
+}
+
+// escaped formats a value, then escapes it.
+type escaped struct {
+	v interface{}
+}
+
+func (e escaped) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, string(Escape(fmt.Sprintf(fmt.FormatString(f, verb), e.v))))
+}
+
+// Sprintf formats according to format, which is included as it is, escaping each argument that
+// is not a Text.
+func Sprintf(format string, args ...interface{}) Text {
+	safe := make([]interface{}, len(args))
+	for i, a := range args {
+		if t, ok := a.(Text); ok {
+			safe[i] = string(t)
+		} else {
+			safe[i] = escaped{a}
+		}
+	}
+	return Text(fmt.Sprintf(format, safe...))
+}
+
+// label returns the part of a mention or link after the "|", if there is one.
+func label(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "|" + string(Escape(s))
+}
+
+// User mentions the user with the given ID.
+func User(id string) Text {
+	return Text("<@" + string(Escape(id)) + ">")
+}
+
+// UserNamed mentions the user with the given ID, falling back to showing name if the user can't
+// be shown, e.g. in notifications.
+func UserNamed(id, name string) Text {
+	return Text("<@" + string(Escape(id)) + label(name) + ">")
+}
+
+// Channel links to the channel with the given ID.
+func Channel(id string) Text {
+	return Text("<#" + string(Escape(id)) + ">")
+}
+
+// ChannelNamed links to the channel with the given ID, falling back to showing name if the
+// channel can't be shown.
+func ChannelNamed(id, name string) Text {
+	return Text("<#" + string(Escape(id)) + label(name) + ">")
+}
+
+// Usergroup mentions the usergroup with the given ID, falling back to showing "@handle".
+func Usergroup(id, handle string) Text {
+	if handle != "" && !strings.HasPrefix(handle, "@") {
+		handle = "@" + handle
+	}
+	return Text("<!subteam^" + string(Escape(id)) + label(handle) + ">")
+}
+
+// Here mentions everyone active in the channel.
+func Here() Text {
+	return "<!here>"
+}
+
+// ChannelMention mentions everyone in the channel.
+func ChannelMention() Text {
+	return "<!channel>"
+}
+
+// Everyone mentions everyone in the workspace.
+func Everyone() Text {
+	return "<!everyone>"
+}
+
+// Link links to url, showing text if it is not empty.
+func Link(url, text string) Text {
+	// A "|" would end the URL early.
+	url = strings.ReplaceAll(url, "|", "%7C")
+	return Text("<" + string(Escape(url)) + label(text) + ">")
+}
+
+// Date shows t in the reader's time zone, according to format, which is made of tokens like
+// "{date_short}" and "{time}". Clients that can't show the date show fallback instead.
+func Date(t time.Time, format, fallback string) Text {
+	return Text("<!date^" + strconv.FormatInt(t.Unix(), 10) + "^" + string(Escape(format)) + label(fallback) + ">")
+}
+
+// backtick looks like a backtick, but doesn't end code spans.
+const backtick = "ˋ"
+
+// Code shows s as inline code. Backticks in s are replaced with a lookalike, since mrkdwn can't
+// escape them.
+func Code(s string) Text {
+	return Text("`" + string(Escape(strings.ReplaceAll(s, "`", backtick))) + "`")
+}
+
+// CodeBlock shows s as a preformatted block.
+func CodeBlock(s string) Text {
+	return Text("```\n" + string(Escape(strings.ReplaceAll(s, "```", strings.Repeat(backtick, 3)))) + "\n```")
+}
+
+// Quote shows s as a block quote.
+func Quote(s string) Text {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = "> " + string(Escape(l))
+	}
+	return Text(strings.Join(lines, "\n"))
+}
+
+// Bold shows s in bold.
+func Bold(s string) Text {
+	return Text("*" + string(Escape(s)) + "*")
+}
+
+// Italic shows s in italics.
+func Italic(s string) Text {
+	return Text("_" + string(Escape(s)) + "_")
+}
+
+// Builder builds a message from pieces of mrkdwn. The zero value is ready to use.
+type Builder struct {
+	b strings.Builder
+}
+
+// Write appends mrkdwn to the message.
+func (b *Builder) Write(texts ...Text) {
+	for _, t := range texts {
+		b.b.WriteString(string(t))
+	}
+}
+
+// WriteText appends text to the message, escaping it.
+func (b *Builder) WriteText(s string) {
+	b.Write(Escape(s))
+}
+
+// Printf appends to the message as Sprintf would format it.
+func (b *Builder) Printf(format string, args ...interface{}) {
+	b.Write(Sprintf(format, args...))
+}
+
+// Line appends mrkdwn to the message followed by a line break.
+func (b *Builder) Line(texts ...Text) {
+	b.Write(texts...)
+	b.b.WriteString("\n")
+}
+
+// Text returns the message built so far.
+func (b *Builder) Text() Text {
+	return Text(b.b.String())
+}
+
+// String returns the message built so far.
+func (b *Builder) String() string {
+	return b.b.String()
+}
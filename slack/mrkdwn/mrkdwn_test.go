@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mrkdwn_test
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
+)
+
+func TestHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		got  mrkdwn.Text
+		want string
+	}{
+		{name: "escape", got: mrkdwn.Escape("<!channel> & <@U1>"), want: "&lt;!channel&gt; &amp; &lt;@U1&gt;"},
+		{name: "user", got: mrkdwn.User("U1"), want: "<@U1>"},
+		{name: "named user", got: mrkdwn.UserNamed("U1", "<!here>"), want: "<@U1|&lt;!here&gt;>"},
+		{name: "crafted user ID", got: mrkdwn.User("U1> <!channel"), want: "<@U1&gt; &lt;!channel>"},
+		{name: "channel", got: mrkdwn.Channel("C1"), want: "<#C1>"},
+		{name: "named channel", got: mrkdwn.ChannelNamed("C1", "general"), want: "<#C1|general>"},
+		{name: "usergroup", got: mrkdwn.Usergroup("S1", "leads"), want: "<!subteam^S1|@leads>"},
+		{name: "usergroup with @", got: mrkdwn.Usergroup("S1", "@leads"), want: "<!subteam^S1|@leads>"},
+		{name: "here", got: mrkdwn.Here(), want: "<!here>"},
+		{name: "link", got: mrkdwn.Link("https://example.com/?a=1&b=2", "a <link>"), want: "<https://example.com/?a=1&amp;b=2|a &lt;link&gt;>"},
+		{name: "link with pipe", got: mrkdwn.Link("https://example.com/a|b", ""), want: "<https://example.com/a%7Cb>"},
+		{name: "date", got: mrkdwn.Date(time.Unix(1600000000, 0), "{date_short} at {time}", "Sep 13"), want: "<!date^1600000000^{date_short} at {time}|Sep 13>"},
+		{name: "code", got: mrkdwn.Code("a `b` <c>"), want: "`a ˋbˋ &lt;c&gt;`"},
+		{name: "code block", got: mrkdwn.CodeBlock("x\n```\ny"), want: "```\nx\nˋˋˋ\ny\n```"},
+		{name: "quote", got: mrkdwn.Quote("one\n> two"), want: "> one\n> &gt; two"},
+		{name: "bold", got: mrkdwn.Bold("<b>"), want: "*&lt;b&gt;*"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if string(tc.got) != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, tc.got)
+			}
+		})
+	}
+}
+
+func TestSprintfEscapesArguments(t *testing.T) {
+	got := mrkdwn.Sprintf("Channel %s was *renamed* to %q by %s (%d)", mrkdwn.Channel("C1"), `<!channel> "hi"`, mrkdwn.User("U1"), 3)
+	want := `Channel <#C1> was *renamed* to "&lt;!channel&gt; \"hi\"" by <@U1> (3)`
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	var b mrkdwn.Builder
+	b.Line(mrkdwn.Bold("Report"))
+	b.Printf("From %s: ", mrkdwn.User("U1"))
+	b.WriteText("<!everyone>")
+	want := "*Report*\nFrom <@U1>: &lt;!everyone&gt;"
+	if b.String() != want {
+		t.Errorf("Expected %q, got %q", want, b.String())
+	}
+}
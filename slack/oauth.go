@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TokenRefreshMargin is how long before an expiring token expires that it is refreshed.
+const TokenRefreshMargin = 5 * time.Minute
+
+// OAuthAccess is the result of exchanging an OAuth code for tokens with oauth.v2.access.
+type OAuthAccess struct {
+	AppID       string `json:"app_id"`
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	BotUserID   string `json:"bot_user_id"`
+	// RefreshToken and ExpiresIn are only set if the app uses token rotation.
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Team         struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"team"`
+	Enterprise *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"enterprise"`
+	IsEnterpriseInstall bool `json:"is_enterprise_install"`
+	AuthedUser          struct {
+		ID          string `json:"id"`
+		Scope       string `json:"scope"`
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	} `json:"authed_user"`
+	IncomingWebhook *struct {
+		Channel   string `json:"channel"`
+		ChannelID string `json:"channel_id"`
+		URL       string `json:"url"`
+	} `json:"incoming_webhook"`
+}
+
+// InstalledTeam returns the team the app was installed in. For an organisation-wide
+// installation, the team has only an enterprise ID.
+func (a OAuthAccess) InstalledTeam() Team {
+	team := Team{ID: a.Team.ID}
+	if a.Enterprise != nil {
+		team.EnterpriseID = a.Enterprise.ID
+	}
+	if a.IsEnterpriseInstall {
+		team.ID = ""
+	}
+	return team
+}
+
+// ExpiresAt returns when AccessToken expires, given that it was issued at now, or the zero time if
+// it doesn't expire.
+func (a OAuthAccess) ExpiresAt(now time.Time) time.Time {
+	if a.ExpiresIn == 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(a.ExpiresIn) * time.Second)
+}
+
+// ExchangeOAuthCode exchanges the code Slack gives a user who installs the app for tokens, using
+// Config.ClientID and Config.ClientSecret. redirectURI must match the one the code was issued for,
+// if there was one.
+func (c *Client) ExchangeOAuthCode(ctx context.Context, code, redirectURI string) (OAuthAccess, error) {
+	vs := url.Values{"code": {code}}
+	if redirectURI != "" {
+		vs.Set("redirect_uri", redirectURI)
+	}
+	result, err := c.oauthV2Access(ctx, vs)
+	if err != nil {
+		return OAuthAccess{}, fmt.Errorf("failed to exchange OAuth code: %w", err)
+	}
+	return result, nil
+}
+
+// RefreshOAuthToken exchanges a refresh token for a new access token, for apps that use token
+// rotation. The result usually includes a new refresh token, which should be used next time.
+func (c *Client) RefreshOAuthToken(ctx context.Context, refreshToken string) (OAuthAccess, error) {
+	result, err := c.oauthV2Access(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+	if err != nil {
+		return OAuthAccess{}, fmt.Errorf("failed to refresh OAuth token: %w", err)
+	}
+	return result, nil
+}
+
+// oauthV2Access calls oauth.v2.access, which is authenticated with the app's client ID and secret
+// rather than a token.
+func (c *Client) oauthV2Access(ctx context.Context, vs url.Values) (OAuthAccess, error) {
+	config := c.CurrentConfig()
+	if config.ClientID == "" || config.ClientSecret == "" {
+		return OAuthAccess{}, errors.New("calling oauth.v2.access requires a client ID and secret")
+	}
+	q := vs.Encode()
+	result := OAuthAccess{}
+	err := c.do(ctx, "oauth.v2.access", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.methodURL("oauth.v2.access"), strings.NewReader(q))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		req.SetBasicAuth(config.ClientID, config.ClientSecret)
+		return req, nil
+	}, &result)
+	return result, err
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// FileStore is a Store that keeps installations in a JSON file. It suits apps installed in a
+// handful of workspaces and run as a single replica.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a FileStore that uses the file at path, which is created when the first
+// installation is saved.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save adds an installation, replacing any existing one for the same team.
+func (f *FileStore) Save(ctx context.Context, installation Installation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	installations, err := f.load()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, existing := range installations {
+		if existing.Team == installation.Team {
+			installations[i] = installation
+			replaced = true
+		}
+	}
+	if !replaced {
+		installations = append(installations, installation)
+	}
+	return f.write(installations)
+}
+
+// Find returns the installation for exactly the given team, or ErrNotFound.
+func (f *FileStore) Find(ctx context.Context, team slack.Team) (Installation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	installations, err := f.load()
+	if err != nil {
+		return Installation{}, err
+	}
+	for _, installation := range installations {
+		if installation.Team == team {
+			return installation, nil
+		}
+	}
+	return Installation{}, ErrNotFound
+}
+
+// Delete removes the installation for the given team, if there is one.
+func (f *FileStore) Delete(ctx context.Context, team slack.Team) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	installations, err := f.load()
+	if err != nil {
+		return err
+	}
+	kept := installations[:0]
+	for _, installation := range installations {
+		if installation.Team != team {
+			kept = append(kept, installation)
+		}
+	}
+	if len(kept) == len(installations) {
+		return nil
+	}
+	return f.write(kept)
+}
+
+func (f *FileStore) load() ([]Installation, error) {
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installations: %v", err)
+	}
+	var installations []Installation
+	if err := json.Unmarshal(content, &installations); err != nil {
+		return nil, fmt.Errorf("failed to parse installations from %s: %v", f.path, err)
+	}
+	return installations, nil
+}
+
+// write replaces the file with the given installations. The file is readable only by its owner,
+// since it contains tokens, and is replaced atomically so that a crash can't leave it truncated.
+func (f *FileStore) write(installations []Installation) error {
+	content, err := json.MarshalIndent(installations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialise installations: %v", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write installations: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write installations: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", f.path, err)
+	}
+	return nil
+}
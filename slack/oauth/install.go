@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// DefaultAuthorizeURL is where users are sent to approve installing an app.
+const DefaultAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+
+// stateLifetime is how long a user has to approve an installation.
+const stateLifetime = 10 * time.Minute
+
+const stateCookie = "slack-oauth-state"
+
+// InstallHandler serves an app's installation page. Visiting it redirects the user to Slack to
+// approve the installation, and Slack redirects them back to it with a code, which the handler
+// exchanges for tokens and saves in Store. The same URL must be configured as a redirect URL in
+// the app's OAuth settings.
+type InstallHandler struct {
+	// Client is used to exchange codes, and must have a ClientID and ClientSecret.
+	Client *slack.Client
+	Store  Store
+	// Scopes are the bot scopes to request.
+	Scopes []string
+	// UserScopes are the user scopes to request, if any.
+	UserScopes []string
+	// RedirectURI is sent to Slack if set. It is only needed if the app has more than one
+	// redirect URL.
+	RedirectURI string
+	// AuthorizeURL defaults to DefaultAuthorizeURL.
+	AuthorizeURL string
+}
+
+func (h *InstallHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch {
+	case q.Get("error") != "":
+		log.Printf("Installation was not approved: %s", q.Get("error"))
+		writePage(w, http.StatusOK, "The app was not installed.")
+	case q.Get("code") != "":
+		h.finishInstall(w, r)
+	default:
+		h.startInstall(w, r)
+	}
+}
+
+// startInstall sends the user to Slack to approve the installation.
+func (h *InstallHandler) startInstall(w http.ResponseWriter, r *http.Request) {
+	state, err := h.newState(time.Now())
+	if err != nil {
+		log.Printf("Failed to generate OAuth state: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// The state is also kept in a cookie, so that only the browser that started the installation
+	// can finish it.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     r.URL.Path,
+		MaxAge:   int(stateLifetime.Seconds()),
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	vs := url.Values{
+		"client_id": {h.Client.CurrentConfig().ClientID},
+		"scope":     {strings.Join(h.Scopes, ",")},
+		"state":     {state},
+	}
+	if len(h.UserScopes) > 0 {
+		vs.Set("user_scope", strings.Join(h.UserScopes, ","))
+	}
+	if h.RedirectURI != "" {
+		vs.Set("redirect_uri", h.RedirectURI)
+	}
+	authorizeURL := h.AuthorizeURL
+	if authorizeURL == "" {
+		authorizeURL = DefaultAuthorizeURL
+	}
+	http.Redirect(w, r, authorizeURL+"?"+vs.Encode(), http.StatusFound)
+}
+
+// finishInstall exchanges the code Slack sent the user back with, and saves the installation.
+func (h *InstallHandler) finishInstall(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(state)) {
+		writePage(w, http.StatusForbidden, "This installation was started in a different browser, or has expired. Please try again.")
+		return
+	}
+	if err := h.checkState(state, time.Now()); err != nil {
+		log.Printf("Rejecting OAuth callback: %v", err)
+		writePage(w, http.StatusForbidden, "This installation has expired. Please try again.")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: r.URL.Path, MaxAge: -1})
+
+	access, err := h.Client.ExchangeOAuthCode(r.Context(), r.URL.Query().Get("code"), h.RedirectURI)
+	if err != nil {
+		log.Printf("Failed to complete installation: %v", err)
+		writePage(w, http.StatusBadGateway, "Slack didn't let us finish installing the app. Please try again.")
+		return
+	}
+	now := time.Now().UTC()
+	installation := Installation{
+		Team:              access.InstalledTeam(),
+		AppID:             access.AppID,
+		BotToken:          access.AccessToken,
+		BotUserID:         access.BotUserID,
+		BotScopes:         splitScopes(access.Scope),
+		InstalledBy:       access.AuthedUser.ID,
+		BotRefreshToken:   access.RefreshToken,
+		BotTokenExpiresAt: access.ExpiresAt(now),
+		UserToken:         access.AuthedUser.AccessToken,
+		UserScopes:        splitScopes(access.AuthedUser.Scope),
+		InstalledAt:       now,
+	}
+	if access.IncomingWebhook != nil {
+		installation.WebhookURL = access.IncomingWebhook.URL
+	}
+	if err := h.Store.Save(r.Context(), installation); err != nil {
+		log.Printf("Failed to save installation: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Installed app in %s for %s", installation.Team, installation.InstalledBy)
+	name := access.Team.Name
+	if access.IsEnterpriseInstall && access.Enterprise != nil {
+		name = access.Enterprise.Name
+	}
+	if name == "" {
+		name = "your workspace"
+	}
+	writePage(w, http.StatusOK, fmt.Sprintf("The app was installed in %s.", name))
+}
+
+// newState returns a state parameter that expires after stateLifetime. It is signed with the
+// client secret, so that we only accept states we created.
+func (h *InstallHandler) newState(now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := strconv.FormatInt(now.Unix(), 10) + "." + hex.EncodeToString(nonce)
+	return payload + "." + h.sign(payload), nil
+}
+
+func (h *InstallHandler) checkState(state string, now time.Time) error {
+	i := strings.LastIndex(state, ".")
+	if i < 0 {
+		return fmt.Errorf("malformed state %q", state)
+	}
+	payload, signature := state[:i], state[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(h.sign(payload))) {
+		return fmt.Errorf("state has a bad signature")
+	}
+	created, err := strconv.ParseInt(strings.SplitN(payload, ".", 2)[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed state %q", state)
+	}
+	if now.Sub(time.Unix(created, 0)) > stateLifetime {
+		return fmt.Errorf("state expired")
+	}
+	return nil
+}
+
+func (h *InstallHandler) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(h.Client.CurrentConfig().ClientSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return nil
+	}
+	return strings.Split(scopes, ",")
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html><head><title>Install</title></head><body><p>{{.}}</p></body></html>
+`))
+
+func writePage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = page.Execute(w, message)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/oauth"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func newInstallHandler(t *testing.T) (*oauth.InstallHandler, *slacktest.Server) {
+	s := slacktest.NewServer()
+	t.Cleanup(s.Close)
+	return &oauth.InstallHandler{
+		Client: s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret"}),
+		Store:  oauth.NewFileStore(filepath.Join(t.TempDir(), "installations.json")),
+		Scopes: []string{"chat:write", "users:read"},
+	}, s
+}
+
+func get(h http.Handler, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// startInstall visits the install page, and returns the state it sent to Slack and the cookie it
+// set.
+func startInstall(t *testing.T, h http.Handler) (string, *http.Cookie) {
+	w := get(h, "/install")
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected a redirect, got %d", w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Failed to parse redirect: %v", err)
+	}
+	if location.Host != "slack.com" || location.Query().Get("client_id") != "123.456" || location.Query().Get("scope") != "chat:write,users:read" {
+		t.Errorf("Unexpected redirect to %s", location)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected one cookie, got %v", cookies)
+	}
+	return location.Query().Get("state"), cookies[0]
+}
+
+func TestInstall(t *testing.T) {
+	h, s := newInstallHandler(t)
+	team := slack.Team{EnterpriseID: "E00000001", ID: "T00000002"}
+	token := s.AddOAuthCode("code", slacktest.OAuthGrant{ClientID: "123.456", ClientSecret: "secret", Team: team, Scope: "chat:write,users:read", UserID: "U00000001"})
+
+	state, cookie := startInstall(t, h)
+	w := get(h, "/install?code=code&state="+url.QueryEscape(state), cookie)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected installation to succeed, got %d: %s", w.Code, w.Body)
+	}
+	installation, err := h.Store.Find(context.Background(), team)
+	if err != nil {
+		t.Fatalf("Expected installation to be saved: %v", err)
+	}
+	if installation.BotToken != token || installation.InstalledBy != "U00000001" || len(installation.BotScopes) != 2 {
+		t.Errorf("Unexpected installation %+v", installation)
+	}
+}
+
+func TestInstallRejectsBadState(t *testing.T) {
+	h, s := newInstallHandler(t)
+	s.AddOAuthCode("code", slacktest.OAuthGrant{ClientID: "123.456", ClientSecret: "secret", Team: slack.Team{ID: "T00000001"}})
+	state, cookie := startInstall(t, h)
+	other, _ := startInstall(t, h)
+
+	tests := []struct {
+		name    string
+		state   string
+		cookies []*http.Cookie
+	}{
+		{name: "no cookie", state: state},
+		{name: "state from another browser", state: other, cookies: []*http.Cookie{cookie}},
+		{name: "forged state", state: "1.00.bad", cookies: []*http.Cookie{{Name: cookie.Name, Value: "1.00.bad"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := get(h, "/install?code=code&state="+url.QueryEscape(tc.state), tc.cookies...)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected 403, got %d", w.Code)
+			}
+		})
+	}
+	if calls := s.CallsTo("oauth.v2.access"); len(calls) != 0 {
+		t.Errorf("Expected no code to be exchanged, got %v", calls)
+	}
+}
+
+func TestInstallWithBadCode(t *testing.T) {
+	h, _ := newInstallHandler(t)
+	state, cookie := startInstall(t, h)
+	w := get(h, "/install?code=wrong&state="+url.QueryEscape(state), cookie)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", w.Code)
+	}
+}
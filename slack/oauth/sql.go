@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// SQLStore is a Store that keeps installations in a SQL database, so that several replicas of an
+// app can share them. Its queries work with SQLite and PostgreSQL.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore returns a SQLStore using db, creating its slack_installations table if needed.
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SQLStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS slack_installations (
+		enterprise_id TEXT NOT NULL,
+		team_id TEXT NOT NULL,
+		installation TEXT NOT NULL,
+		PRIMARY KEY (enterprise_id, team_id)
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installations table: %v", err)
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// Save adds an installation, replacing any existing one for the same team.
+func (s *SQLStore) Save(ctx context.Context, installation Installation) error {
+	content, err := json.Marshal(installation)
+	if err != nil {
+		return fmt.Errorf("failed to serialise installation: %v", err)
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO slack_installations (enterprise_id, team_id, installation)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (enterprise_id, team_id) DO UPDATE SET installation = excluded.installation`,
+		installation.Team.EnterpriseID, installation.Team.ID, string(content))
+	if err != nil {
+		return fmt.Errorf("failed to save installation for %s: %v", installation.Team, err)
+	}
+	return nil
+}
+
+// Find returns the installation for exactly the given team, or ErrNotFound.
+func (s *SQLStore) Find(ctx context.Context, team slack.Team) (Installation, error) {
+	var content string
+	err := s.db.QueryRowContext(ctx, `SELECT installation FROM slack_installations WHERE enterprise_id = $1 AND team_id = $2`,
+		team.EnterpriseID, team.ID).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Installation{}, ErrNotFound
+	}
+	if err != nil {
+		return Installation{}, fmt.Errorf("failed to find installation for %s: %v", team, err)
+	}
+	installation := Installation{}
+	if err := json.Unmarshal([]byte(content), &installation); err != nil {
+		return Installation{}, fmt.Errorf("failed to parse installation for %s: %v", team, err)
+	}
+	return installation, nil
+}
+
+// Delete removes the installation for the given team, if there is one.
+func (s *SQLStore) Delete(ctx context.Context, team slack.Team) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM slack_installations WHERE enterprise_id = $1 AND team_id = $2`,
+		team.EnterpriseID, team.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete installation for %s: %v", team, err)
+	}
+	return nil
+}
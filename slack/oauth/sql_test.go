@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDriver is a database/sql driver that understands just the statements SQLStore makes,
+// keeping the slack_installations table in memory for each data source name.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string]map[[2]string]string
+}
+
+func init() {
+	sql.Register("fakesql", &fakeDriver{tables: map[string]map[[2]string]string{}})
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tables[name] == nil {
+		d.tables[name] = map[[2]string]string{}
+	}
+	return &fakeConn{driver: d, table: d.tables[name]}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	table  map[[2]string]string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.TrimSpace(query)}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS slack_installations"):
+	case strings.HasPrefix(s.query, "INSERT INTO slack_installations"):
+		s.conn.table[[2]string{args[0].(string), args[1].(string)}] = args[2].(string)
+	case strings.HasPrefix(s.query, "DELETE FROM slack_installations"):
+		delete(s.conn.table, [2]string{args[0].(string), args[1].(string)})
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+	if !strings.HasPrefix(s.query, "SELECT installation FROM slack_installations") {
+		return nil, fmt.Errorf("unexpected query %q", s.query)
+	}
+	rows := &fakeRows{}
+	if v, ok := s.conn.table[[2]string{args[0].(string), args[1].(string)}]; ok {
+		rows.values = []string{v}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"installation"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oauth installs Slack apps into workspaces with OAuth v2, and stores the tokens each
+// installation is given so that one app can serve many workspaces.
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// ErrNotFound is returned by a Store when there is no installation for a team.
+var ErrNotFound = errors.New("installation not found")
+
+// Installation is an app's installation into a workspace, or across an Enterprise Grid
+// organisation, and the tokens it was granted.
+type Installation struct {
+	// Team is where the app was installed. Organisation-wide installations have no team ID.
+	Team        slack.Team `json:"team"`
+	AppID       string     `json:"appId"`
+	BotToken    string     `json:"botToken"`
+	BotUserID   string     `json:"botUserId"`
+	BotScopes   []string   `json:"botScopes,omitempty"`
+	InstalledBy string     `json:"installedBy"`
+	// BotRefreshToken and BotTokenExpiresAt are only set if the app uses token rotation.
+	BotRefreshToken   string    `json:"botRefreshToken,omitempty"`
+	BotTokenExpiresAt time.Time `json:"botTokenExpiresAt"`
+	// UserToken and UserScopes are only set if the app asked for user scopes.
+	UserToken   string    `json:"userToken,omitempty"`
+	UserScopes  []string  `json:"userScopes,omitempty"`
+	WebhookURL  string    `json:"webhookUrl,omitempty"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// Store saves installations, keyed by their team.
+type Store interface {
+	// Save adds an installation, replacing any existing one for the same team.
+	Save(ctx context.Context, installation Installation) error
+	// Find returns the installation for exactly the given team, or ErrNotFound.
+	Find(ctx context.Context, team slack.Team) (Installation, error)
+	// Delete removes the installation for the given team, if there is one.
+	Delete(ctx context.Context, team slack.Team) error
+}
+
+// Lookup returns the installation that should be used for a team: the one for the team itself,
+// or else the organisation-wide installation for its enterprise.
+func Lookup(ctx context.Context, store Store, team slack.Team) (Installation, error) {
+	installation, err := store.Find(ctx, team)
+	if !errors.Is(err, ErrNotFound) || team.EnterpriseID == "" || team.ID == "" {
+		return installation, err
+	}
+	return store.Find(ctx, slack.Team{EnterpriseID: team.EnterpriseID})
+}
+
+// Tokens returns a slack.TokenSource giving the bot token of each team's installation. Tokens that
+// expire are refreshed with client shortly before they do, and the installation is saved with the
+// new tokens. The TokenSource is safe for concurrent use, but assumes it is the only thing
+// refreshing the tokens in store.
+func Tokens(store Store, client *slack.Client) slack.TokenSource {
+	return &tokenSource{store: store, client: client, locks: map[slack.Team]*sync.Mutex{}}
+}
+
+type tokenSource struct {
+	store  Store
+	client *slack.Client
+
+	mu sync.Mutex
+	// locks ensure only one token is refreshed at a time for each team.
+	locks map[slack.Team]*sync.Mutex
+}
+
+func (t *tokenSource) lock(team slack.Team) func() {
+	t.mu.Lock()
+	l, ok := t.locks[team]
+	if !ok {
+		l = &sync.Mutex{}
+		t.locks[team] = l
+	}
+	t.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func (t *tokenSource) Token(ctx context.Context, team slack.Team) (string, error) {
+	installation, err := Lookup(ctx, t.store, team)
+	if err != nil {
+		return "", err
+	}
+	if installation.BotToken == "" {
+		return "", fmt.Errorf("installation for %s has no bot token", team)
+	}
+	if !needsRefresh(installation, time.Now()) {
+		return installation.BotToken, nil
+	}
+
+	defer t.lock(installation.Team)()
+	// Someone else may have refreshed the token while we waited.
+	installation, err = t.store.Find(ctx, installation.Team)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if !needsRefresh(installation, now) {
+		return installation.BotToken, nil
+	}
+	access, err := t.client.RefreshOAuthToken(ctx, installation.BotRefreshToken)
+	if err != nil {
+		if now.Before(installation.BotTokenExpiresAt) {
+			log.Printf("Failed to refresh token for %s, using it until it expires at %s: %v", installation.Team, installation.BotTokenExpiresAt, err)
+			return installation.BotToken, nil
+		}
+		return "", err
+	}
+	installation.BotToken = access.AccessToken
+	if access.RefreshToken != "" {
+		installation.BotRefreshToken = access.RefreshToken
+	}
+	installation.BotTokenExpiresAt = access.ExpiresAt(now)
+	if err := t.store.Save(ctx, installation); err != nil {
+		return "", fmt.Errorf("failed to save refreshed token: %v", err)
+	}
+	return installation.BotToken, nil
+}
+
+// needsRefresh returns whether the installation's bot token expires within
+// slack.TokenRefreshMargin of now.
+func needsRefresh(installation Installation, now time.Time) bool {
+	return installation.BotRefreshToken != "" && installation.BotTokenExpiresAt.Sub(now) <= slack.TokenRefreshMargin
+}
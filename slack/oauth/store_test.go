@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauth_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/oauth"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func newSQLStore(t *testing.T) oauth.Store {
+	db, err := sql.Open("fakesql", t.Name())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	store, err := oauth.NewSQLStore(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return store
+}
+
+func newFileStore(t *testing.T) oauth.Store {
+	return oauth.NewFileStore(filepath.Join(t.TempDir(), "installations.json"))
+}
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name     string
+		newStore func(t *testing.T) oauth.Store
+	}{
+		{name: "file", newStore: newFileStore},
+		{name: "sql", newStore: newSQLStore},
+	}
+	workspace := slack.Team{ID: "T00000001"}
+	gridWorkspace := slack.Team{EnterpriseID: "E00000001", ID: "T00000002"}
+	org := slack.Team{EnterpriseID: "E00000001"}
+
+	for _, tc := range stores {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := tc.newStore(t)
+
+			if _, err := store.Find(ctx, workspace); !errors.Is(err, oauth.ErrNotFound) {
+				t.Fatalf("Expected ErrNotFound from an empty store, got %v", err)
+			}
+			installedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			for _, i := range []oauth.Installation{
+				{Team: workspace, BotToken: "xoxb-old", InstalledAt: installedAt},
+				{Team: workspace, BotToken: "xoxb-1", BotScopes: []string{"chat:write"}, InstalledAt: installedAt},
+				{Team: org, BotToken: "xoxb-org"},
+			} {
+				if err := store.Save(ctx, i); err != nil {
+					t.Fatalf("Failed to save installation: %v", err)
+				}
+			}
+
+			got, err := store.Find(ctx, workspace)
+			if err != nil {
+				t.Fatalf("Failed to find installation: %v", err)
+			}
+			if got.BotToken != "xoxb-1" || len(got.BotScopes) != 1 || !got.InstalledAt.Equal(installedAt) {
+				t.Errorf("Expected the latest installation to be found, got %+v", got)
+			}
+			if _, err := store.Find(ctx, gridWorkspace); !errors.Is(err, oauth.ErrNotFound) {
+				t.Errorf("Expected Find to match teams exactly, got %v", err)
+			}
+
+			tokens := oauth.Tokens(store, nil)
+			for team, want := range map[slack.Team]string{workspace: "xoxb-1", gridWorkspace: "xoxb-org", org: "xoxb-org"} {
+				if token, err := tokens.Token(ctx, team); err != nil || token != want {
+					t.Errorf("Expected token %q for %s, got %q (%v)", want, team, token, err)
+				}
+			}
+
+			if err := store.Delete(ctx, org); err != nil {
+				t.Fatalf("Failed to delete installation: %v", err)
+			}
+			if _, err := tokens.Token(ctx, gridWorkspace); !errors.Is(err, oauth.ErrNotFound) {
+				t.Errorf("Expected no token once the organisation's installation was deleted, got %v", err)
+			}
+			if err := store.Delete(ctx, org); err != nil {
+				t.Errorf("Expected deleting a missing installation to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTokensRefreshExpiringInstallations(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	team := slack.Team{ID: "T00000001"}
+	s.AddRefreshToken("xoxe-1-initial", slacktest.OAuthGrant{ClientID: "123.456", ClientSecret: "secret", Team: team})
+	client := s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret"})
+	store := newFileStore(t)
+	ctx := context.Background()
+	err := store.Save(ctx, oauth.Installation{
+		Team:              team,
+		BotToken:          "xoxe.xoxb-expiring",
+		BotRefreshToken:   "xoxe-1-initial",
+		BotTokenExpiresAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Failed to save installation: %v", err)
+	}
+
+	tokens := oauth.Tokens(store, client)
+	var wg sync.WaitGroup
+	got := make([]string, 10)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := tokens.Token(ctx, team)
+			if err != nil {
+				t.Errorf("Failed to get token: %v", err)
+			}
+			got[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	issued := s.BotTokens()
+	if len(issued) != 1 {
+		t.Fatalf("Expected one refresh, got %v", issued)
+	}
+	for _, token := range got {
+		if token != issued[0] {
+			t.Errorf("Expected the refreshed token %q, got %q", issued[0], token)
+		}
+	}
+	installation, err := store.Find(ctx, team)
+	if err != nil {
+		t.Fatalf("Failed to find installation: %v", err)
+	}
+	if installation.BotToken != issued[0] || installation.BotRefreshToken == "xoxe-1-initial" || time.Until(installation.BotTokenExpiresAt) < time.Hour {
+		t.Errorf("Expected the refreshed tokens to be saved, got %+v", installation)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultPageSize is how many items are asked for in each page, if the Pager doesn't say.
+	defaultPageSize = 200
+	// pagerRateLimitRetries is how many more times a Pager will wait and retry a page when the
+	// client has given up on it because of rate limiting. Because a Pager knows where it got to,
+	// it can wait out a long rate limit without starting over.
+	pagerRateLimitRetries = 3
+)
+
+// Pager fetches every item from a paginated Slack method, one page at a time. The zero value is not
+// usable; the fields below must be set, or a Pager obtained from one of the Client's List methods.
+type Pager[T any] struct {
+	Client *Client
+	Method string
+	// Args are the arguments sent with every page.
+	Args map[string]string
+	// Field is the path to the list of items in each response, e.g. "members", or
+	// "messages.matches" for search.messages.
+	Field string
+	// Pages selects the page-number pagination used by search methods, instead of cursors.
+	Pages bool
+	// PageSize is how many items to ask for in each page. If zero, 200 are asked for with cursor
+	// pagination, and Slack's default number with page-number pagination.
+	PageSize int
+}
+
+// All returns an iterator over every item. If fetching a page fails, the error is yielded with
+// the zero value of T, and iteration stops.
+func (p Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		next := ""
+		for {
+			items, nextPage, err := p.fetch(ctx, next)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if nextPage == "" {
+				return
+			}
+			next = nextPage
+		}
+	}
+}
+
+// Collect returns every item.
+func (p Pager[T]) Collect(ctx context.Context) ([]T, error) {
+	var result []T
+	for item, err := range p.All(ctx) {
+		if err != nil {
+			return result, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// Stream sends every item on the returned channel from a new goroutine, closing it when done or
+// when ctx is. The error that stopped it, if any, can then be received from the error channel,
+// which is closed after the item channel.
+func (p Pager[T]) Stream(ctx context.Context) (<-chan T, <-chan error) {
+	items := make(chan T)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(items)
+		for item, err := range p.All(ctx) {
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return items, errs
+}
+
+// fetch fetches the page identified by next, which is a cursor or a page number, or empty for the
+// first page. It returns the items and the identifier of the following page, if there is one.
+func (p Pager[T]) fetch(ctx context.Context, next string) ([]T, string, error) {
+	args := map[string]string{}
+	for k, v := range p.Args {
+		args[k] = v
+	}
+	if p.Pages {
+		if p.PageSize > 0 {
+			args["count"] = strconv.Itoa(p.PageSize)
+		}
+		if next != "" {
+			args["page"] = next
+		}
+	} else {
+		pageSize := p.PageSize
+		if pageSize == 0 {
+			pageSize = defaultPageSize
+		}
+		args["limit"] = strconv.Itoa(pageSize)
+		if next != "" {
+			args["cursor"] = next
+		}
+	}
+
+	var raw map[string]json.RawMessage
+	for attempt := 0; ; attempt++ {
+		err := p.Client.CallOldMethodContext(ctx, p.Method, args, &raw)
+		var rateLimit ErrRateLimit
+		if errors.As(err, &rateLimit) && attempt < pagerRateLimitRetries {
+			log.Printf("Still rate limited listing %s, waiting %s before carrying on...", p.Method, rateLimit.Wait)
+			if err := sleep(ctx, rateLimit.Wait); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list %s: %w", p.Method, err)
+		}
+		break
+	}
+
+	// Walk down to the object containing the list.
+	path := strings.Split(p.Field, ".")
+	container := raw
+	for _, key := range path[:len(path)-1] {
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(container[key], &inner); err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s from %s: %v", key, p.Method, err)
+		}
+		container = inner
+	}
+	var items []T
+	if list, ok := container[path[len(path)-1]]; ok {
+		if err := json.Unmarshal(list, &items); err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s from %s: %v", p.Field, p.Method, err)
+		}
+	}
+
+	if p.Pages {
+		pagination := struct {
+			Page      int `json:"page"`
+			PageCount int `json:"page_count"`
+		}{}
+		if b, ok := container["pagination"]; ok {
+			if err := json.Unmarshal(b, &pagination); err != nil {
+				return nil, "", fmt.Errorf("failed to parse pagination from %s: %v", p.Method, err)
+			}
+		}
+		if pagination.Page < pagination.PageCount {
+			return items, strconv.Itoa(pagination.Page + 1), nil
+		}
+		return items, "", nil
+	}
+	metadata := struct {
+		NextCursor string `json:"next_cursor"`
+	}{}
+	if b, ok := raw["response_metadata"]; ok {
+		if err := json.Unmarshal(b, &metadata); err != nil {
+			return nil, "", fmt.Errorf("failed to parse response_metadata from %s: %v", p.Method, err)
+		}
+	}
+	return items, metadata.NextCursor, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func addUsers(s *slacktest.Server, n int) {
+	for i := 0; i < n; i++ {
+		s.AddUser(slack.User{Name: fmt.Sprintf("user%d", i)})
+	}
+}
+
+func TestPagerFollowsCursors(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	addUsers(s, 5)
+	c := s.Client(slack.Config{})
+	pager := c.ListUsers()
+	pager.PageSize = 2
+
+	users, err := pager.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 5 {
+		t.Errorf("Expected 5 users, got %d", len(users))
+	}
+	if calls := len(s.CallsTo("users.list")); calls != 3 {
+		t.Errorf("Expected 3 pages to be fetched, got %d", calls)
+	}
+}
+
+func TestPagerStopsWhenIterationDoes(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	addUsers(s, 5)
+	c := s.Client(slack.Config{})
+	pager := c.ListUsers()
+	pager.PageSize = 2
+
+	seen := 0
+	for _, err := range pager.All(context.Background()) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		seen++
+		if seen == 3 {
+			break
+		}
+	}
+	if calls := len(s.CallsTo("users.list")); calls != 2 {
+		t.Errorf("Expected only 2 pages to be fetched, got %d", calls)
+	}
+}
+
+func TestPagerFollowsPages(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "general"})
+	for i := 0; i < 5; i++ {
+		s.AddMessage(channel, slacktest.Message{User: "U1", Text: "hello"})
+	}
+	c := s.Client(slack.Config{})
+	pager := slack.Pager[struct {
+		TS string `json:"ts"`
+	}]{Client: c, Method: "search.messages", Args: map[string]string{"query": "hello"}, Field: "messages.matches", Pages: true, PageSize: 2}
+
+	matches, err := pager.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(matches) != 5 {
+		t.Errorf("Expected 5 matches, got %d", len(matches))
+	}
+	calls := s.CallsTo("search.messages")
+	if len(calls) != 3 || calls[2].Arg("page") != "3" {
+		t.Errorf("Expected pages 1 to 3 to be fetched, got %v", calls)
+	}
+}
+
+func TestPagerWaitsOutRateLimits(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	addUsers(s, 3)
+	c := s.Client(slack.Config{})
+	c.RetryPolicy = &slack.RetryPolicy{}
+	pager := c.ListUsers()
+	pager.PageSize = 2
+
+	items, errs := pager.Stream(context.Background())
+	<-items
+	// The client itself won't retry, but the pager should pick up where it left off.
+	s.InjectRateLimit("users.list", 0)
+	s.InjectRateLimit("users.list", 0)
+	count := 1
+	for range items {
+		count++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 users, got %d", count)
+	}
+	calls := s.CallsTo("users.list")
+	if len(calls) != 4 || calls[3].Arg("cursor") == "" {
+		t.Errorf("Expected the second page to be retried, got %v", calls)
+	}
+}
+
+func TestPagerReturnsErrors(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+	s.InjectError("conversations.members", "channel_not_found")
+
+	_, err := c.ListConversationMembers("C1").Collect(context.Background())
+	var e slack.ErrSlack
+	if !errors.As(err, &e) || e.Type != "channel_not_found" {
+		t.Errorf("Expected channel_not_found, got %v", err)
+	}
+}
+
+func TestListConversationHistoryAndReplies(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "general"}, "U1", "U2")
+	first := s.AddMessage(channel, slacktest.Message{User: "U1", Text: "first"})
+	s.AddMessage(channel, slacktest.Message{User: "U2", Text: "reply", ThreadTS: first})
+	s.AddMessage(channel, slacktest.Message{User: "U2", Text: "second"})
+	c := s.Client(slack.Config{})
+	ctx := context.Background()
+
+	history, err := c.ListConversationHistory(slack.HistoryRequest{Channel: channel}).Collect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list history: %v", err)
+	}
+	if len(history) != 2 || history[0].Text != "second" || history[1].Text != "first" {
+		t.Errorf("Expected the two top-level messages newest first, got %+v", history)
+	}
+	replies, err := c.ListConversationReplies(channel, first).Collect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list replies: %v", err)
+	}
+	if len(replies) != 2 || replies[1].Text != "reply" || replies[1].ThreadTS != first {
+		t.Errorf("Expected the thread, got %+v", replies)
+	}
+	members, err := c.ListConversationMembers(channel).Collect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list members: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("Expected two members, got %v", members)
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// TokenType says what a Token in a message is.
+type TokenType int
+
+const (
+	// TextToken is plain text.
+	TextToken TokenType = iota
+	// UserToken mentions the user with the token's ID.
+	UserToken
+	// ChannelToken links to the channel with the token's ID.
+	ChannelToken
+	// UsergroupToken mentions the usergroup with the token's ID.
+	UsergroupToken
+	// BroadcastToken mentions everyone in a channel or workspace. Its ID is "here", "channel" or
+	// "everyone".
+	BroadcastToken
+	// LinkToken links to the token's URL.
+	LinkToken
+	// CodeToken is inline code.
+	CodeToken
+	// CodeBlockToken is a preformatted block.
+	CodeBlockToken
+	// EmojiToken is an emoji, named by the token's Text.
+	EmojiToken
+)
+
+// Token is a piece of a message.
+type Token struct {
+	Type TokenType
+	// Text is the text the token shows: the text itself, the label of a mention or link if it has
+	// one, the contents of code, or the name of an emoji without colons.
+	Text string
+	// ID is the ID of the user, channel or usergroup mentioned, or the kind of broadcast.
+	ID string
+	// URL is the target of a link.
+	URL string
+	// Quoted is true for tokens in a block quote.
+	Quoted bool
+}
+
+// ParseMessage splits a message into tokens, using its rich_text blocks if it has any, since they
+// describe it more precisely, and its text otherwise.
+func ParseMessage(text string, blocks json.RawMessage) []Token {
+	if tokens, ok := ParseMessageBlocks(blocks); ok {
+		return tokens
+	}
+	return ParseMessageText(text)
+}
+
+// tokenizer accumulates tokens, joining adjacent text.
+type tokenizer struct {
+	tokens []Token
+}
+
+func (t *tokenizer) add(token Token) {
+	if token.Type == TextToken {
+		if token.Text == "" {
+			return
+		}
+		if n := len(t.tokens); n > 0 && t.tokens[n-1].Type == TextToken && t.tokens[n-1].Quoted == token.Quoted {
+			t.tokens[n-1].Text += token.Text
+			return
+		}
+	}
+	t.tokens = append(t.tokens, token)
+}
+
+var unescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// ParseMessageText splits the text of a message, in the format Slack sends it, into tokens.
+func ParseMessageText(text string) []Token {
+	t := &tokenizer{}
+	quoteAll := false
+	// Code blocks are between pairs of ```, and may span several lines.
+	segments := strings.Split(text, "```")
+	if len(segments)%2 == 0 {
+		// The last ``` is unmatched, so it is just text.
+		last := len(segments) - 1
+		segments[last-1] += "```" + segments[last]
+		segments = segments[:last]
+	}
+	for i, segment := range segments {
+		if i%2 == 1 {
+			t.add(Token{Type: CodeBlockToken, Text: unescaper.Replace(strings.Trim(segment, "\n")), Quoted: quoteAll})
+			continue
+		}
+		lineStart := i == 0
+		for j, line := range strings.Split(segment, "\n") {
+			if j > 0 {
+				t.add(Token{Type: TextToken, Text: "\n", Quoted: quoteAll})
+				lineStart = true
+			}
+			quoted := quoteAll
+			if lineStart && !quoteAll {
+				if rest, ok := trimQuoteMarker(line, 3); ok {
+					quoteAll, quoted, line = true, true, rest
+				} else if rest, ok := trimQuoteMarker(line, 1); ok {
+					quoted, line = true, rest
+				}
+			}
+			parseLine(t, line, quoted)
+		}
+	}
+	return t.tokens
+}
+
+// trimQuoteMarker removes n quote markers from the start of line, along with a following space.
+func trimQuoteMarker(line string, n int) (string, bool) {
+	for _, marker := range []string{"&gt;", ">"} {
+		prefix := strings.Repeat(marker, n)
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(strings.TrimPrefix(line, prefix), " "), true
+		}
+	}
+	return line, false
+}
+
+var emojiPattern = regexp.MustCompile(`^:([a-z0-9_+'-]+(?:::skin-tone-[2-6])?):`)
+
+// parseLine adds the tokens in a line of text that isn't in a code block.
+func parseLine(t *tokenizer, line string, quoted bool) {
+	text := func(s string) {
+		t.add(Token{Type: TextToken, Text: unescaper.Replace(s), Quoted: quoted})
+	}
+	start := 0
+	for i := 0; i < len(line); {
+		var token Token
+		end := -1
+		switch line[i] {
+		case '<':
+			if n := strings.IndexByte(line[i+1:], '>'); n >= 0 {
+				token, end = parseAngle(line[i+1:i+1+n]), i+n+2
+			}
+		case '`':
+			if n := strings.IndexByte(line[i+1:], '`'); n > 0 {
+				token, end = Token{Type: CodeToken, Text: unescaper.Replace(line[i+1 : i+1+n])}, i+n+2
+			}
+		case ':':
+			if m := emojiPattern.FindStringSubmatch(line[i:]); m != nil && strings.Trim(m[1], "0123456789") != "" {
+				token, end = Token{Type: EmojiToken, Text: m[1]}, i+len(m[0])
+			}
+		}
+		if end < 0 {
+			i++
+			continue
+		}
+		text(line[start:i])
+		token.Quoted = quoted
+		t.add(token)
+		i, start = end, end
+	}
+	text(line[start:])
+}
+
+// parseAngle parses the contents of <...>: a mention, a link, or some other special text.
+func parseAngle(s string) Token {
+	target, label, _ := strings.Cut(s, "|")
+	label = unescaper.Replace(label)
+	switch {
+	case strings.HasPrefix(target, "@"):
+		return Token{Type: UserToken, ID: target[1:], Text: label}
+	case strings.HasPrefix(target, "#"):
+		return Token{Type: ChannelToken, ID: target[1:], Text: label}
+	case strings.HasPrefix(target, "!subteam^"):
+		return Token{Type: UsergroupToken, ID: strings.TrimPrefix(target, "!subteam^"), Text: label}
+	case target == "!here" || target == "!channel" || target == "!everyone":
+		return Token{Type: BroadcastToken, ID: target[1:], Text: label}
+	case strings.HasPrefix(target, "!"):
+		// Dates and other special text show their label.
+		return Token{Type: TextToken, Text: label}
+	default:
+		return Token{Type: LinkToken, URL: unescaper.Replace(target), Text: label}
+	}
+}
+
+// richTextElement is any element of a rich_text block.
+type richTextElement struct {
+	Type     string            `json:"type"`
+	Elements []richTextElement `json:"elements"`
+	Text     string            `json:"text"`
+	Style    struct {
+		Code bool `json:"code"`
+	} `json:"style"`
+	UserID      string `json:"user_id"`
+	ChannelID   string `json:"channel_id"`
+	UsergroupID string `json:"usergroup_id"`
+	Range       string `json:"range"`
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	Fallback    string `json:"fallback"`
+}
+
+// ParseMessageBlocks splits the rich_text blocks of a message into tokens. It reports false if
+// there are no rich_text blocks, or they can't be parsed.
+func ParseMessageBlocks(blocks json.RawMessage) ([]Token, bool) {
+	if len(blocks) == 0 {
+		return nil, false
+	}
+	// Other kinds of block look different, so only decode the elements of rich_text blocks.
+	var parsed []struct {
+		Type     string          `json:"type"`
+		Elements json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(blocks, &parsed); err != nil {
+		return nil, false
+	}
+	t := &tokenizer{}
+	found := false
+	for _, b := range parsed {
+		if b.Type != "rich_text" {
+			continue
+		}
+		var elements []richTextElement
+		if err := json.Unmarshal(b.Elements, &elements); err != nil {
+			return nil, false
+		}
+		if found {
+			t.add(Token{Type: TextToken, Text: "\n"})
+		}
+		found = true
+		addRichText(t, elements, false)
+	}
+	return t.tokens, found
+}
+
+// addRichText adds the tokens for a list of rich text elements.
+func addRichText(t *tokenizer, elements []richTextElement, quoted bool) {
+	for i, e := range elements {
+		if i > 0 && strings.HasPrefix(e.Type, "rich_text_") {
+			// Sections, lists, quotes and preformatted blocks each start on a new line.
+			t.add(Token{Type: TextToken, Text: "\n", Quoted: quoted})
+		}
+		switch e.Type {
+		case "rich_text_section", "rich_text_list", "rich_text_quote":
+			addRichText(t, e.Elements, quoted || e.Type == "rich_text_quote")
+		case "rich_text_preformatted":
+			var code strings.Builder
+			for _, c := range e.Elements {
+				if c.Type == "link" && c.Text == "" {
+					code.WriteString(c.URL)
+				} else {
+					code.WriteString(c.Text)
+				}
+			}
+			t.add(Token{Type: CodeBlockToken, Text: code.String(), Quoted: quoted})
+		case "text":
+			if e.Style.Code {
+				t.add(Token{Type: CodeToken, Text: e.Text, Quoted: quoted})
+			} else {
+				t.add(Token{Type: TextToken, Text: e.Text, Quoted: quoted})
+			}
+		case "user":
+			t.add(Token{Type: UserToken, ID: e.UserID, Quoted: quoted})
+		case "channel":
+			t.add(Token{Type: ChannelToken, ID: e.ChannelID, Quoted: quoted})
+		case "usergroup":
+			t.add(Token{Type: UsergroupToken, ID: e.UsergroupID, Quoted: quoted})
+		case "broadcast":
+			t.add(Token{Type: BroadcastToken, ID: e.Range, Quoted: quoted})
+		case "link":
+			t.add(Token{Type: LinkToken, URL: e.URL, Text: e.Text, Quoted: quoted})
+		case "emoji":
+			t.add(Token{Type: EmojiToken, Text: e.Name, Quoted: quoted})
+		case "date":
+			t.add(Token{Type: TextToken, Text: e.Fallback, Quoted: quoted})
+		}
+	}
+}
+
+// PlainText returns the text a message's tokens show outside quotes, code, mentions and links:
+// the words its author wrote themselves. Each token left out is replaced by a space, so the words
+// either side of it aren't joined together.
+func PlainText(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if t.Type == TextToken && !t.Quoted {
+			b.WriteString(t.Text)
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
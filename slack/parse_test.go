@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestParseMessageText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []slack.Token
+	}{
+		{
+			name: "plain text is unescaped",
+			text: "a &lt;b&gt; &amp; c",
+			want: []slack.Token{{Type: slack.TextToken, Text: "a <b> & c"}},
+		},
+		{
+			name: "mentions",
+			text: "hi <@U1>, <@U2|bob> in <#C1|general> and <!subteam^S1|@leads> <!here>",
+			want: []slack.Token{
+				{Type: slack.TextToken, Text: "hi "},
+				{Type: slack.UserToken, ID: "U1"},
+				{Type: slack.TextToken, Text: ", "},
+				{Type: slack.UserToken, ID: "U2", Text: "bob"},
+				{Type: slack.TextToken, Text: " in "},
+				{Type: slack.ChannelToken, ID: "C1", Text: "general"},
+				{Type: slack.TextToken, Text: " and "},
+				{Type: slack.UsergroupToken, ID: "S1", Text: "@leads"},
+				{Type: slack.TextToken, Text: " "},
+				{Type: slack.BroadcastToken, ID: "here"},
+			},
+		},
+		{
+			name: "links",
+			text: "<https://example.com/?a=1&amp;b=2|the &lt;site&gt;> <mailto:a@example.com>",
+			want: []slack.Token{
+				{Type: slack.LinkToken, URL: "https://example.com/?a=1&b=2", Text: "the <site>"},
+				{Type: slack.TextToken, Text: " "},
+				{Type: slack.LinkToken, URL: "mailto:a@example.com"},
+			},
+		},
+		{
+			name: "dates show their fallback",
+			text: "due <!date^1600000000^{date}|Sep 13>",
+			want: []slack.Token{{Type: slack.TextToken, Text: "due Sep 13"}},
+		},
+		{
+			name: "code",
+			text: "run `rm -rf &lt;dir&gt;` then\n```\nline one\n<@U1>\n```",
+			want: []slack.Token{
+				{Type: slack.TextToken, Text: "run "},
+				{Type: slack.CodeToken, Text: "rm -rf <dir>"},
+				{Type: slack.TextToken, Text: " then\n"},
+				{Type: slack.CodeBlockToken, Text: "line one\n<@U1>"},
+			},
+		},
+		{
+			name: "unmatched backticks are text",
+			text: "a ` b\nc ```d",
+			want: []slack.Token{{Type: slack.TextToken, Text: "a ` b\nc ```d"}},
+		},
+		{
+			name: "emoji",
+			text: "nice :+1: :thumbsup::skin-tone-3: at 10:30:45",
+			want: []slack.Token{
+				{Type: slack.TextToken, Text: "nice "},
+				{Type: slack.EmojiToken, Text: "+1"},
+				{Type: slack.TextToken, Text: " "},
+				{Type: slack.EmojiToken, Text: "thumbsup::skin-tone-3"},
+				{Type: slack.TextToken, Text: " at 10:30:45"},
+			},
+		},
+		{
+			name: "quotes",
+			text: "&gt; quoted <@U1>\nnot quoted",
+			want: []slack.Token{
+				{Type: slack.TextToken, Text: "quoted ", Quoted: true},
+				{Type: slack.UserToken, ID: "U1", Quoted: true},
+				{Type: slack.TextToken, Text: "\nnot quoted"},
+			},
+		},
+		{
+			name: "multi-line quotes",
+			text: "said:\n&gt;&gt;&gt; one\ntwo",
+			want: []slack.Token{
+				{Type: slack.TextToken, Text: "said:\n"},
+				{Type: slack.TextToken, Text: "one\ntwo", Quoted: true},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := slack.ParseMessageText(tc.text); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMessagePrefersBlocks(t *testing.T) {
+	blocks := []byte(`[
+		{"type": "section", "text": {"type": "mrkdwn", "text": "ignored"}},
+		{"type": "rich_text", "elements": [
+			{"type": "rich_text_section", "elements": [
+				{"type": "text", "text": "hi "},
+				{"type": "user", "user_id": "U1"},
+				{"type": "text", "text": " see "},
+				{"type": "link", "url": "https://example.com", "text": "this"},
+				{"type": "text", "text": "cmd", "style": {"code": true}},
+				{"type": "emoji", "name": "wave"},
+				{"type": "broadcast", "range": "channel"}
+			]},
+			{"type": "rich_text_quote", "elements": [{"type": "text", "text": "quoted"}]},
+			{"type": "rich_text_preformatted", "elements": [{"type": "text", "text": "code\nblock"}]},
+			{"type": "rich_text_list", "elements": [
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "one"}]},
+				{"type": "rich_text_section", "elements": [{"type": "channel", "channel_id": "C1"}, {"type": "usergroup", "usergroup_id": "S1"}]}
+			]}
+		]}
+	]`)
+	want := []slack.Token{
+		{Type: slack.TextToken, Text: "hi "},
+		{Type: slack.UserToken, ID: "U1"},
+		{Type: slack.TextToken, Text: " see "},
+		{Type: slack.LinkToken, URL: "https://example.com", Text: "this"},
+		{Type: slack.CodeToken, Text: "cmd"},
+		{Type: slack.EmojiToken, Text: "wave"},
+		{Type: slack.BroadcastToken, ID: "channel"},
+		{Type: slack.TextToken, Text: "\n"},
+		{Type: slack.TextToken, Text: "quoted", Quoted: true},
+		{Type: slack.TextToken, Text: "\n"},
+		{Type: slack.CodeBlockToken, Text: "code\nblock"},
+		{Type: slack.TextToken, Text: "\none\n"},
+		{Type: slack.ChannelToken, ID: "C1"},
+		{Type: slack.UsergroupToken, ID: "S1"},
+	}
+	if got := slack.ParseMessage("the text is ignored", blocks); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := slack.ParseMessage("no blocks", []byte(`[{"type": "section"}]`)); len(got) != 1 || got[0].Text != "no blocks" {
+		t.Errorf("Expected the text to be used without rich_text blocks, got %+v", got)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tokens := slack.ParseMessageText("hi<@U1>there `code` <https://example.com/word>\n&gt; quoted word")
+	if got, want := slack.PlainText(tokens), "hi there    \n "; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pin is a pinned item in a conversation.
+type Pin struct {
+	Type      string `json:"type"`
+	Channel   string `json:"channel"`
+	CreatedBy string `json:"created_by"`
+	Created   int64  `json:"created"`
+	// Message is set if Type is "message".
+	Message *Message `json:"message,omitempty"`
+	// File is set if Type is "file".
+	File *File `json:"file,omitempty"`
+}
+
+// ListPins returns the items pinned in the given conversation.
+func (c *Client) ListPins(ctx context.Context, channel string) ([]Pin, error) {
+	ret := struct {
+		Items []Pin `json:"items"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "pins.list", map[string]string{"channel": channel}, &ret); err != nil {
+		return nil, fmt.Errorf("failed to list pins in %s: %w", channel, err)
+	}
+	return ret.Items, nil
+}
+
+// PinMessage pins the message with the given timestamp to its channel.
+func (c *Client) PinMessage(ctx context.Context, channel, ts string) error {
+	if err := c.CallMethodContext(ctx, "pins.add", map[string]string{"channel": channel, "timestamp": ts}, nil); err != nil {
+		return fmt.Errorf("failed to pin message %s in %s: %w", ts, channel, err)
+	}
+	return nil
+}
+
+// UnpinMessage unpins the message with the given timestamp from its channel.
+func (c *Client) UnpinMessage(ctx context.Context, channel, ts string) error {
+	if err := c.CallMethodContext(ctx, "pins.remove", map[string]string{"channel": channel, "timestamp": ts}, nil); err != nil {
+		return fmt.Errorf("failed to unpin message %s in %s: %w", ts, channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tier is a Slack rate limit tier, as described at https://api.slack.com/docs/rate-limits.
+type Tier int
+
+const (
+	// Tier1 methods permit at least 1 request per minute.
+	Tier1 Tier = iota + 1
+	// Tier2 methods permit at least 20 requests per minute.
+	Tier2
+	// Tier3 methods permit at least 50 requests per minute.
+	Tier3
+	// Tier4 methods permit at least 100 requests per minute.
+	Tier4
+	// TierSpecial methods have their own limits; we treat them as permitting one request per
+	// second, which is what chat.postMessage allows per channel.
+	TierSpecial
+)
+
+// TierLimit is the client-side limit applied to each method in a tier.
+type TierLimit struct {
+	// PerMinute is the sustained number of requests permitted per minute.
+	PerMinute float64
+	// Burst is the number of requests that can be made at once before throttling begins.
+	Burst float64
+}
+
+// DefaultTierLimits are the limits used by New.
+var DefaultTierLimits = map[Tier]TierLimit{
+	Tier1:       {PerMinute: 1, Burst: 1},
+	Tier2:       {PerMinute: 20, Burst: 3},
+	Tier3:       {PerMinute: 50, Burst: 5},
+	Tier4:       {PerMinute: 100, Burst: 10},
+	TierSpecial: {PerMinute: 60, Burst: 5},
+}
+
+// methodTiers lists the tier of each method we use. Anything not listed is assumed to be Tier3.
+var methodTiers = map[string]Tier{
+	"apps.connections.open": Tier1,
+
+	"admin.conversations.convertToPrivate": Tier2,
+	"admin.conversations.convertToPublic":  Tier2,
+	"admin.roles.addAssignments":           Tier2,
+	"admin.roles.listAssignments":          Tier2,
+	"admin.roles.removeAssignments":        Tier2,
+	"bookmarks.add":                        Tier2,
+	"bookmarks.edit":                       Tier2,
+	"bookmarks.list":                       Tier2,
+	"bookmarks.remove":                     Tier2,
+	"conversations.archive":                Tier2,
+	"conversations.create":                 Tier2,
+	"conversations.list":                   Tier2,
+	"conversations.rename":                 Tier2,
+	"conversations.setPurpose":             Tier2,
+	"conversations.setTopic":               Tier2,
+	"conversations.unarchive":              Tier2,
+	"pins.add":                             Tier2,
+	"pins.list":                            Tier2,
+	"pins.remove":                          Tier2,
+	"search.files":                         Tier2,
+	"search.messages":                      Tier2,
+	"usergroups.create":                    Tier2,
+	"usergroups.disable":                   Tier2,
+	"usergroups.enable":                    Tier2,
+	"usergroups.list":                      Tier2,
+	"usergroups.update":                    Tier2,
+	"usergroups.users.list":                Tier2,
+	"usergroups.users.update":              Tier2,
+	"users.admin.setInactive":              Tier2,
+	"users.list":                           Tier2,
+
+	"chat.delete":           Tier3,
+	"chat.update":           Tier3,
+	"conversations.history": Tier3,
+	"conversations.join":    Tier3,
+	"conversations.members": Tier3,
+	"conversations.open":    Tier3,
+	"conversations.replies": Tier3,
+	"files.delete":          Tier3,
+	"users.conversations":   Tier3,
+	"users.lookupByEmail":   Tier3,
+
+	"chat.getPermalink":            Tier4,
+	"chat.postEphemeral":           Tier4,
+	"dialog.open":                  Tier4,
+	"files.completeUploadExternal": Tier4,
+	"files.getUploadURLExternal":   Tier4,
+	"files.info":                   Tier4,
+	"users.info":                   Tier4,
+	"views.open":                   Tier4,
+	"views.push":                   Tier4,
+	"views.update":                 Tier4,
+
+	"chat.postMessage": TierSpecial,
+}
+
+// MethodTier returns the rate limit tier of the given Slack API method.
+func MethodTier(method string) Tier {
+	if t, ok := methodTiers[method]; ok {
+		return t
+	}
+	return Tier3
+}
+
+// RetryPolicy controls how a Client responds to being rate limited by Slack.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times a single call will be retried.
+	MaxRetries int
+	// MaxWait is the maximum total time a single call will spend waiting to retry. If Slack asks
+	// us to wait longer than this, we give up immediately.
+	MaxWait time.Duration
+	// Jitter is the maximum fraction of Slack's requested wait that is randomly added to it, so
+	// that concurrent callers don't all retry at the same moment.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by clients with no RetryPolicy set.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 10,
+	MaxWait:    5 * time.Minute,
+	Jitter:     0.2,
+}
+
+func (p RetryPolicy) backoff(wait time.Duration) time.Duration {
+	if p.Jitter <= 0 || wait <= 0 {
+		return wait
+	}
+	return wait + time.Duration(rand.Float64()*p.Jitter*float64(wait))
+}
+
+// RateLimiter is a set of client-side token buckets, one per Slack API method, sized according
+// to the method's rate limit tier. It is safe for concurrent use.
+type RateLimiter struct {
+	limits map[Tier]TierLimit
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens    float64
+	last      time.Time
+	notBefore time.Time
+}
+
+// NewRateLimiter returns a RateLimiter with the given limits. Tiers missing from limits are not
+// limited.
+func NewRateLimiter(limits map[Tier]TierLimit) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Wait blocks until a call to the given method is permitted, or the context is done.
+func (r *RateLimiter) Wait(ctx context.Context, method string) error {
+	return sleep(ctx, r.reserve(method))
+}
+
+// reserve takes a token for the given method, returning how long the caller must wait before
+// using it.
+func (r *RateLimiter) reserve(method string) time.Duration {
+	limit, ok := r.limits[MethodTier(method)]
+	if !ok || limit.PerMinute <= 0 {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	b := r.bucket(method, limit, now)
+	rate := limit.PerMinute / float64(time.Minute)
+	b.tokens += float64(now.Sub(b.last)) * rate
+	if b.tokens > limit.Burst {
+		b.tokens = limit.Burst
+	}
+	b.last = now
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / rate)
+	}
+	if d := b.notBefore.Sub(now); d > wait {
+		wait = d
+	}
+	return wait
+}
+
+// Penalize prevents any calls to the given method for the next d, typically because Slack has
+// told us to back off.
+func (r *RateLimiter) Penalize(method string, d time.Duration) {
+	limit, ok := r.limits[MethodTier(method)]
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	b := r.bucket(method, limit, now)
+	if t := now.Add(d); t.After(b.notBefore) {
+		b.notBefore = t
+	}
+}
+
+// bucket returns the bucket for method, creating a full one if necessary. r.mu must be held.
+func (r *RateLimiter) bucket(method string, limit TierLimit, now time.Time) *bucket {
+	b, ok := r.buckets[method]
+	if !ok {
+		b = &bucket{tokens: limit.Burst, last: now}
+		r.buckets[method] = b
+	}
+	return b
+}
+
+// sleep waits for the given duration, returning early with an error if the context is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// isMethodName returns true if api names a Slack API method rather than a complete URL, such as
+// a webhook or response_url.
+func isMethodName(api string) bool {
+	return !strings.HasPrefix(api, "https://") && !strings.HasPrefix(api, "http://")
+}
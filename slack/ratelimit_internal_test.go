@@ -0,0 +1,56 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	r := NewRateLimiter(map[Tier]TierLimit{Tier2: {PerMinute: 20, Burst: 2}})
+	r.now = func() time.Time { return now }
+
+	// The burst is available immediately, then calls are spaced out at three seconds each.
+	expected := []time.Duration{0, 0, 3 * time.Second, 6 * time.Second}
+	for i, e := range expected {
+		if d := r.reserve("conversations.list"); d != e {
+			t.Errorf("Call %d: expected to wait %s, but got %s", i, e, d)
+		}
+	}
+
+	// Other methods have their own buckets, and untiered limits don't apply.
+	if d := r.reserve("usergroups.list"); d != 0 {
+		t.Errorf("Expected a different method not to wait, but got %s", d)
+	}
+	if d := r.reserve("users.info"); d != 0 {
+		t.Errorf("Expected a method with no configured limit not to wait, but got %s", d)
+	}
+
+	// Time passing refills the bucket.
+	now = now.Add(time.Minute)
+	if d := r.reserve("conversations.list"); d != 0 {
+		t.Errorf("Expected a refilled bucket not to wait, but got %s", d)
+	}
+
+	// Penalties apply even if there are tokens available.
+	r.Penalize("conversations.list", 10*time.Second)
+	if d := r.reserve("conversations.list"); d != 10*time.Second {
+		t.Errorf("Expected to wait out the 10s penalty, but got %s", d)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestClientRetriesRateLimitedCalls(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        slack.RetryPolicy
+		rateLimits    []int
+		expectErr     bool
+		expectedCalls int
+	}{
+		{
+			name:          "succeeds without being rate limited",
+			policy:        slack.RetryPolicy{MaxRetries: 3, MaxWait: time.Minute},
+			expectedCalls: 1,
+		},
+		{
+			name:          "retries until it succeeds",
+			policy:        slack.RetryPolicy{MaxRetries: 3, MaxWait: time.Minute},
+			rateLimits:    []int{0, 0},
+			expectedCalls: 3,
+		},
+		{
+			name:          "gives up after too many retries",
+			policy:        slack.RetryPolicy{MaxRetries: 2, MaxWait: time.Minute},
+			rateLimits:    []int{0, 0, 0},
+			expectErr:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "gives up immediately if asked to wait too long",
+			policy:        slack.RetryPolicy{MaxRetries: 3, MaxWait: time.Second},
+			rateLimits:    []int{30},
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := slacktest.NewServer()
+			defer s.Close()
+			for _, r := range tc.rateLimits {
+				s.InjectRateLimit("api.test", r)
+			}
+			c := s.Client(slack.Config{})
+			c.RetryPolicy = &tc.policy
+
+			err := c.CallMethod("api.test", nil, nil)
+			if tc.expectErr {
+				if _, ok := err.(slack.ErrRateLimit); !ok {
+					t.Errorf("Expected ErrRateLimit, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if calls := len(s.CallsTo("api.test")); calls != tc.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestClientStopsWaitingWhenContextIsDone(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	s.InjectRateLimit("api.test", 30)
+	c := s.Client(slack.Config{})
+	c.RetryPolicy = &slack.RetryPolicy{MaxRetries: 3, MaxWait: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.CallMethodContext(ctx, "api.test", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected to give up promptly, but took %s", elapsed)
+	}
+	if calls := len(s.CallsTo("api.test")); calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+
+	// A context that is already done prevents the call from being made at all.
+	c.Limiter = slack.NewRateLimiter(slack.DefaultTierLimits)
+	if err := c.CallMethodContext(ctx, "api.test", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded from a done context, got %v", err)
+	}
+	if calls := len(s.CallsTo("api.test")); calls != 1 {
+		t.Errorf("Expected no further calls, got %d", calls-1)
+	}
+}
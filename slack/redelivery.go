@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import "context"
+
+// Redelivery describes a request that Slack is sending again because an earlier attempt to
+// deliver it failed or took too long.
+type Redelivery struct {
+	// Attempt counts the retries, starting at 1 for the first.
+	Attempt int
+	// Reason is Slack's explanation of why the previous attempt failed, e.g. "http_timeout".
+	Reason string
+}
+
+type redeliveryKey struct{}
+
+// WithRedelivery returns a context recording that the request being handled with it is a retry.
+func WithRedelivery(ctx context.Context, r Redelivery) context.Context {
+	return context.WithValue(ctx, redeliveryKey{}, r)
+}
+
+// RedeliveryFromContext returns the retry recorded in ctx by WithRedelivery, if any.
+func RedeliveryFromContext(ctx context.Context) (Redelivery, bool) {
+	r, ok := ctx.Value(redeliveryKey{}).(Redelivery)
+	return r, ok
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report lets users report messages to the moderators. A message shortcut opens a modal
+// asking why, and submitting it sends the report to the webhook in the client's config.
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
+)
+
+// CallbackID is the callback ID of the report modal, whose submissions HandleSubmission handles.
+const CallbackID = "send_report"
+
+// Reporter runs the report flow.
+type Reporter struct {
+	Client *slack.Client
+	// Users, if set, is used to look up the author of the reported message, instead of users.info.
+	Users *slack.Directory
+	// Filed, if set, is called with the category of each report sent to the moderators.
+	Filed func(category string)
+}
+
+// reportCategories are the reasons a user can give for reporting a message.
+var reportCategories = []blockkit.Option{
+	blockkit.NewOption("Spam", "spam"),
+	blockkit.NewOption("Harassment or abuse", "harassment"),
+	blockkit.NewOption("Code of Conduct violation", "coc"),
+	blockkit.NewOption("Something else", "other"),
+}
+
+// OpenModal opens the modal for reporting the message the interaction was for. It handles a
+// message shortcut.
+func (r *Reporter) OpenModal(ctx context.Context, interaction *events.Interaction) error {
+	metadata, err := encodeMetadata(reportMetadata{
+		Channel:     interaction.Channel.ID,
+		ChannelName: interaction.Channel.Name,
+		Sender:      interaction.Message.User,
+		TS:          interaction.Message.TS,
+		Content:     interaction.Message.Text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialise metadata for modal: %v", err)
+	}
+
+	reason := blockkit.NewInput("message", "Why are you reporting this message?", &blockkit.PlainTextInput{ActionID: "message", Multiline: true})
+	reason.Hint = blockkit.PlainText("Moderators will see whatever you write here, along with the message being reported.")
+	view := blockkit.NewModal(CallbackID, "Report Message",
+		&blockkit.Context{Elements: []interface{}{blockkit.Markdown(string(mrkdwn.Sprintf("Reporting a message from %s.", mrkdwn.User(interaction.Message.User))))}},
+		blockkit.NewInput("category", "What kind of problem is it?", &blockkit.RadioButtons{ActionID: "category", Options: reportCategories}),
+		reason,
+	)
+	if interaction.Channel.Name != "directmessage" {
+		no := blockkit.NewOption("No, report with my username", "no")
+		view.Blocks = append(view.Blocks, blockkit.NewInput("anonymous", "Would you like to report anonymously?", &blockkit.RadioButtons{
+			ActionID:      "anonymous",
+			Options:       []blockkit.Option{no, blockkit.NewOption("Yes, report anonymously", "yes")},
+			InitialOption: &no,
+		}))
+	}
+	view.Submit = blockkit.PlainText("Report")
+	view.PrivateMetadata = metadata
+	if _, err := r.Client.OpenView(ctx, interaction.TriggerID, view); err != nil {
+		return fmt.Errorf("failed to call views.open: %v", err)
+	}
+	return nil
+}
+
+// HandleSubmission sends a report submitted through the modal to the moderators, at the webhook
+// in the client's config, and thanks the reporter.
+func (r *Reporter) HandleSubmission(ctx context.Context, interaction *events.Interaction) (blockkit.ViewSubmissionResponse, error) {
+	state := interaction.View.State
+	anonymous := state.StringValue("anonymous", "anonymous") == "yes"
+	message := strings.TrimSpace(state.StringValue("message", "message"))
+	if message == "" {
+		return blockkit.ErrorsResponse(map[string]string{"message": "Please tell the moderators why you're reporting this message."}), nil
+	}
+	category := categoryLabel(state.StringValue("category", "category"))
+	metadata := reportMetadata{}
+	if err := json.Unmarshal([]byte(interaction.View.PrivateMetadata), &metadata); err != nil {
+		return blockkit.ViewSubmissionResponse{}, fmt.Errorf("failed to parse provided metadata: %v", err)
+	}
+
+	// Construct summary string
+	var who mrkdwn.Text
+	if anonymous {
+		who = "An anonymous user"
+	} else {
+		who = mrkdwn.UserNamed(interaction.User.ID, interaction.User.Name)
+	}
+
+	var where mrkdwn.Text
+	if metadata.ChannelName == "directmessage" {
+		where = "a direct message"
+	} else {
+		where = mrkdwn.ChannelNamed(metadata.Channel, metadata.ChannelName)
+	}
+
+	summary := mrkdwn.Sprintf("%s *reported a message* in %s as *%s*:", who, where, category)
+
+	// Figure out a timestamp from the combined timestamp/message ID
+	ts, err := strconv.ParseFloat(metadata.TS, 64)
+	if err != nil {
+		return blockkit.ViewSubmissionResponse{}, fmt.Errorf("failed to parse provided timestamp: %v", err)
+	}
+
+	messageLink := mrkdwn.Text("message they reported")
+	if metadata.ChannelName != "directmessage" {
+		permalink, err := r.Client.GetPermalink(ctx, metadata.Channel, metadata.TS)
+		if err != nil {
+			log.Printf("Failed to get a permalink: %v.", err)
+		} else {
+			messageLink = mrkdwn.Link(permalink, "message they reported")
+		}
+	}
+
+	// The content in the metadata may have been cut short to fit, so only use it if we can't get
+	// the message.
+	content := metadata.Content
+	if m, err := r.Client.GetMessage(ctx, metadata.Channel, metadata.TS); err != nil {
+		log.Printf("Failed to get the reported message, using the content it was reported with: %v", err)
+	} else {
+		content = m.Text
+	}
+
+	var author mrkdwn.Text
+	if senderName, err := r.userName(ctx, metadata.Sender); err == nil {
+		author = mrkdwn.UserNamed(metadata.Sender, senderName)
+	} else {
+		author = mrkdwn.User(metadata.Sender)
+		log.Printf("Failed to look up sender: %v", err)
+	}
+
+	report := map[string]interface{}{
+		"text": string(summary),
+		"attachments": []map[string]interface{}{
+			{
+				"pretext":   "They said:",
+				"text":      string(mrkdwn.Escape(message)),
+				"mrkdwn_in": []string{"text"},
+				"fallback":  "They said: " + message,
+			},
+			{
+				"pretext":     string(mrkdwn.Sprintf("The %s was:", messageLink)),
+				"author_name": string(author),
+				"text":        content,
+				"ts":          ts,
+				"mrkdwn_in":   []string{"text", "pretext", "author_name"},
+				"fallback":    fmt.Sprintf("The message they reported was: %s", content),
+			},
+		},
+	}
+	if err := r.Client.CallMethodContext(ctx, r.Client.CurrentConfig().WebhookURL, report, nil); err != nil {
+		return blockkit.ViewSubmissionResponse{}, fmt.Errorf("failed to send report: %v", err)
+	}
+	if r.Filed != nil {
+		r.Filed(category)
+	}
+
+	thanks := blockkit.NewModal("report_sent", "Report Message", blockkit.NewSection("Thank you! Your report has been submitted."))
+	thanks.Close = blockkit.PlainText("Done")
+	return blockkit.UpdateResponse(thanks), nil
+}
+
+// userName returns the name of the user with the given ID.
+func (r *Reporter) userName(ctx context.Context, id string) (string, error) {
+	var user slack.User
+	var err error
+	if r.Users != nil {
+		user, err = r.Users.User(ctx, id)
+	} else {
+		user, err = r.Client.GetUser(ctx, id)
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.Name, nil
+}
+
+func categoryLabel(value string) string {
+	for _, o := range reportCategories {
+		if o.Value == value {
+			return o.Text.Text
+		}
+	}
+	return "Something else"
+}
+
+// reportMetadata is stored in the private_metadata of the report modal. The JSON strings here
+// are short because private_metadata is limited to maxMetadataLength characters, and we want to
+// leave as much of that as possible for the content of the reported message. The content is only
+// used if the message can't be fetched when the report is submitted.
+type reportMetadata struct {
+	Channel     string `json:"c"`
+	ChannelName string `json:"n"`
+	Sender      string `json:"s"`
+	TS          string `json:"t"`
+	Content     string `json:"m"`
+}
+
+// maxMetadataLength is the most characters Slack permits in a view's private_metadata.
+const maxMetadataLength = 3000
+
+// truncatedMarker ends message content that was cut short to fit in private_metadata.
+const truncatedMarker = "…(truncated)"
+
+// encodeMetadata serialises m, shortening the message content as little as possible to fit in
+// private_metadata, and marking it with truncatedMarker if it was shortened.
+func encodeMetadata(m reportMetadata) (string, error) {
+	encode := func(content string) (string, bool, error) {
+		m.Content = content
+		b, err := json.Marshal(m)
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), utf8.RuneCount(b) <= maxMetadataLength, nil
+	}
+	content := []rune(m.Content)
+	encoded, fits, err := encode(string(content))
+	if err != nil || fits {
+		return encoded, err
+	}
+	// Find the longest prefix of the content that fits. Escaping means we can't just work out
+	// how much to remove.
+	lo, hi := 0, len(content)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if _, fits, err := encode(string(content[:mid]) + truncatedMarker); err != nil {
+			return "", err
+		} else if fits {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	encoded, fits, err = encode(string(content[:lo]) + truncatedMarker)
+	if err != nil {
+		return "", err
+	}
+	if !fits {
+		return "", fmt.Errorf("metadata is too long even without any content")
+	}
+	return encoded, nil
+}
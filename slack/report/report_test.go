@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestEncodeMetadataFitsLongMessages(t *testing.T) {
+	long := strings.Repeat(`"<é>"`, 1000)
+	encoded, err := encodeMetadata(reportMetadata{Channel: "C00000001", Sender: "U00000001", TS: "1.000001", Content: long})
+	if err != nil {
+		t.Fatalf("Failed to encode metadata: %v", err)
+	}
+	if n := len([]rune(encoded)); n > maxMetadataLength {
+		t.Errorf("Expected at most %d characters, got %d", maxMetadataLength, n)
+	}
+	decoded := reportMetadata{}
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		t.Fatalf("Failed to decode metadata: %v", err)
+	}
+	prefix := strings.TrimSuffix(decoded.Content, truncatedMarker)
+	if prefix == decoded.Content {
+		t.Errorf("Expected the content to be marked as truncated, got %q", decoded.Content)
+	}
+	if !strings.HasPrefix(long, prefix) || len(prefix) < 1000 {
+		t.Errorf("Expected a long prefix of the content, got %d bytes", len(prefix))
+	}
+}
+
+func TestReportsHaveTheWholeMessage(t *testing.T) {
+	long := strings.Repeat("spam ", 1000)
+	tests := []struct {
+		name     string
+		inSlack  bool
+		expected string
+	}{
+		{
+			name:     "the message is fetched",
+			inSlack:  true,
+			expected: long,
+		},
+		{
+			name:     "the reported content is used if the message can't be fetched",
+			expected: truncatedMarker,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := slacktest.NewServer()
+			defer s.Close()
+			reporter := s.AddUser(slack.User{Name: "reporter"})
+			spammer := s.AddUser(slack.User{Name: "spammer"})
+			channel := s.AddConversation(slack.Conversation{Name: "general"})
+			ts := "1.000001"
+			if tc.inSlack {
+				ts = s.AddMessage(channel, slacktest.Message{User: spammer, Text: long})
+			}
+			var filed []string
+			r := &Reporter{Client: s.Client(slack.Config{}), Filed: func(category string) { filed = append(filed, category) }}
+			router := events.NewRouter()
+			router.MessageShortcut("report_message", r.OpenModal)
+			router.ViewSubmission(CallbackID, r.HandleSubmission)
+			interact := func(interaction map[string]interface{}) {
+				payload, err := json.Marshal(interaction)
+				if err != nil {
+					t.Fatalf("Failed to marshal interaction: %v", err)
+				}
+				if _, err := router.HandleInteraction(context.Background(), payload); err != nil {
+					t.Fatalf("Failed to handle interaction: %v", err)
+				}
+			}
+
+			interact(map[string]interface{}{
+				"type":        "message_action",
+				"callback_id": "report_message",
+				"trigger_id":  "trigger",
+				"user":        map[string]string{"id": reporter},
+				"channel":     map[string]string{"id": channel, "name": "general"},
+				"message":     map[string]string{"user": spammer, "ts": ts, "text": long},
+			})
+			views := s.CallsTo("views.open")
+			if len(views) != 1 {
+				t.Fatalf("Expected the report modal to be opened, got %d calls", len(views))
+			}
+			view := struct {
+				PrivateMetadata string `json:"private_metadata"`
+			}{}
+			if err := json.Unmarshal([]byte(views[0].Arg("view")), &view); err != nil {
+				t.Fatalf("Failed to parse view: %v", err)
+			}
+			interact(map[string]interface{}{
+				"type": "view_submission",
+				"user": map[string]string{"id": reporter, "name": "reporter"},
+				"view": map[string]interface{}{
+					"callback_id":      CallbackID,
+					"private_metadata": view.PrivateMetadata,
+					"state": map[string]interface{}{"values": map[string]interface{}{
+						"category": map[string]interface{}{"category": map[string]interface{}{"type": "radio_buttons", "selected_option": map[string]interface{}{"value": "spam"}}},
+						"message":  map[string]interface{}{"message": map[string]interface{}{"type": "plain_text_input", "value": "so much spam"}},
+					}},
+				},
+			})
+
+			reports := s.WebhookMessages()
+			if len(reports) != 1 {
+				t.Fatalf("Expected one report, got %d", len(reports))
+			}
+			if attachments := (slacktest.Call{Params: reports[0]}).Arg("attachments"); !strings.Contains(attachments, tc.expected) {
+				t.Errorf("Expected the report to include %q, got %s", tc.expected, attachments)
+			}
+			if len(filed) != 1 || filed[0] != "Spam" {
+				t.Errorf("Expected one spam report to be counted, got %v", filed)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RoleChannelManager is the ID of the channel manager role, for use with the admin.roles methods.
+const RoleChannelManager = "Rl0A"
+
+// RoleAssignment records that a user holds a role for some entity, such as a channel.
+type RoleAssignment struct {
+	RoleID     string `json:"role_id"`
+	EntityID   string `json:"entity_id"`
+	UserID     string `json:"user_id"`
+	DateCreate int64  `json:"date_create"`
+}
+
+// ListRoleAssignments returns a Pager over the assignments of the given role, optionally limited
+// to the given entities. Like the other admin.roles methods, this requires an Enterprise Grid org
+// and a user token with the admin.roles:read or admin.roles:write scope.
+func (c *Client) ListRoleAssignments(roleID string, entityIDs []string) Pager[RoleAssignment] {
+	args := map[string]string{"role_ids": roleID}
+	if len(entityIDs) > 0 {
+		args["entity_ids"] = strings.Join(entityIDs, ",")
+	}
+	return Pager[RoleAssignment]{Client: c, Method: "admin.roles.listAssignments", Args: args, Field: "role_assignments"}
+}
+
+// AddRoleAssignments gives users the given role for each of the given entities.
+func (c *Client) AddRoleAssignments(ctx context.Context, roleID string, entityIDs, users []string) error {
+	args := map[string]interface{}{"role_id": roleID, "entity_ids": entityIDs, "user_ids": users}
+	if err := c.CallMethodContext(ctx, "admin.roles.addAssignments", args, nil); err != nil {
+		return fmt.Errorf("failed to give %s role %s for %s: %w", strings.Join(users, ", "), roleID, strings.Join(entityIDs, ", "), err)
+	}
+	return nil
+}
+
+// RemoveRoleAssignments takes the given role for each of the given entities away from users.
+func (c *Client) RemoveRoleAssignments(ctx context.Context, roleID string, entityIDs, users []string) error {
+	args := map[string]interface{}{"role_id": roleID, "entity_ids": entityIDs, "user_ids": users}
+	if err := c.CallMethodContext(ctx, "admin.roles.removeAssignments", args, nil); err != nil {
+		return fmt.Errorf("failed to take role %s for %s from %s: %w", roleID, strings.Join(entityIDs, ", "), strings.Join(users, ", "), err)
+	}
+	return nil
+}
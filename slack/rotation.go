@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// rotatingToken is the current access token of a client whose config has a RefreshToken. Callers
+// wait for each other while it is refreshed, so that only one refresh happens at a time.
+type rotatingToken struct {
+	mu sync.Mutex
+	// configured is the refresh token from the config that the rest of the state derives from.
+	// If the config is changed to have a different one, the state is discarded.
+	configured   string
+	refreshToken string
+	accessToken  string
+	expiry       time.Time
+}
+
+// token returns an access token that won't expire for at least TokenRefreshMargin, refreshing it
+// with c if necessary. The access token in the config is not used, since we don't know when it
+// expires.
+func (r *rotatingToken) token(ctx context.Context, c *Client, config Config) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.configured != config.RefreshToken {
+		r.configured = config.RefreshToken
+		r.refreshToken = config.RefreshToken
+		r.accessToken = ""
+		r.expiry = time.Time{}
+	}
+	now := time.Now()
+	if r.accessToken != "" && (r.expiry.IsZero() || r.expiry.Sub(now) > TokenRefreshMargin) {
+		return r.accessToken, nil
+	}
+	access, err := c.RefreshOAuthToken(ctx, r.refreshToken)
+	if err != nil {
+		if r.accessToken != "" && now.Before(r.expiry) {
+			log.Printf("Failed to refresh access token, using it until it expires at %s: %v", r.expiry, err)
+			return r.accessToken, nil
+		}
+		return "", fmt.Errorf("couldn't refresh access token: %w", err)
+	}
+	r.accessToken = access.AccessToken
+	if access.RefreshToken != "" {
+		r.refreshToken = access.RefreshToken
+	}
+	r.expiry = access.ExpiresAt(now)
+	return r.accessToken, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+var rotatingGrant = slacktest.OAuthGrant{ClientID: "123.456", ClientSecret: "secret", Team: slack.Team{ID: slacktest.DefaultTeamID}}
+
+func TestRotatingTokenIsRefreshedOnce(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	s.AddRefreshToken("xoxe-1-initial", rotatingGrant)
+	c := s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret", RefreshToken: "xoxe-1-initial"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.CallMethodContext(context.Background(), "auth.test", nil, nil); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tokens := s.BotTokens()
+	if len(tokens) != 1 {
+		t.Fatalf("Expected the token to be refreshed once, got %v", tokens)
+	}
+	for _, call := range s.CallsTo("auth.test") {
+		if call.Token != tokens[0] {
+			t.Errorf("Expected calls to use the refreshed token %q, got %q", tokens[0], call.Token)
+		}
+	}
+}
+
+func TestRotatingTokenIsRefreshedBeforeExpiry(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	// Tokens that last less than the refresh margin are refreshed every time they're used.
+	s.TokenLifetime = slack.TokenRefreshMargin - time.Minute
+	s.AddRefreshToken("xoxe-1-initial", rotatingGrant)
+	c := s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret", RefreshToken: "xoxe-1-initial"})
+
+	for i := 0; i < 3; i++ {
+		if err := c.CallMethodContext(context.Background(), "auth.test", nil, nil); err != nil {
+			t.Fatalf("Call %d failed: %v", i, err)
+		}
+	}
+	// The fake only accepts each refresh token once, so this also checks the new refresh token
+	// is used each time.
+	tokens := s.BotTokens()
+	if len(tokens) != 3 {
+		t.Fatalf("Expected three refreshes, got %v", tokens)
+	}
+	calls := s.CallsTo("auth.test")
+	if got := calls[len(calls)-1].Token; got != tokens[2] {
+		t.Errorf("Expected the latest token %q, got %q", tokens[2], got)
+	}
+}
+
+func TestRotatingTokenFailsWithBadRefreshToken(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret", RefreshToken: "xoxe-1-unknown"})
+	if err := c.CallMethodContext(context.Background(), "auth.test", nil, nil); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if calls := s.CallsTo("auth.test"); len(calls) != 0 {
+		t.Errorf("Expected no call without a token, got %v", calls)
+	}
+}
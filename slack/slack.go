@@ -19,78 +19,218 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
 	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
+
+	"sigs.k8s.io/slack-infra/slack/mrkdwn"
 )
 
+// DefaultAPIURL is the base URL for the Slack Web API.
+const DefaultAPIURL = "https://slack.com/api/"
+
 // Client has methods for interacting with Slack.
 type Client struct {
 	Config Config
+
+	// APIURL is the base URL that Slack API method names are appended to. If empty,
+	// DefaultAPIURL is used.
+	APIURL string
+	// HTTPClient is used to send requests to Slack. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// RetryPolicy controls retries when Slack rate limits us. If nil, DefaultRetryPolicy is used.
+	RetryPolicy *RetryPolicy
+	// Limiter throttles calls to Slack API methods before Slack has to. If nil, calls are not
+	// throttled client-side.
+	Limiter *RateLimiter
+	// Tokens provides the token for calls made on behalf of the team in their context (see
+	// WithTeam), so one client can serve every workspace the app is installed in. If nil, or if
+	// the context names no team, Config.AccessToken is used.
+	Tokens TokenSource
+	// ConfigSource, if set, supplies the configuration in place of Config, so that it can be
+	// changed while the client is in use.
+	ConfigSource ConfigSource
+
+	// rotation holds the current access token if the config has a RefreshToken.
+	rotation *rotatingToken
 }
 
-// New returns a new Client.
+// New returns a new Client, which throttles itself according to DefaultTierLimits. Only clients
+// created with New rotate tokens when the config has a RefreshToken.
 func New(config Config) *Client {
-	return &Client{Config: config}
+	return &Client{Config: config, Limiter: NewRateLimiter(DefaultTierLimits), rotation: &rotatingToken{}}
+}
+
+// CurrentConfig returns the configuration the client is currently using.
+func (c *Client) CurrentConfig() Config {
+	if c.ConfigSource != nil {
+		return c.ConfigSource.Config()
+	}
+	return c.Config
+}
+
+// WithToken returns a copy of the Client that always authenticates using the given token instead
+// of its usual one.
+func (c *Client) WithToken(token string) *Client {
+	c2 := *c
+	c2.Config = c.CurrentConfig()
+	c2.Config.AccessToken = token
+	c2.Config.RefreshToken = ""
+	c2.ConfigSource = nil
+	c2.Tokens = nil
+	return &c2
 }
 
 // CallMethod calls most Slack API methods by name. If the API is normal but the URL is weird,
 // providing a complete https:// URL as the API name also works.
+//
+// If Slack rate limits the call, it is retried according to the client's RetryPolicy; if we still
+// can't make the call, ErrRateLimit is returned.
 func (c *Client) CallMethod(api string, args interface{}, ret interface{}) error {
+	return c.CallMethodContext(context.Background(), api, args, ret)
+}
+
+// CallMethodContext is like CallMethod, but gives up when the context is done, including while
+// waiting out a rate limit.
+func (c *Client) CallMethodContext(ctx context.Context, api string, args interface{}, ret interface{}) error {
 	marshalled, err := json.Marshal(args)
 	if err != nil {
 		return fmt.Errorf("failed to marshal slack message: %v", err)
 	}
-	b := bytes.NewBuffer(marshalled)
-	req, err := http.NewRequest("POST", slackMethodToURL(api), b)
+	token, err := c.accessToken(ctx, api)
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %v", err)
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Header.Set("Authorization", "Bearer "+c.Config.AccessToken)
-	return handleSlackRequest(req, ret)
+	return c.do(ctx, api, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.methodURL(api), bytes.NewReader(marshalled))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}, ret)
 }
 
 // CallOldMethod calls Slack API methods that for some reason continue to not support JSON requests.
 func (c *Client) CallOldMethod(api string, args map[string]string, ret interface{}) error {
+	return c.CallOldMethodContext(context.Background(), api, args, ret)
+}
+
+// CallOldMethodContext is like CallOldMethod, but gives up when the context is done.
+func (c *Client) CallOldMethodContext(ctx context.Context, api string, args map[string]string, ret interface{}) error {
+	token, err := c.accessToken(ctx, api)
+	if err != nil {
+		return err
+	}
 	vs := url.Values{}
 	for k, v := range args {
 		vs[k] = []string{v}
 	}
-	vs["token"] = []string{c.Config.AccessToken}
+	vs["token"] = []string{token}
 	q := vs.Encode()
-	b := bytes.NewBufferString(q)
-	u := slackMethodToURL(api)
-	req, err := http.NewRequest("POST", u, b)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %v", err)
+	u := c.methodURL(api)
+	return c.do(ctx, api, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(q))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		return req, nil
+	}, ret)
+}
+
+// do sends the request produced by newRequest, handling client-side throttling and retrying if
+// Slack rate limits us.
+func (c *Client) do(ctx context.Context, api string, newRequest func() (*http.Request, error), ret interface{}) error {
+	policy := DefaultRetryPolicy
+	if c.RetryPolicy != nil {
+		policy = *c.RetryPolicy
+	}
+	limiter := c.Limiter
+	if !isMethodName(api) {
+		limiter = nil
+	}
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		if limiter != nil {
+			start := time.Now()
+			if err := limiter.Wait(ctx, api); err != nil {
+				return err
+			}
+			if wait := time.Since(start); wait > time.Millisecond {
+				observeRateLimitWait(api, wait)
+			}
+		}
+		req, err := newRequest()
+		if err != nil {
+			return fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		start := time.Now()
+		err = c.handleSlackRequest(req, ret)
+		observeCall(api, start, err)
+		var rateLimit ErrRateLimit
+		if !errors.As(err, &rateLimit) {
+			return err
+		}
+		wait := policy.backoff(rateLimit.Wait)
+		if attempt >= policy.MaxRetries || waited+wait > policy.MaxWait {
+			return err
+		}
+		log.Printf("Slack is rate limiting %s, trying again in %s...", api, wait)
+		if limiter != nil {
+			limiter.Penalize(api, wait)
+		} else {
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+			observeRateLimitWait(api, wait)
+		}
+		waited += wait
+	}
+}
+
+func (c *Client) methodURL(method string) string {
+	if strings.HasPrefix(method, "https://") || strings.HasPrefix(method, "http://") {
+		return method
+	}
+	base := c.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
-	return handleSlackRequest(req, ret)
+	return base + method
 }
 
-func slackMethodToURL(method string) string {
-	if !strings.HasPrefix(method, "https://") {
-		return "https://slack.com/api/" + method
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
 	}
-	return method
+	return http.DefaultClient
 }
 
-func handleSlackRequest(req *http.Request, ret interface{}) error {
-	client := http.Client{}
-	response, err := client.Do(req)
+func (c *Client) handleSlackRequest(req *http.Request, ret interface{}) error {
+	response, err := c.httpClient().Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to POST message to Slack: %v", err)
+		return ErrTransport{Err: err}
 	}
+	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
 		if response.StatusCode == http.StatusTooManyRequests {
 			retryAfter, err := strconv.ParseInt(response.Header.Get("Retry-After"), 10, 64)
@@ -99,7 +239,8 @@ func handleSlackRequest(req *http.Request, ret interface{}) error {
 			}
 			return ErrRateLimit{Wait: time.Duration(retryAfter) * time.Second}
 		}
-		return fmt.Errorf("sending message to Slack failed")
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodyLength))
+		return ErrHTTP{StatusCode: response.StatusCode, Body: string(body)}
 	}
 	if strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
 		result := struct {
@@ -132,10 +273,15 @@ func handleSlackRequest(req *http.Request, ret interface{}) error {
 
 // SendMessage sends a simple message to Slack.
 func (c *Client) SendMessage(message string) error {
+	return c.SendMessageContext(context.Background(), message)
+}
+
+// SendMessageContext is like SendMessage, but gives up when the context is done.
+func (c *Client) SendMessageContext(ctx context.Context, message string) error {
 	toSend := struct {
 		Text string `json:"text"`
 	}{message}
-	return c.CallMethod(c.Config.WebhookURL, toSend, nil)
+	return c.CallMethodContext(ctx, c.CurrentConfig().WebhookURL, toSend, nil)
 }
 
 // VerifySignature verifies the signature on a message from Slack to ensure it is real.
@@ -169,7 +315,7 @@ func (c *Client) VerifySignature(body []byte, headers http.Header) error {
 
 	// Step 3
 	sigBase := append([]byte("v0:"+tsHeader+":"), body...)
-	h := hmac.New(sha256.New, []byte(c.Config.SigningSecret))
+	h := hmac.New(sha256.New, []byte(c.CurrentConfig().SigningSecret))
 	_, _ = h.Write(sigBase)
 	ourSignature := h.Sum(nil)
 	if !hmac.Equal(ourSignature, expectedSignatureBytes) {
@@ -178,7 +324,8 @@ func (c *Client) VerifySignature(body []byte, headers http.Header) error {
 	return nil
 }
 
-// EscapeMessage escapes special characters in Slack messages.
+// EscapeMessage escapes special characters in Slack messages. The mrkdwn package builds whole
+// messages with escaping applied throughout.
 func EscapeMessage(s string) string {
-	return strings.NewReplacer("<", "&lt;", ">", "&gt;", "&", "&amp;").Replace(s)
+	return string(mrkdwn.Escape(s))
 }
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// AddBookmark adds a bookmark to the given conversation, returning its ID. If the bookmark has no
+// ID, one is assigned.
+func (s *Server) AddBookmark(channel string, b slack.Bookmark) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[channel]
+	if !ok {
+		panic(fmt.Sprintf("slacktest: AddBookmark to unknown channel %s", channel))
+	}
+	if b.ID == "" {
+		b.ID = s.newID("Bk")
+	}
+	if b.Type == "" {
+		b.Type = "link"
+	}
+	b.ChannelID = channel
+	c.bookmarks = append(c.bookmarks, &b)
+	return b.ID
+}
+
+// Bookmarks returns the bookmarks in the given conversation, in the order they were added.
+func (s *Server) Bookmarks(channel string) []slack.Bookmark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[channel]
+	if !ok {
+		return nil
+	}
+	result := make([]slack.Bookmark, 0, len(c.bookmarks))
+	for _, b := range c.bookmarks {
+		result = append(result, *b)
+	}
+	return result
+}
+
+func (s *Server) registerBookmarks() {
+	s.methods["bookmarks.list"] = s.bookmarksList
+	s.methods["bookmarks.add"] = s.bookmarksAdd
+	s.methods["bookmarks.edit"] = s.bookmarksEdit
+	s.methods["bookmarks.remove"] = s.bookmarksRemove
+}
+
+func (s *Server) bookmarksList(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	bookmarks := []slack.Bookmark{}
+	for _, b := range c.bookmarks {
+		bookmarks = append(bookmarks, *b)
+	}
+	return success(map[string]interface{}{"bookmarks": bookmarks}), nil
+}
+
+func (s *Server) bookmarksAdd(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("is_archived")
+	}
+	if call.Arg("title") == "" || call.Arg("type") != "link" || call.Arg("link") == "" {
+		return nil, slackError("invalid_arguments")
+	}
+	b := &slack.Bookmark{
+		ID:          s.newID("Bk"),
+		ChannelID:   c.ID,
+		Title:       call.Arg("title"),
+		Link:        call.Arg("link"),
+		Emoji:       call.Arg("emoji"),
+		Type:        "link",
+		DateCreated: time.Now().Unix(),
+	}
+	c.bookmarks = append(c.bookmarks, b)
+	return success(map[string]interface{}{"bookmark": b}), nil
+}
+
+func (s *Server) bookmarksEdit(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	b := c.bookmark(call.Arg("bookmark_id"))
+	if b == nil {
+		return nil, slackError("not_found")
+	}
+	if t, ok := call.Params["title"]; ok {
+		b.Title = stringify(t)
+	}
+	if l, ok := call.Params["link"]; ok {
+		b.Link = stringify(l)
+	}
+	if e, ok := call.Params["emoji"]; ok {
+		b.Emoji = stringify(e)
+	}
+	b.DateUpdated = time.Now().Unix()
+	return success(map[string]interface{}{"bookmark": b}), nil
+}
+
+func (s *Server) bookmarksRemove(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	id := call.Arg("bookmark_id")
+	for i, b := range c.bookmarks {
+		if b.ID == id {
+			c.bookmarks = append(c.bookmarks[:i], c.bookmarks[i+1:]...)
+			return success(nil), nil
+		}
+	}
+	return nil, slackError("not_found")
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Message is a message stored by the fake.
+type Message struct {
+	Type     string          `json:"type"`
+	Channel  string          `json:"channel,omitempty"`
+	User     string          `json:"user,omitempty"`
+	Text     string          `json:"text"`
+	TS       string          `json:"ts"`
+	ThreadTS string          `json:"thread_ts,omitempty"`
+	BotID    string          `json:"bot_id,omitempty"`
+	Blocks   json.RawMessage `json:"blocks,omitempty"`
+	Files    []File          `json:"files,omitempty"`
+	// Ephemeral is set for messages sent with chat.postEphemeral. EphemeralTo is the user who can
+	// see them.
+	Ephemeral   bool   `json:"-"`
+	EphemeralTo string `json:"-"`
+}
+
+// AddMessage adds a message to the given conversation, returning its timestamp. If the message
+// has no timestamp, one is assigned.
+func (s *Server) AddMessage(channel string, m Message) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[channel]
+	if !ok {
+		panic(fmt.Sprintf("slacktest: AddMessage to unknown channel %s", channel))
+	}
+	if m.TS == "" {
+		m.TS = s.newTS()
+	}
+	if m.Type == "" {
+		m.Type = "message"
+	}
+	m.Channel = channel
+	c.messages = append(c.messages, &m)
+	return m.TS
+}
+
+// Messages returns every message currently in the given conversation, oldest first, including
+// ephemeral messages.
+func (s *Server) Messages(channel string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[channel]
+	if !ok {
+		return nil
+	}
+	result := make([]Message, 0, len(c.messages))
+	for _, m := range c.messages {
+		result = append(result, *m)
+	}
+	return result
+}
+
+func (s *Server) registerChat() {
+	s.methods["chat.postMessage"] = s.chatPostMessage
+	s.methods["chat.postEphemeral"] = s.chatPostEphemeral
+	s.methods["chat.update"] = s.chatUpdate
+	s.methods["chat.delete"] = s.chatDelete
+	s.methods["chat.getPermalink"] = s.chatGetPermalink
+}
+
+// messageTarget resolves the channel argument of a chat method, which may be a user ID, in which
+// case the message goes to the bot's DM with that user.
+func (s *Server) messageTarget(call Call) (*conversation, error) {
+	id := call.Arg("channel")
+	if strings.HasPrefix(id, "U") || strings.HasPrefix(id, "W") {
+		if _, ok := s.users[id]; !ok {
+			return nil, slackError("channel_not_found")
+		}
+		for _, cid := range sortedKeys(s.conversations) {
+			c := s.conversations[cid]
+			if c.IsIM && c.hasMember(id) && c.hasMember(s.BotUserID) {
+				return c, nil
+			}
+		}
+		c := &conversation{}
+		c.ID = s.newID("D")
+		c.IsIM = true
+		c.members = []string{s.BotUserID, id}
+		s.conversations[c.ID] = c
+		return c, nil
+	}
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("is_archived")
+	}
+	if c.IsPrivate && !c.hasMember(s.BotUserID) {
+		return nil, slackError("channel_not_found")
+	}
+	return c, nil
+}
+
+func messageFromCall(call Call) Message {
+	m := Message{
+		Type:     "message",
+		Text:     call.Arg("text"),
+		ThreadTS: call.Arg("thread_ts"),
+	}
+	if b, ok := call.Params["blocks"]; ok {
+		switch x := b.(type) {
+		case string:
+			m.Blocks = json.RawMessage(x)
+		default:
+			m.Blocks, _ = json.Marshal(x)
+		}
+	}
+	return m
+}
+
+func (s *Server) chatPostMessage(call Call) (interface{}, error) {
+	c, err := s.messageTarget(call)
+	if err != nil {
+		return nil, err
+	}
+	m := messageFromCall(call)
+	if m.Text == "" && len(m.Blocks) == 0 && call.Arg("attachments") == "" {
+		return nil, slackError("no_text")
+	}
+	if m.ThreadTS != "" && c.message(m.ThreadTS) == nil {
+		return nil, slackError("thread_not_found")
+	}
+	m.Channel = c.ID
+	m.User = s.BotUserID
+	m.TS = s.newTS()
+	c.messages = append(c.messages, &m)
+	return success(map[string]interface{}{"channel": c.ID, "ts": m.TS, "message": m}), nil
+}
+
+func (s *Server) chatPostEphemeral(call Call) (interface{}, error) {
+	c, err := s.messageTarget(call)
+	if err != nil {
+		return nil, err
+	}
+	user := call.Arg("user")
+	if _, ok := s.users[user]; !ok {
+		return nil, slackError("user_not_found")
+	}
+	if !c.hasMember(user) {
+		return nil, slackError("user_not_in_channel")
+	}
+	m := messageFromCall(call)
+	m.Channel = c.ID
+	m.User = s.BotUserID
+	m.TS = s.newTS()
+	m.Ephemeral = true
+	m.EphemeralTo = user
+	c.messages = append(c.messages, &m)
+	return success(map[string]interface{}{"message_ts": m.TS}), nil
+}
+
+func (s *Server) chatUpdate(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	m := c.message(call.Arg("ts"))
+	if m == nil || m.Ephemeral {
+		return nil, slackError("message_not_found")
+	}
+	updated := messageFromCall(call)
+	m.Text = updated.Text
+	if updated.Blocks != nil {
+		m.Blocks = updated.Blocks
+	}
+	return success(map[string]interface{}{"channel": c.ID, "ts": m.TS, "text": m.Text}), nil
+}
+
+func (s *Server) chatDelete(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	ts := call.Arg("ts")
+	for i, m := range c.messages {
+		if m.TS == ts && !m.Ephemeral {
+			c.messages = append(c.messages[:i], c.messages[i+1:]...)
+			s.unpin(c, ts)
+			return success(map[string]interface{}{"channel": c.ID, "ts": ts}), nil
+		}
+	}
+	return nil, slackError("message_not_found")
+}
+
+func (s *Server) chatGetPermalink(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	ts := call.Arg("message_ts")
+	if c.message(ts) == nil {
+		return nil, slackError("message_not_found")
+	}
+	return success(map[string]interface{}{
+		"channel":   c.ID,
+		"permalink": Permalink(c.ID, ts),
+	}), nil
+}
+
+// Permalink returns the permalink that the fake generates for the given message.
+func Permalink(channel, ts string) string {
+	return fmt.Sprintf("https://slacktest.slack.com/archives/%s/p%s", channel, strings.Replace(ts, ".", "", 1))
+}
+
+func (s *Server) registerPins() {
+	s.methods["pins.add"] = s.pinsAdd
+	s.methods["pins.list"] = s.pinsList
+	s.methods["pins.remove"] = s.pinsRemove
+}
+
+// Pins returns the timestamps of the pinned messages in the given conversation.
+func (s *Server) Pins(channel string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.conversations[channel]; ok {
+		return append([]string(nil), c.pins...)
+	}
+	return nil
+}
+
+func (s *Server) unpin(c *conversation, ts string) bool {
+	for i, p := range c.pins {
+		if p == ts {
+			c.pins = append(c.pins[:i], c.pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) pinsAdd(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	ts := call.Arg("timestamp")
+	if c.message(ts) == nil {
+		return nil, slackError("message_not_found")
+	}
+	for _, p := range c.pins {
+		if p == ts {
+			return nil, slackError("already_pinned")
+		}
+	}
+	c.pins = append(c.pins, ts)
+	return success(nil), nil
+}
+
+func (s *Server) pinsList(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	items := []map[string]interface{}{}
+	for _, p := range c.pins {
+		if m := c.message(p); m != nil {
+			items = append(items, map[string]interface{}{
+				"type":       "message",
+				"channel":    c.ID,
+				"created_by": s.BotUserID,
+				"message":    m,
+			})
+		}
+	}
+	return success(map[string]interface{}{"items": items}), nil
+}
+
+func (s *Server) pinsRemove(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if !s.unpin(c, call.Arg("timestamp")) {
+		return nil, slackError("no_pin")
+	}
+	return success(nil), nil
+}
@@ -0,0 +1,515 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+type conversation struct {
+	slack.Conversation
+	members   []string
+	messages  []*Message
+	pins      []string
+	bookmarks []*slack.Bookmark
+}
+
+func (c *conversation) hasMember(user string) bool {
+	for _, m := range c.members {
+		if m == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *conversation) addMember(user string) {
+	if !c.hasMember(user) {
+		c.members = append(c.members, user)
+	}
+}
+
+func (c *conversation) message(ts string) *Message {
+	for _, m := range c.messages {
+		if m.TS == ts {
+			return m
+		}
+	}
+	return nil
+}
+
+func (c *conversation) bookmark(id string) *slack.Bookmark {
+	for _, b := range c.bookmarks {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (c *conversation) matchesType(t string) bool {
+	switch slack.ConversationType(t) {
+	case slack.ConversationTypePublicChannel:
+		return c.IsChannel && !c.IsPrivate
+	case slack.ConversationTypePrivateChannel:
+		return (c.IsChannel || c.IsGroup) && c.IsPrivate
+	case slack.ConversationTypeIM:
+		return c.IsIM
+	case slack.ConversationTypeMPIM, "mpim":
+		return c.IsMPIM
+	}
+	return false
+}
+
+// view returns the conversation as seen by the given user.
+func (c *conversation) view(user string) slack.Conversation {
+	v := c.Conversation
+	v.IsMember = c.hasMember(user)
+	v.NumMembers = len(c.members)
+	return v
+}
+
+// AddConversation adds a conversation to the fake, returning its ID. If the conversation has no ID,
+// one is assigned. Conversations with no type flags set are assumed to be public channels. Any
+// members given are added to the conversation; if the conversation's IsMember is set, the bot
+// user is also added.
+func (s *Server) AddConversation(c slack.Conversation, members ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !c.IsChannel && !c.IsGroup && !c.IsIM && !c.IsMPIM {
+		c.IsChannel = true
+	}
+	if c.ID == "" {
+		prefix := "C"
+		if c.IsIM {
+			prefix = "D"
+		}
+		c.ID = s.newID(prefix)
+	}
+	if c.NameNormalized == "" {
+		c.NameNormalized = c.Name
+	}
+	conv := &conversation{Conversation: c}
+	for _, m := range members {
+		conv.addMember(m)
+	}
+	if c.IsMember {
+		conv.addMember(s.BotUserID)
+	}
+	s.conversations[c.ID] = conv
+	return c.ID
+}
+
+// Conversation returns the current state of the conversation with the given ID, as seen by the bot
+// user.
+func (s *Server) Conversation(id string) (slack.Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[id]
+	if !ok {
+		return slack.Conversation{}, false
+	}
+	return c.view(s.BotUserID), true
+}
+
+// ConversationByName returns the current state of the conversation with the given name.
+func (s *Server) ConversationByName(name string) (slack.Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.conversationByName(name); c != nil {
+		return c.view(s.BotUserID), true
+	}
+	return slack.Conversation{}, false
+}
+
+// Members returns the IDs of the members of the given conversation.
+func (s *Server) Members(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.conversations[id]; ok {
+		return append([]string(nil), c.members...)
+	}
+	return nil
+}
+
+func (s *Server) conversationByName(name string) *conversation {
+	for _, id := range sortedKeys(s.conversations) {
+		if c := s.conversations[id]; c.Name == name && !c.IsIM {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) conversationArg(call Call, arg string) (*conversation, error) {
+	id := call.Arg(arg)
+	if id == "" {
+		return nil, slackError("channel_not_found")
+	}
+	c, ok := s.conversations[id]
+	if !ok {
+		return nil, slackError("channel_not_found")
+	}
+	return c, nil
+}
+
+func (s *Server) registerConversations() {
+	s.methods["conversations.list"] = s.conversationsList
+	s.methods["conversations.info"] = s.conversationsInfo
+	s.methods["conversations.create"] = s.conversationsCreate
+	s.methods["conversations.archive"] = s.conversationsArchive
+	s.methods["conversations.unarchive"] = s.conversationsUnarchive
+	s.methods["conversations.rename"] = s.conversationsRename
+	s.methods["conversations.setTopic"] = s.conversationsSetTopic
+	s.methods["conversations.setPurpose"] = s.conversationsSetPurpose
+	s.methods["conversations.join"] = s.conversationsJoin
+	s.methods["conversations.invite"] = s.conversationsInvite
+	s.methods["conversations.kick"] = s.conversationsKick
+	s.methods["conversations.open"] = s.conversationsOpen
+	s.methods["conversations.members"] = s.conversationsMembers
+	s.methods["conversations.history"] = s.conversationsHistory
+	s.methods["conversations.replies"] = s.conversationsReplies
+	s.methods["users.conversations"] = s.usersConversations
+	s.methods["admin.conversations.convertToPrivate"] = s.adminConversationsConvertToPrivate
+	s.methods["admin.conversations.convertToPublic"] = s.adminConversationsConvertToPublic
+}
+
+func (s *Server) listConversations(call Call, filter func(c *conversation) bool) (interface{}, error) {
+	types := splitList(call.Arg("types"))
+	if len(types) == 0 {
+		types = []string{string(slack.ConversationTypePublicChannel)}
+	}
+	excludeArchived := call.Arg("exclude_archived") == "true"
+	var matches []slack.Conversation
+	for _, id := range sortedKeys(s.conversations) {
+		c := s.conversations[id]
+		if excludeArchived && c.IsArchived {
+			continue
+		}
+		if !filter(c) {
+			continue
+		}
+		for _, t := range types {
+			if c.matchesType(t) {
+				matches = append(matches, c.view(s.BotUserID))
+				break
+			}
+		}
+	}
+	start, end, next := paginate(call, len(matches))
+	channels := append([]slack.Conversation{}, matches[start:end]...)
+	return success(map[string]interface{}{"channels": channels, "response_metadata": metadata(next)}), nil
+}
+
+func (s *Server) conversationsList(call Call) (interface{}, error) {
+	return s.listConversations(call, func(c *conversation) bool {
+		// Private channels are only visible to their members.
+		return !c.IsPrivate || c.hasMember(s.BotUserID)
+	})
+}
+
+func (s *Server) usersConversations(call Call) (interface{}, error) {
+	user := call.Arg("user")
+	if user == "" {
+		user = s.BotUserID
+	}
+	return s.listConversations(call, func(c *conversation) bool {
+		return c.hasMember(user)
+	})
+}
+
+func (s *Server) conversationsInfo(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsCreate(call Call) (interface{}, error) {
+	name := call.Arg("name")
+	if name == "" {
+		return nil, slackError("invalid_name_required")
+	}
+	if name != strings.ToLower(name) || strings.ContainsAny(name, " .,#@") || len(name) > 80 {
+		return nil, slackError("invalid_name_specials")
+	}
+	if s.conversationByName(name) != nil {
+		return nil, slackError("name_taken")
+	}
+	c := &conversation{Conversation: slack.Conversation{
+		ID:             s.newID("C"),
+		Name:           name,
+		NameNormalized: name,
+		IsChannel:      true,
+		IsPrivate:      call.Arg("is_private") == "true",
+		Created:        time.Now().Unix(),
+		Creator:        s.BotUserID,
+	}}
+	c.addMember(s.BotUserID)
+	s.conversations[c.ID] = c
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsArchive(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("already_archived")
+	}
+	c.IsArchived = true
+	return success(nil), nil
+}
+
+func (s *Server) conversationsUnarchive(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsArchived {
+		return nil, slackError("not_archived")
+	}
+	c.IsArchived = false
+	return success(nil), nil
+}
+
+func (s *Server) conversationsRename(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	name := call.Arg("name")
+	if name == "" {
+		return nil, slackError("invalid_name_required")
+	}
+	if other := s.conversationByName(name); other != nil && other != c {
+		return nil, slackError("name_taken")
+	}
+	c.PreviousNames = append(c.PreviousNames, c.Name)
+	c.Name = name
+	c.NameNormalized = name
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsSetTopic(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("is_archived")
+	}
+	c.Topic.Topic = call.Arg("topic")
+	c.Topic.Creator = s.BotUserID
+	c.Topic.LastSet = time.Now().Unix()
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsSetPurpose(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("is_archived")
+	}
+	c.Purpose.Purpose = call.Arg("purpose")
+	c.Purpose.Creator = s.BotUserID
+	c.Purpose.LastSet = time.Now().Unix()
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsJoin(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if c.IsArchived {
+		return nil, slackError("is_archived")
+	}
+	if c.IsPrivate && !c.hasMember(s.BotUserID) {
+		return nil, slackError("method_not_supported_for_channel_type")
+	}
+	c.addMember(s.BotUserID)
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsInvite(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	if !c.hasMember(s.BotUserID) {
+		return nil, slackError("not_in_channel")
+	}
+	users := splitList(call.Arg("users"))
+	if len(users) == 0 {
+		return nil, slackError("no_user")
+	}
+	for _, u := range users {
+		if _, ok := s.users[u]; !ok {
+			return nil, slackError("user_not_found")
+		}
+		if c.hasMember(u) {
+			return nil, slackError("already_in_channel")
+		}
+	}
+	for _, u := range users {
+		c.addMember(u)
+	}
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsKick(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	user := call.Arg("user")
+	if !c.hasMember(user) {
+		return nil, slackError("not_in_channel")
+	}
+	members := c.members[:0]
+	for _, m := range c.members {
+		if m != user {
+			members = append(members, m)
+		}
+	}
+	c.members = members
+	return success(nil), nil
+}
+
+func (s *Server) conversationsOpen(call Call) (interface{}, error) {
+	if call.Arg("channel") != "" {
+		c, err := s.conversationArg(call, "channel")
+		if err != nil {
+			return nil, err
+		}
+		return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+	}
+	users := splitList(call.Arg("users"))
+	if len(users) == 0 {
+		return nil, slackError("users_list_not_supplied")
+	}
+	for _, u := range users {
+		if _, ok := s.users[u]; !ok {
+			return nil, slackError("user_not_found")
+		}
+	}
+	members := append([]string{s.BotUserID}, users...)
+	sort.Strings(members)
+	for _, id := range sortedKeys(s.conversations) {
+		c := s.conversations[id]
+		if !c.IsIM && !c.IsMPIM {
+			continue
+		}
+		existing := append([]string(nil), c.members...)
+		sort.Strings(existing)
+		if strings.Join(existing, ",") == strings.Join(members, ",") {
+			return success(map[string]interface{}{"channel": c.view(s.BotUserID), "already_open": true}), nil
+		}
+	}
+	c := &conversation{Conversation: slack.Conversation{
+		ID:      s.newID("D"),
+		IsIM:    len(users) == 1,
+		IsMPIM:  len(users) > 1,
+		Created: time.Now().Unix(),
+	}}
+	c.members = members
+	s.conversations[c.ID] = c
+	return success(map[string]interface{}{"channel": c.view(s.BotUserID)}), nil
+}
+
+func (s *Server) conversationsMembers(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	start, end, next := paginate(call, len(c.members))
+	members := append([]string{}, c.members[start:end]...)
+	return success(map[string]interface{}{"members": members, "response_metadata": metadata(next)}), nil
+}
+
+func (s *Server) conversationsHistory(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	// History is returned newest first, and excludes thread replies.
+	var messages []Message
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		m := c.messages[i]
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			continue
+		}
+		messages = append(messages, *m)
+	}
+	start, end, next := paginate(call, len(messages))
+	return success(map[string]interface{}{
+		"messages":          append([]Message{}, messages[start:end]...),
+		"has_more":          next != "",
+		"response_metadata": metadata(next),
+	}), nil
+}
+
+func (s *Server) conversationsReplies(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel")
+	if err != nil {
+		return nil, err
+	}
+	ts := call.Arg("ts")
+	parent := c.message(ts)
+	if parent == nil {
+		return nil, slackError("thread_not_found")
+	}
+	messages := []Message{*parent}
+	for _, m := range c.messages {
+		if m.ThreadTS == ts && m.TS != ts {
+			messages = append(messages, *m)
+		}
+	}
+	start, end, next := paginate(call, len(messages))
+	return success(map[string]interface{}{
+		"messages":          append([]Message{}, messages[start:end]...),
+		"has_more":          next != "",
+		"response_metadata": metadata(next),
+	}), nil
+}
+
+func (s *Server) adminConversationsConvertToPrivate(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	c.IsPrivate = true
+	return success(nil), nil
+}
+
+func (s *Server) adminConversationsConvertToPublic(call Call) (interface{}, error) {
+	c, err := s.conversationArg(call, "channel_id")
+	if err != nil {
+		return nil, err
+	}
+	c.IsPrivate = false
+	return success(nil), nil
+}
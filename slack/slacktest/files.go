@@ -0,0 +1,232 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File is a file stored by the fake.
+type File struct {
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Title              string   `json:"title"`
+	User               string   `json:"user"`
+	Created            int64    `json:"created"`
+	Size               int      `json:"size"`
+	Mimetype           string   `json:"mimetype,omitempty"`
+	URLPrivate         string   `json:"url_private,omitempty"`
+	URLPrivateDownload string   `json:"url_private_download,omitempty"`
+	Channels           []string `json:"channels"`
+	// Content is served by the fake at URLPrivate and URLPrivateDownload.
+	Content []byte `json:"-"`
+}
+
+// upload is a file being uploaded with files.getUploadURLExternal that has not yet been completed.
+type upload struct {
+	name    string
+	length  int
+	content []byte
+	sent    bool
+}
+
+// AddFile adds a file to the fake, returning its ID. If the file has no ID, one is assigned, and if
+// it has no creation time, it is created now. Its private URLs are set to serve its content.
+func (s *Server) AddFile(f File) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFile(f)
+}
+
+// addFile adds a file. Must be called with s.mu held.
+func (s *Server) addFile(f File) string {
+	if f.ID == "" {
+		f.ID = s.newID("F")
+	}
+	if f.Created == 0 {
+		f.Created = time.Now().Unix()
+	}
+	if f.Size == 0 {
+		f.Size = len(f.Content)
+	}
+	u := s.server.URL + "/files/" + url.PathEscape(f.ID) + "/" + url.PathEscape(f.Name)
+	f.URLPrivate = u
+	f.URLPrivateDownload = u + "?download=1"
+	s.files[f.ID] = &f
+	return f.ID
+}
+
+// Files returns every file currently stored by the fake, sorted by ID.
+func (s *Server) Files() []File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]File, 0, len(s.files))
+	for _, id := range sortedKeys(s.files) {
+		result = append(result, *s.files[id])
+	}
+	return result
+}
+
+func (s *Server) registerFiles() {
+	s.methods["files.info"] = s.filesInfo
+	s.methods["files.delete"] = s.filesDelete
+	s.methods["files.getUploadURLExternal"] = s.filesGetUploadURLExternal
+	s.methods["files.completeUploadExternal"] = s.filesCompleteUploadExternal
+}
+
+func (s *Server) filesInfo(call Call) (interface{}, error) {
+	f, ok := s.files[call.Arg("file")]
+	if !ok {
+		return nil, slackError("file_not_found")
+	}
+	return success(map[string]interface{}{"file": f}), nil
+}
+
+func (s *Server) filesDelete(call Call) (interface{}, error) {
+	id := call.Arg("file")
+	if _, ok := s.files[id]; !ok {
+		return nil, slackError("file_not_found")
+	}
+	delete(s.files, id)
+	return success(nil), nil
+}
+
+func (s *Server) filesGetUploadURLExternal(call Call) (interface{}, error) {
+	name := call.Arg("filename")
+	length, err := strconv.Atoi(call.Arg("length"))
+	if name == "" || err != nil || length <= 0 {
+		return nil, slackError("invalid_arguments")
+	}
+	id := s.newID("F")
+	s.uploads[id] = &upload{name: name, length: length}
+	return success(map[string]interface{}{
+		"upload_url": s.server.URL + "/upload/" + id,
+		"file_id":    id,
+	}), nil
+}
+
+func (s *Server) filesCompleteUploadExternal(call Call) (interface{}, error) {
+	var files []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(call.Arg("files")), &files); err != nil || len(files) == 0 {
+		return nil, slackError("invalid_arguments")
+	}
+	for _, f := range files {
+		if u, ok := s.uploads[f.ID]; !ok || !u.sent {
+			return nil, slackError("file_not_found")
+		}
+	}
+	var c *conversation
+	if call.Arg("channel_id") != "" {
+		var err error
+		if c, err = s.conversationArg(call, "channel_id"); err != nil {
+			return nil, err
+		}
+		if !c.hasMember(s.BotUserID) {
+			return nil, slackError("not_in_channel")
+		}
+	}
+
+	var completed []File
+	for _, f := range files {
+		u := s.uploads[f.ID]
+		delete(s.uploads, f.ID)
+		title := f.Title
+		if title == "" {
+			title = u.name
+		}
+		file := File{ID: f.ID, Name: u.name, Title: title, User: s.BotUserID, Content: u.content}
+		if c != nil {
+			file.Channels = []string{c.ID}
+		}
+		s.addFile(file)
+		completed = append(completed, *s.files[f.ID])
+	}
+	if c != nil {
+		m := Message{
+			Type:     "message",
+			Text:     call.Arg("initial_comment"),
+			ThreadTS: call.Arg("thread_ts"),
+			Channel:  c.ID,
+			User:     s.BotUserID,
+			TS:       s.newTS(),
+			Files:    completed,
+		}
+		c.messages = append(c.messages, &m)
+	}
+	return success(map[string]interface{}{"files": completed}), nil
+}
+
+// serveUpload accepts file content sent to an upload URL.
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/upload/")
+	s.record(Call{Method: "upload", Path: r.URL.Path, Params: map[string]interface{}{"file": id}})
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[id]
+	if !ok || u.sent {
+		http.NotFound(w, r)
+		return
+	}
+	if len(body) != u.length {
+		http.Error(w, "length mismatch", http.StatusBadRequest)
+		return
+	}
+	u.content = body
+	u.sent = true
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("OK - " + strconv.Itoa(len(body))))
+}
+
+// serveFile serves the content of a file at its private URL. Like Slack, it serves a login page
+// to callers without a token.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	id, _ := url.PathUnescape(path.Dir(strings.TrimPrefix(r.URL.Path, "/files/")))
+	s.record(Call{Method: "file_download", Path: r.URL.Path, Params: map[string]interface{}{"file": id}})
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body>Sign in to Slack</body></html>"))
+		return
+	}
+	s.mu.Lock()
+	f, ok := s.files[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	mimetype := f.Mimetype
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", mimetype)
+	_, _ = w.Write(f.Content)
+}
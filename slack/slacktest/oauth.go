@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// DefaultTokenLifetime is how long the fake's rotating tokens last, as Slack's do.
+const DefaultTokenLifetime = 12 * time.Hour
+
+// OAuthGrant describes an installation of an app that the fake will report when its code is
+// exchanged with oauth.v2.access.
+type OAuthGrant struct {
+	// ClientID and ClientSecret are the credentials the code must be exchanged with.
+	ClientID     string
+	ClientSecret string
+	// Team is the team the app is being installed in. A Team with only an EnterpriseID is an
+	// organisation-wide installation.
+	Team slack.Team
+	// Scope is the comma-separated list of scopes granted to the bot token.
+	Scope string
+	// UserID is the user installing the app.
+	UserID string
+	// UserScope, if set, causes a user token with these scopes to be issued as well.
+	UserScope string
+	// RedirectURI, if set, must match the redirect_uri the code is exchanged with.
+	RedirectURI string
+	// Rotating causes the bot token to expire after the server's TokenLifetime, and a refresh
+	// token to be issued with it.
+	Rotating bool
+}
+
+type oauthCode struct {
+	grant        OAuthGrant
+	botToken     string
+	userToken    string
+	refreshToken string
+}
+
+func (s *Server) registerOAuth() {
+	s.methods["oauth.v2.access"] = s.oauthV2Access
+}
+
+// AddOAuthCode makes code exchangeable for tokens once, and returns the bot token that will be
+// issued for it.
+func (s *Server) AddOAuthCode(code string, grant OAuthGrant) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := oauthCode{grant: grant, botToken: s.newID("xoxb-")}
+	if grant.UserScope != "" {
+		c.userToken = s.newID("xoxp-")
+	}
+	if grant.Rotating {
+		c.botToken = s.newID("xoxe.xoxb-")
+		c.refreshToken = s.newID("xoxe-1-")
+		s.refreshTokens[c.refreshToken] = grant
+	}
+	s.oauthCodes[code] = c
+	return c.botToken
+}
+
+// AddRefreshToken makes refreshToken usable once to get a new rotating bot token for the grant.
+// Each refresh issues another refresh token, and the old one stops working.
+func (s *Server) AddRefreshToken(refreshToken string, grant OAuthGrant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grant.Rotating = true
+	s.refreshTokens[refreshToken] = grant
+}
+
+// BotTokens returns every bot token the fake has issued through OAuth, in order.
+func (s *Server) BotTokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.botTokens...)
+}
+
+func (s *Server) oauthV2Access(call Call) (interface{}, error) {
+	if call.Arg("grant_type") == "refresh_token" {
+		return s.refreshOAuthToken(call)
+	}
+	c, ok := s.oauthCodes[call.Arg("code")]
+	if !ok {
+		return nil, slackError("invalid_code")
+	}
+	if err := checkClient(call, c.grant); err != nil {
+		return nil, err
+	}
+	if c.grant.RedirectURI != "" && call.Arg("redirect_uri") != c.grant.RedirectURI {
+		return nil, slackError("bad_redirect_uri")
+	}
+	delete(s.oauthCodes, call.Arg("code"))
+
+	result := s.oauthResult(c.grant, c.botToken, c.refreshToken)
+	result["authed_user"] = map[string]interface{}{
+		"id":           c.grant.UserID,
+		"scope":        c.grant.UserScope,
+		"access_token": c.userToken,
+		"token_type":   "user",
+	}
+	return success(result), nil
+}
+
+func (s *Server) refreshOAuthToken(call Call) (interface{}, error) {
+	grant, ok := s.refreshTokens[call.Arg("refresh_token")]
+	if !ok {
+		return nil, slackError("invalid_refresh_token")
+	}
+	if err := checkClient(call, grant); err != nil {
+		return nil, err
+	}
+	delete(s.refreshTokens, call.Arg("refresh_token"))
+	refreshToken := s.newID("xoxe-1-")
+	s.refreshTokens[refreshToken] = grant
+	result := s.oauthResult(grant, s.newID("xoxe.xoxb-"), refreshToken)
+	return success(result), nil
+}
+
+func checkClient(call Call, grant OAuthGrant) error {
+	if call.Arg("client_id") != grant.ClientID {
+		return slackError("invalid_client_id")
+	}
+	if call.Arg("client_secret") != grant.ClientSecret {
+		return slackError("bad_client_secret")
+	}
+	return nil
+}
+
+// oauthResult returns the fields of an oauth.v2.access response issuing botToken for the grant,
+// and records the token. Must be called with s.mu held.
+func (s *Server) oauthResult(grant OAuthGrant, botToken, refreshToken string) map[string]interface{} {
+	s.botTokens = append(s.botTokens, botToken)
+	result := map[string]interface{}{
+		"app_id":                "A00000001",
+		"access_token":          botToken,
+		"token_type":            "bot",
+		"scope":                 grant.Scope,
+		"bot_user_id":           s.BotUserID,
+		"is_enterprise_install": grant.Team.ID == "",
+	}
+	if refreshToken != "" {
+		result["refresh_token"] = refreshToken
+		result["expires_in"] = int(s.TokenLifetime.Seconds())
+	}
+	if grant.Team.ID != "" {
+		result["team"] = map[string]interface{}{"id": grant.Team.ID}
+	}
+	if grant.Team.EnterpriseID != "" {
+		result["enterprise"] = map[string]interface{}{"id": grant.Team.EnterpriseID}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// AddRoleAssignment gives a user an admin role, such as slack.RoleChannelManager, for an entity.
+func (s *Server) AddRoleAssignment(a slack.RoleAssignment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addRoleAssignment(a)
+}
+
+func (s *Server) addRoleAssignment(a slack.RoleAssignment) {
+	for _, r := range s.roles {
+		if r.RoleID == a.RoleID && r.EntityID == a.EntityID && r.UserID == a.UserID {
+			return
+		}
+	}
+	if a.DateCreate == 0 {
+		a.DateCreate = time.Now().Unix()
+	}
+	s.roles = append(s.roles, a)
+}
+
+// RoleAssignments returns the assignments of the given role, sorted by entity then user.
+func (s *Server) RoleAssignments(roleID string) []slack.RoleAssignment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.roleAssignments(roleID, nil)
+}
+
+func (s *Server) roleAssignments(roleID string, entities map[string]bool) []slack.RoleAssignment {
+	result := []slack.RoleAssignment{}
+	for _, r := range s.roles {
+		if r.RoleID == roleID && (entities == nil || entities[r.EntityID]) {
+			result = append(result, r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].EntityID != result[j].EntityID {
+			return result[i].EntityID < result[j].EntityID
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result
+}
+
+func (s *Server) registerRoles() {
+	s.methods["admin.roles.listAssignments"] = s.rolesListAssignments
+	s.methods["admin.roles.addAssignments"] = s.rolesAddAssignments
+	s.methods["admin.roles.removeAssignments"] = s.rolesRemoveAssignments
+}
+
+// splitArg returns the comma-separated values of the named argument.
+func splitArg(call Call, name string) []string {
+	if call.Arg(name) == "" {
+		return nil
+	}
+	return strings.Split(call.Arg(name), ",")
+}
+
+func (s *Server) rolesListAssignments(call Call) (interface{}, error) {
+	var entities map[string]bool
+	if ids := splitArg(call, "entity_ids"); len(ids) > 0 {
+		entities = map[string]bool{}
+		for _, id := range ids {
+			entities[id] = true
+		}
+	}
+	var assignments []slack.RoleAssignment
+	for _, role := range splitArg(call, "role_ids") {
+		assignments = append(assignments, s.roleAssignments(role, entities)...)
+	}
+	start, end, next := paginate(call, len(assignments))
+	return success(map[string]interface{}{
+		"role_assignments":  append([]slack.RoleAssignment{}, assignments[start:end]...),
+		"response_metadata": metadata(next),
+	}), nil
+}
+
+// roleArgs checks and returns the arguments to admin.roles.addAssignments and removeAssignments.
+func (s *Server) roleArgs(call Call) (role string, entities, users []string, err error) {
+	role, entities, users = call.Arg("role_id"), splitArg(call, "entity_ids"), splitArg(call, "user_ids")
+	if role == "" || len(entities) == 0 || len(users) == 0 {
+		return "", nil, nil, slackError("invalid_arguments")
+	}
+	for _, id := range entities {
+		if _, ok := s.conversations[id]; !ok {
+			return "", nil, nil, slackError("invalid_entity_id")
+		}
+	}
+	return role, entities, users, nil
+}
+
+func (s *Server) rolesAddAssignments(call Call) (interface{}, error) {
+	role, entities, users, err := s.roleArgs(call)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entities {
+		for _, u := range users {
+			s.addRoleAssignment(slack.RoleAssignment{RoleID: role, EntityID: e, UserID: u})
+		}
+	}
+	return success(nil), nil
+}
+
+func (s *Server) rolesRemoveAssignments(call Call) (interface{}, error) {
+	role, entities, users, err := s.roleArgs(call)
+	if err != nil {
+		return nil, err
+	}
+	remove := map[slack.RoleAssignment]bool{}
+	for _, e := range entities {
+		for _, u := range users {
+			remove[slack.RoleAssignment{RoleID: role, EntityID: e, UserID: u}] = true
+		}
+	}
+	kept := s.roles[:0]
+	for _, r := range s.roles {
+		if !remove[slack.RoleAssignment{RoleID: r.RoleID, EntityID: r.EntityID, UserID: r.UserID}] {
+			kept = append(kept, r)
+		}
+	}
+	s.roles = kept
+	return success(nil), nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	fromQuery = regexp.MustCompile(`from:<@(\w+)>`)
+	inQuery   = regexp.MustCompile(`in:<#(\w+)(?:\|[^>]*)?>`)
+)
+
+// searchQuery is a parsed search query. The fake only understands "from:<@USER>" and
+// "in:<#CHANNEL>" modifiers and plain words; everything else (e.g. "after:") is ignored, so results
+// may be broader than Slack's.
+type searchQuery struct {
+	from  string
+	in    string
+	words []string
+}
+
+func parseSearchQuery(q string) searchQuery {
+	var sq searchQuery
+	if m := fromQuery.FindStringSubmatch(q); m != nil {
+		sq.from = m[1]
+		q = fromQuery.ReplaceAllString(q, "")
+	}
+	if m := inQuery.FindStringSubmatch(q); m != nil {
+		sq.in = m[1]
+		q = inQuery.ReplaceAllString(q, "")
+	}
+	for _, w := range strings.Fields(q) {
+		if strings.Contains(w, ":") {
+			continue
+		}
+		sq.words = append(sq.words, strings.ToLower(w))
+	}
+	return sq
+}
+
+func (q searchQuery) matches(user, text string) bool {
+	if q.from != "" && user != q.from {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, w := range q.words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// searchPage applies Slack-style page pagination to n results.
+func searchPage(call Call, n int) (start, end int, pagination map[string]interface{}) {
+	count := 20
+	if c, err := strconv.Atoi(call.Arg("count")); err == nil && c > 0 {
+		count = c
+	}
+	page := 1
+	if p, err := strconv.Atoi(call.Arg("page")); err == nil && p > 0 {
+		page = p
+	}
+	pageCount := (n + count - 1) / count
+	if pageCount == 0 {
+		pageCount = 1
+	}
+	start = (page - 1) * count
+	if start > n {
+		start = n
+	}
+	end = start + count
+	if end > n {
+		end = n
+	}
+	return start, end, map[string]interface{}{
+		"total_count": n,
+		"page":        page,
+		"per_page":    count,
+		"page_count":  pageCount,
+		"first":       start + 1,
+		"last":        end,
+	}
+}
+
+func (s *Server) registerSearch() {
+	s.methods["search.messages"] = s.searchMessages
+	s.methods["search.files"] = s.searchFiles
+}
+
+func (s *Server) searchMessages(call Call) (interface{}, error) {
+	q := parseSearchQuery(call.Arg("query"))
+	type match struct {
+		Message
+		Channel struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"channel"`
+		Permalink string `json:"permalink"`
+	}
+	var matches []match
+	for _, id := range sortedKeys(s.conversations) {
+		c := s.conversations[id]
+		for _, m := range c.messages {
+			if m.Ephemeral || (q.in != "" && c.ID != q.in) || !q.matches(m.User, m.Text) {
+				continue
+			}
+			mm := match{Message: *m, Permalink: Permalink(c.ID, m.TS)}
+			mm.Channel.ID = c.ID
+			mm.Channel.Name = c.Name
+			matches = append(matches, mm)
+		}
+	}
+	// Slack sorts by timestamp, descending, when asked to; we always do.
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].TS > matches[j].TS })
+	start, end, pagination := searchPage(call, len(matches))
+	return success(map[string]interface{}{
+		"query": call.Arg("query"),
+		"messages": map[string]interface{}{
+			"matches":    append([]match{}, matches[start:end]...),
+			"pagination": pagination,
+			"total":      len(matches),
+		},
+	}), nil
+}
+
+func (s *Server) searchFiles(call Call) (interface{}, error) {
+	q := parseSearchQuery(call.Arg("query"))
+	var matches []File
+	for _, id := range sortedKeys(s.files) {
+		f := s.files[id]
+		if (q.in == "" || contains(f.Channels, q.in)) && q.matches(f.User, f.Name+" "+f.Title) {
+			matches = append(matches, *f)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Created > matches[j].Created })
+	start, end, pagination := searchPage(call, len(matches))
+	return success(map[string]interface{}{
+		"query": call.Arg("query"),
+		"files": map[string]interface{}{
+			"matches":    append([]File{}, matches[start:end]...),
+			"pagination": pagination,
+			"total":      len(matches),
+		},
+	}), nil
+}
@@ -0,0 +1,485 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slacktest provides an in-process fake of the Slack Web API for use in tests.
+//
+// The fake is stateful: channels created through it can later be listed, messages posted to it can
+// be searched for and deleted, and so forth. Every call made to it is recorded and can be inspected
+// with Calls and CallsTo.
+package slacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// DefaultBotUserID is the user ID that the fake considers to be making API calls.
+const DefaultBotUserID = "UBOT00001"
+
+// DefaultTeamID is the team ID reported by the fake.
+const DefaultTeamID = "T00000001"
+
+// Call is a single recorded call to the fake.
+type Call struct {
+	// Method is the Slack API method name, e.g. "chat.postMessage". Calls to the webhook are
+	// recorded as "webhook", and calls to response URLs as "response_url".
+	Method string
+	// Token is the token that was used to authenticate the call, if any.
+	Token string
+	// Params contains the arguments to the call. Form-encoded arguments are always strings; JSON
+	// arguments have whatever type encoding/json decoded them to.
+	Params map[string]interface{}
+	// Path is the URL path the call was made to.
+	Path string
+}
+
+// Arg returns the named argument as a string. Non-string JSON arguments are re-encoded as JSON,
+// except for lists of strings, which are joined with commas as Slack does.
+func (c Call) Arg(name string) string {
+	return stringify(c.Params[name])
+}
+
+func stringify(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(x))
+		for _, p := range x {
+			if _, ok := p.(map[string]interface{}); ok {
+				b, _ := json.Marshal(x)
+				return string(b)
+			}
+			parts = append(parts, stringify(p))
+		}
+		return strings.Join(parts, ",")
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+type methodFunc func(call Call) (interface{}, error)
+
+// slackError is returned by method implementations to produce an ok: false response.
+type slackError string
+
+func (e slackError) Error() string {
+	return string(e)
+}
+
+// Server is a fake Slack API server.
+type Server struct {
+	// URL is the base URL for API methods, suitable for use as slack.Client.APIURL.
+	URL string
+	// WebhookURL is an incoming webhook URL served by the fake.
+	WebhookURL string
+	// BotUserID is the user ID that the fake treats as the caller of API methods.
+	BotUserID string
+	// TeamID is the team ID reported by the fake.
+	TeamID string
+	// TokenLifetime is how long rotating tokens issued by the fake last. It should be set before
+	// any are issued.
+	TokenLifetime time.Duration
+
+	server  *httptest.Server
+	methods map[string]methodFunc
+
+	mu            sync.Mutex
+	calls         []Call
+	injected      map[string][]injection
+	nextID        int
+	nextTS        int64
+	users         map[string]*slack.User
+	conversations map[string]*conversation
+	usergroups    map[string]*slack.Subteam
+	files         map[string]*File
+	uploads       map[string]*upload
+	roles         []slack.RoleAssignment
+	webhook       []map[string]interface{}
+	responses     map[string][]map[string]interface{}
+	oauthCodes    map[string]oauthCode
+	refreshTokens map[string]OAuthGrant
+	botTokens     []string
+
+	socket            *socketConn
+	socketConnections int
+}
+
+// NewServer starts and returns a new fake Slack server. Callers should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		BotUserID:     DefaultBotUserID,
+		TeamID:        DefaultTeamID,
+		TokenLifetime: DefaultTokenLifetime,
+		injected:      map[string][]injection{},
+		nextTS:        time.Now().UnixMicro(),
+		users:         map[string]*slack.User{},
+		conversations: map[string]*conversation{},
+		usergroups:    map[string]*slack.Subteam{},
+		files:         map[string]*File{},
+		uploads:       map[string]*upload{},
+		responses:     map[string][]map[string]interface{}{},
+		oauthCodes:    map[string]oauthCode{},
+		refreshTokens: map[string]OAuthGrant{},
+	}
+	s.methods = map[string]methodFunc{
+		"api.test":  s.apiTest,
+		"auth.test": s.authTest,
+	}
+	s.registerConversations()
+	s.registerUsers()
+	s.registerUsergroups()
+	s.registerChat()
+	s.registerPins()
+	s.registerBookmarks()
+	s.registerRoles()
+	s.registerSearch()
+	s.registerFiles()
+	s.registerViews()
+	s.registerSocketMode()
+	s.registerOAuth()
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL + "/api/"
+	s.WebhookURL = s.server.URL + "/webhook"
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.DropSocketMode()
+	s.server.Close()
+}
+
+// Client returns a slack.Client configured to talk to the fake server. If the config has no
+// access token, a placeholder one is filled in. The client does not throttle itself, since the
+// fake doesn't either.
+func (s *Server) Client(config slack.Config) *slack.Client {
+	if config.AccessToken == "" {
+		config.AccessToken = "xoxb-slacktest"
+	}
+	if config.WebhookURL == "" {
+		config.WebhookURL = s.WebhookURL
+	}
+	c := slack.New(config)
+	c.APIURL = s.URL
+	c.HTTPClient = s.server.Client()
+	c.Limiter = nil
+	return c
+}
+
+// ResponseURL returns a response_url that is served by the fake, and whose messages can be
+// retrieved with Responses.
+func (s *Server) ResponseURL(id string) string {
+	return s.server.URL + "/response/" + url.PathEscape(id)
+}
+
+// Calls returns every call made to the server so far, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns every call made to the given API method so far, in order.
+func (s *Server) CallsTo(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// injection is a failure to return instead of handling a call.
+type injection struct {
+	errorType  string
+	retryAfter int
+}
+
+// InjectError causes the next call to the given method to fail with the given Slack error type,
+// without affecting any state. Multiple failures for the same method are returned in order.
+func (s *Server) InjectError(method, errorType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.injected[method] = append(s.injected[method], injection{errorType: errorType})
+}
+
+// InjectRateLimit causes the next call to the given method to be rejected with HTTP 429, asking
+// the caller to retry after the given number of seconds.
+func (s *Server) InjectRateLimit(method string, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.injected[method] = append(s.injected[method], injection{retryAfter: retryAfter})
+}
+
+// WebhookMessages returns every message sent to the webhook URL.
+func (s *Server) WebhookMessages() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.webhook...)
+}
+
+// Responses returns every message sent to the response URL with the given ID.
+func (s *Server) Responses(id string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.responses[id]...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// File content isn't a form or JSON, so is handled before parsing.
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/upload/"):
+		s.serveUpload(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/files/"):
+		s.serveFile(w, r)
+		return
+	}
+
+	call, err := parseCall(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/webhook":
+		call.Method = "webhook"
+		s.record(call)
+		s.mu.Lock()
+		s.webhook = append(s.webhook, call.Params)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("ok"))
+		return
+	case strings.HasPrefix(r.URL.Path, "/response/"):
+		call.Method = "response_url"
+		s.record(call)
+		id, _ := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/response/"))
+		s.mu.Lock()
+		s.responses[id] = append(s.responses[id], call.Params)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("ok"))
+		return
+	case r.URL.Path == "/socket-mode":
+		s.serveSocketMode(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/api/"):
+		call.Method = strings.TrimPrefix(r.URL.Path, "/api/")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	s.record(call)
+	if inj, ok := s.nextInjection(call.Method); ok {
+		if inj.errorType == "" {
+			w.Header().Set("Retry-After", strconv.Itoa(inj.retryAfter))
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"ok": false, "error": inj.errorType})
+		return
+	}
+	result, err := s.dispatch(call)
+	if err != nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, result)
+}
+
+func (s *Server) nextInjection(method string) (injection, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inj := s.injected[method]; len(inj) > 0 {
+		s.injected[method] = inj[1:]
+		return inj[0], true
+	}
+	return injection{}, false
+}
+
+// unauthenticatedMethods are the methods that can be called without a token.
+var unauthenticatedMethods = map[string]bool{
+	"oauth.v2.access": true,
+}
+
+func (s *Server) dispatch(call Call) (interface{}, error) {
+	fn, ok := s.methods[call.Method]
+	if !ok {
+		return nil, slackError("unknown_method")
+	}
+	if call.Token == "" && !unauthenticatedMethods[call.Method] {
+		return nil, slackError("not_authed")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(call)
+}
+
+func (s *Server) record(call Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func parseCall(r *http.Request) (Call, error) {
+	call := Call{Path: r.URL.Path, Params: map[string]interface{}{}}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return call, fmt.Errorf("failed to read body: %v", err)
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		call.Token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if id, secret, ok := r.BasicAuth(); ok {
+		call.Params["client_id"] = id
+		call.Params["client_secret"] = secret
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if len(body) > 0 && string(body) != "null" {
+			if err := json.Unmarshal(body, &call.Params); err != nil {
+				return call, fmt.Errorf("failed to parse JSON body: %v", err)
+			}
+		}
+	} else {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return call, fmt.Errorf("failed to parse form body: %v", err)
+		}
+		for k, v := range r.URL.Query() {
+			values[k] = v
+		}
+		for k := range values {
+			if k == "token" {
+				call.Token = values.Get(k)
+				continue
+			}
+			call.Params[k] = values.Get(k)
+		}
+	}
+	return call, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// success wraps a result in a successful Slack response.
+func success(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["ok"] = true
+	return fields
+}
+
+// newID returns a new Slack-style ID with the given prefix. Must be called with s.mu held.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%08d", prefix, s.nextID)
+}
+
+// newTS returns a new, unique message timestamp. Must be called with s.mu held.
+func (s *Server) newTS() string {
+	s.nextTS++
+	return fmt.Sprintf("%d.%06d", s.nextTS/1e6, s.nextTS%1e6)
+}
+
+// paginate applies Slack-style cursor pagination to a list of n items, returning the range of
+// items to return and the next cursor.
+func paginate(call Call, n int) (start, end int, next string) {
+	limit := 100
+	if l, err := strconv.Atoi(call.Arg("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if c, err := strconv.Atoi(call.Arg("cursor")); err == nil && c > 0 {
+		start = c
+	}
+	if start > n {
+		start = n
+	}
+	end = start + limit
+	if end >= n {
+		return start, n, ""
+	}
+	return start, end, strconv.Itoa(end)
+}
+
+func metadata(next string) map[string]interface{} {
+	return map[string]interface{}{"next_cursor": next}
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var result []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) apiTest(call Call) (interface{}, error) {
+	return success(map[string]interface{}{"args": call.Params}), nil
+}
+
+func (s *Server) authTest(call Call) (interface{}, error) {
+	return success(map[string]interface{}{
+		"url":     "https://slacktest.slack.com/",
+		"team":    "slacktest",
+		"user":    "slacktest-bot",
+		"team_id": s.TeamID,
+		"user_id": s.BotUserID,
+	}), nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestGetConversationsPaginates(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	for i := 0; i < 250; i++ {
+		s.AddConversation(slack.Conversation{Name: fmt.Sprintf("channel-%d", i)})
+	}
+	s.AddConversation(slack.Conversation{Name: "secret", IsPrivate: true})
+
+	channels, err := s.Client(slack.Config{}).GetPublicChannels()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(channels) != 250 {
+		t.Errorf("Expected 250 channels, got %d", len(channels))
+	}
+	if calls := len(s.CallsTo("conversations.list")); calls != 3 {
+		t.Errorf("Expected 3 calls to conversations.list, got %d", calls)
+	}
+}
+
+func TestStatefulChannelLifecycle(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{})
+
+	ret := struct {
+		Channel slack.Conversation `json:"channel"`
+	}{}
+	if err := c.CallMethod("conversations.create", map[string]string{"name": "sig-testing"}, &ret); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	err := c.CallMethod("conversations.create", map[string]string{"name": "sig-testing"}, nil)
+	if e, ok := err.(slack.ErrSlack); !ok || e.Type != "name_taken" {
+		t.Errorf("Expected name_taken creating a duplicate channel, got %v", err)
+	}
+	if err := c.CallMethod("conversations.archive", map[string]string{"channel": ret.Channel.ID}, nil); err != nil {
+		t.Fatalf("Failed to archive channel: %v", err)
+	}
+	if conv, _ := s.Conversation(ret.Channel.ID); !conv.IsArchived {
+		t.Errorf("Expected channel to be archived")
+	}
+	err = c.CallMethod("conversations.archive", map[string]string{"channel": ret.Channel.ID}, nil)
+	if e, ok := err.(slack.ErrSlack); !ok || e.Type != "already_archived" {
+		t.Errorf("Expected already_archived archiving an archived channel, got %v", err)
+	}
+}
+
+func TestMessagesCanBeSearchedAndDeleted(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "general"})
+	s.AddMessage(channel, Message{User: "U00000001", Text: "spam"})
+	s.AddMessage(channel, Message{User: "U00000002", Text: "not spam"})
+	c := s.Client(slack.Config{})
+
+	result := struct {
+		Messages struct {
+			Matches []struct {
+				TS string `json:"ts"`
+			} `json:"matches"`
+		} `json:"messages"`
+	}{}
+	if err := c.CallOldMethod("search.messages", map[string]string{"query": "from:<@U00000001>"}, &result); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(result.Messages.Matches) != 1 {
+		t.Fatalf("Expected one match, got %d", len(result.Messages.Matches))
+	}
+	if err := c.CallMethod("chat.delete", map[string]string{"channel": channel, "ts": result.Messages.Matches[0].TS}, nil); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if messages := s.Messages(channel); len(messages) != 1 || messages[0].Text != "not spam" {
+		t.Errorf("Expected only 'not spam' to remain, got %v", messages)
+	}
+}
+
+func TestCallsAreRecorded(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.InjectError("users.info", "user_not_found")
+	c := s.Client(slack.Config{AccessToken: "xoxb-test"})
+
+	if err := c.CallOldMethod("users.info", map[string]string{"user": "U12345678"}, nil); err == nil {
+		t.Errorf("Expected injected error, but got none")
+	}
+	if err := c.SendMessage("hello"); err != nil {
+		t.Errorf("Failed to send webhook message: %v", err)
+	}
+
+	calls := s.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d: %v", len(calls), calls)
+	}
+	if calls[0].Method != "users.info" || calls[0].Token != "xoxb-test" || calls[0].Arg("user") != "U12345678" {
+		t.Errorf("Unexpected first call: %#v", calls[0])
+	}
+	if calls[1].Method != "webhook" || calls[1].Arg("text") != "hello" {
+		t.Errorf("Unexpected second call: %#v", calls[1])
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SignRequest adds the headers Slack would send to prove that the request with the given body
+// came from Slack, using the given signing secret.
+func SignRequest(r *http.Request, signingSecret string, body []byte) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(signingSecret))
+	_, _ = h.Write([]byte("v0:" + ts + ":"))
+	_, _ = h.Write(body)
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+}
+
+// NewEventRequest returns a signed request delivering the given Events API body, as Slack would
+// send it to a webhook.
+func NewEventRequest(signingSecret string, body []byte) *http.Request {
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	SignRequest(r, signingSecret, body)
+	return r
+}
+
+// NewInteractionRequest returns a signed request delivering the given interaction payload, as
+// Slack would send it to a webhook.
+func NewInteractionRequest(signingSecret string, payload []byte) *http.Request {
+	body := []byte(url.Values{"payload": {string(payload)}}.Encode())
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	SignRequest(r, signingSecret, body)
+	return r
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// socketModeTimeout is how long the fake waits for a Socket Mode client to connect, or to
+// acknowledge an envelope.
+const socketModeTimeout = 5 * time.Second
+
+// SocketModeAck is a Socket Mode client's acknowledgement of an envelope.
+type SocketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// socketConn is a Socket Mode client's connection to the fake.
+type socketConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	// acks receives acknowledgements by envelope ID. Guarded by Server.mu.
+	acks map[string]chan SocketModeAck
+}
+
+func (c *socketConn) send(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (s *Server) registerSocketMode() {
+	s.methods["apps.connections.open"] = s.appsConnectionsOpen
+}
+
+func (s *Server) appsConnectionsOpen(call Call) (interface{}, error) {
+	if !strings.HasPrefix(call.Token, "xapp-") {
+		return nil, slackError("not_allowed_token_type")
+	}
+	u := "ws" + strings.TrimPrefix(s.server.URL, "http") + "/socket-mode?ticket=" + s.newID("")
+	return success(map[string]interface{}{"url": u}), nil
+}
+
+// serveSocketMode accepts a Socket Mode connection, which replaces any existing one.
+func (s *Server) serveSocketMode(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &socketConn{conn: conn, acks: map[string]chan SocketModeAck{}}
+	if err := c.send(map[string]interface{}{"type": "hello", "num_connections": 1}); err != nil {
+		_ = conn.Close()
+		return
+	}
+	s.mu.Lock()
+	if s.socket != nil {
+		_ = s.socket.conn.Close()
+	}
+	s.socket = c
+	s.socketConnections++
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.socket == c {
+			s.socket = nil
+		}
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+	for {
+		ack := SocketModeAck{}
+		if err := conn.ReadJSON(&ack); err != nil {
+			return
+		}
+		s.mu.Lock()
+		ch, ok := c.acks[ack.EnvelopeID]
+		delete(c.acks, ack.EnvelopeID)
+		s.mu.Unlock()
+		if ok {
+			ch <- ack
+		}
+	}
+}
+
+// SocketModeConnections returns the number of Socket Mode connections that have been made to the
+// fake.
+func (s *Server) SocketModeConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.socketConnections
+}
+
+// waitForSocket returns the current Socket Mode connection, waiting for one if necessary.
+func (s *Server) waitForSocket() (*socketConn, error) {
+	deadline := time.Now().Add(socketModeTimeout)
+	for {
+		s.mu.Lock()
+		c := s.socket
+		s.mu.Unlock()
+		if c != nil {
+			return c, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("no Socket Mode client connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// sendEnvelope sends an envelope to the connected Socket Mode client and waits for it to be
+// acknowledged.
+func (s *Server) sendEnvelope(envelopeType string, payload []byte, acceptsResponse bool) (SocketModeAck, error) {
+	c, err := s.waitForSocket()
+	if err != nil {
+		return SocketModeAck{}, err
+	}
+	ch := make(chan SocketModeAck, 1)
+	s.mu.Lock()
+	id := s.newID("E")
+	c.acks[id] = ch
+	s.mu.Unlock()
+	envelope := map[string]interface{}{
+		"type":                     envelopeType,
+		"envelope_id":              id,
+		"payload":                  json.RawMessage(payload),
+		"accepts_response_payload": acceptsResponse,
+	}
+	if err := c.send(envelope); err != nil {
+		return SocketModeAck{}, fmt.Errorf("failed to send envelope: %v", err)
+	}
+	select {
+	case ack := <-ch:
+		return ack, nil
+	case <-time.After(socketModeTimeout):
+		s.mu.Lock()
+		delete(c.acks, id)
+		s.mu.Unlock()
+		return SocketModeAck{}, fmt.Errorf("envelope %s was not acknowledged", id)
+	}
+}
+
+// SendSocketModeEvent delivers the given Events API body to the connected Socket Mode client,
+// waiting for a client to connect if necessary, and returns its acknowledgement.
+func (s *Server) SendSocketModeEvent(body []byte) (SocketModeAck, error) {
+	return s.sendEnvelope("events_api", body, false)
+}
+
+// SendSocketModeInteraction delivers the given interaction payload to the connected Socket Mode
+// client, waiting for a client to connect if necessary, and returns its acknowledgement.
+func (s *Server) SendSocketModeInteraction(payload []byte) (SocketModeAck, error) {
+	return s.sendEnvelope("interactive", payload, true)
+}
+
+// DisconnectSocketMode asks the connected Socket Mode client to reconnect, giving the
+// reason, e.g. "refresh_requested" or "link_disabled".
+func (s *Server) DisconnectSocketMode(reason string) error {
+	c, err := s.waitForSocket()
+	if err != nil {
+		return err
+	}
+	// The client should now reconnect, so later envelopes must wait for the new connection.
+	s.mu.Lock()
+	if s.socket == c {
+		s.socket = nil
+	}
+	s.mu.Unlock()
+	return c.send(map[string]interface{}{"type": "disconnect", "reason": reason})
+}
+
+// DropSocketMode abruptly closes the Socket Mode connection, if there is one.
+func (s *Server) DropSocketMode() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.socket != nil {
+		_ = s.socket.conn.Close()
+		s.socket = nil
+	}
+}
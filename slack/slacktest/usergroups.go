@@ -0,0 +1,212 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// AddUsergroup adds a usergroup to the fake, returning its ID. If the usergroup has no ID, one is
+// assigned.
+func (s *Server) AddUsergroup(g slack.Subteam) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g.ID == "" {
+		g.ID = s.newID("S")
+	}
+	g.IsUsergroup = true
+	g.UserCount = len(g.Users)
+	s.usergroups[g.ID] = &g
+	return g.ID
+}
+
+// Usergroup returns the current state of the usergroup with the given ID.
+func (s *Server) Usergroup(id string) (slack.Subteam, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.usergroups[id]
+	if !ok {
+		return slack.Subteam{}, false
+	}
+	return *g, true
+}
+
+// UsergroupByHandle returns the current state of the usergroup with the given handle.
+func (s *Server) UsergroupByHandle(handle string) (slack.Subteam, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g := s.usergroupByHandle(handle); g != nil {
+		return *g, true
+	}
+	return slack.Subteam{}, false
+}
+
+func (s *Server) usergroupByHandle(handle string) *slack.Subteam {
+	for _, id := range sortedKeys(s.usergroups) {
+		if g := s.usergroups[id]; g.Handle == handle {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) usergroupArg(call Call) (*slack.Subteam, error) {
+	g, ok := s.usergroups[call.Arg("usergroup")]
+	if !ok {
+		return nil, slackError("no_such_subteam")
+	}
+	return g, nil
+}
+
+func (s *Server) registerUsergroups() {
+	s.methods["usergroups.list"] = s.usergroupsList
+	s.methods["usergroups.create"] = s.usergroupsCreate
+	s.methods["usergroups.update"] = s.usergroupsUpdate
+	s.methods["usergroups.enable"] = s.usergroupsEnable
+	s.methods["usergroups.disable"] = s.usergroupsDisable
+	s.methods["usergroups.users.list"] = s.usergroupsUsersList
+	s.methods["usergroups.users.update"] = s.usergroupsUsersUpdate
+}
+
+// usergroupView returns a copy of g, with users omitted unless requested.
+func usergroupView(g *slack.Subteam, includeUsers bool) slack.Subteam {
+	v := *g
+	if !includeUsers {
+		v.Users = nil
+	}
+	return v
+}
+
+func (s *Server) usergroupsList(call Call) (interface{}, error) {
+	includeUsers := call.Arg("include_users") == "true"
+	includeDisabled := call.Arg("include_disabled") == "true"
+	result := []slack.Subteam{}
+	for _, id := range sortedKeys(s.usergroups) {
+		g := s.usergroups[id]
+		if g.DeleteTime != 0 && !includeDisabled {
+			continue
+		}
+		result = append(result, usergroupView(g, includeUsers))
+	}
+	return success(map[string]interface{}{"usergroups": result}), nil
+}
+
+func (s *Server) applyUsergroupArgs(g *slack.Subteam, call Call) error {
+	if handle := call.Arg("handle"); handle != "" {
+		if other := s.usergroupByHandle(handle); other != nil && other != g {
+			return slackError("name_already_exists")
+		}
+		g.Handle = handle
+	}
+	if name := call.Arg("name"); name != "" {
+		g.Name = name
+	}
+	if _, ok := call.Params["description"]; ok {
+		g.Description = call.Arg("description")
+	}
+	if _, ok := call.Params["channels"]; ok {
+		channels := splitList(call.Arg("channels"))
+		for _, c := range channels {
+			if _, ok := s.conversations[c]; !ok {
+				return slackError("invalid_channel")
+			}
+		}
+		g.Prefs.Channels = channels
+	}
+	g.UpdatedBy = s.BotUserID
+	g.UpdateTime = int(time.Now().Unix())
+	return nil
+}
+
+func (s *Server) usergroupsCreate(call Call) (interface{}, error) {
+	if call.Arg("name") == "" {
+		return nil, slackError("invalid_name")
+	}
+	g := &slack.Subteam{
+		ID:          s.newID("S"),
+		IsUsergroup: true,
+		CreatedBy:   s.BotUserID,
+		CreateTime:  int(time.Now().Unix()),
+	}
+	if err := s.applyUsergroupArgs(g, call); err != nil {
+		return nil, err
+	}
+	s.usergroups[g.ID] = g
+	return success(map[string]interface{}{"usergroup": usergroupView(g, false)}), nil
+}
+
+func (s *Server) usergroupsUpdate(call Call) (interface{}, error) {
+	g, err := s.usergroupArg(call)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyUsergroupArgs(g, call); err != nil {
+		return nil, err
+	}
+	return success(map[string]interface{}{"usergroup": usergroupView(g, call.Arg("include_count") == "true")}), nil
+}
+
+func (s *Server) usergroupsEnable(call Call) (interface{}, error) {
+	g, err := s.usergroupArg(call)
+	if err != nil {
+		return nil, err
+	}
+	g.DeleteTime = 0
+	g.DeletedBy = ""
+	return success(map[string]interface{}{"usergroup": usergroupView(g, false)}), nil
+}
+
+func (s *Server) usergroupsDisable(call Call) (interface{}, error) {
+	g, err := s.usergroupArg(call)
+	if err != nil {
+		return nil, err
+	}
+	g.DeleteTime = int(time.Now().Unix())
+	g.DeletedBy = s.BotUserID
+	return success(map[string]interface{}{"usergroup": usergroupView(g, false)}), nil
+}
+
+func (s *Server) usergroupsUsersList(call Call) (interface{}, error) {
+	g, err := s.usergroupArg(call)
+	if err != nil {
+		return nil, err
+	}
+	return success(map[string]interface{}{"users": append([]string{}, g.Users...)}), nil
+}
+
+func (s *Server) usergroupsUsersUpdate(call Call) (interface{}, error) {
+	g, err := s.usergroupArg(call)
+	if err != nil {
+		return nil, err
+	}
+	users := splitList(call.Arg("users"))
+	if len(users) == 0 {
+		return nil, slackError("invalid_users")
+	}
+	for _, u := range users {
+		if _, ok := s.users[u]; !ok {
+			return nil, slackError("invalid_users")
+		}
+	}
+	g.Users = users
+	g.UserCount = len(users)
+	g.UpdatedBy = s.BotUserID
+	g.UpdateTime = int(time.Now().Unix())
+	return success(map[string]interface{}{"usergroup": usergroupView(g, true)}), nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+import (
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// AddUser adds a user to the fake, returning its ID. If the user has no ID, one is assigned.
+func (s *Server) AddUser(u slack.User) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u.ID == "" {
+		u.ID = s.newID("U")
+	}
+	if u.TeamID == "" {
+		u.TeamID = s.TeamID
+	}
+	s.users[u.ID] = &u
+	return u.ID
+}
+
+// User returns the current state of the user with the given ID.
+func (s *Server) User(id string) (slack.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return slack.User{}, false
+	}
+	return *u, true
+}
+
+func (s *Server) registerUsers() {
+	s.methods["users.info"] = s.usersInfo
+	s.methods["users.list"] = s.usersList
+	s.methods["users.lookupByEmail"] = s.usersLookupByEmail
+	s.methods["users.admin.setInactive"] = s.usersAdminSetInactive
+}
+
+func (s *Server) usersInfo(call Call) (interface{}, error) {
+	u, ok := s.users[call.Arg("user")]
+	if !ok {
+		return nil, slackError("user_not_found")
+	}
+	return success(map[string]interface{}{"user": u}), nil
+}
+
+func (s *Server) usersList(call Call) (interface{}, error) {
+	ids := sortedKeys(s.users)
+	start, end, next := paginate(call, len(ids))
+	members := make([]slack.User, 0, end-start)
+	for _, id := range ids[start:end] {
+		members = append(members, *s.users[id])
+	}
+	return success(map[string]interface{}{"members": members, "response_metadata": metadata(next)}), nil
+}
+
+func (s *Server) usersLookupByEmail(call Call) (interface{}, error) {
+	email := call.Arg("email")
+	for _, id := range sortedKeys(s.users) {
+		if u := s.users[id]; email != "" && strings.EqualFold(u.Profile.Email, email) {
+			return success(map[string]interface{}{"user": u}), nil
+		}
+	}
+	return nil, slackError("users_not_found")
+}
+
+func (s *Server) usersAdminSetInactive(call Call) (interface{}, error) {
+	u, ok := s.users[call.Arg("user")]
+	if !ok {
+		return nil, slackError("user_not_found")
+	}
+	u.Deleted = true
+	return success(nil), nil
+}
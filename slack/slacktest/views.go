@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slacktest
+
+// The fake doesn't model views or dialogs beyond recording that they were opened; tests can
+// inspect what was sent using CallsTo.
+
+func (s *Server) registerViews() {
+	s.methods["views.open"] = s.viewsOpen
+	s.methods["views.push"] = s.viewsOpen
+	s.methods["views.update"] = s.viewsUpdate
+	s.methods["dialog.open"] = s.dialogOpen
+}
+
+func (s *Server) viewsOpen(call Call) (interface{}, error) {
+	if call.Arg("trigger_id") == "" {
+		return nil, slackError("invalid_trigger_id")
+	}
+	if _, ok := call.Params["view"]; !ok {
+		return nil, slackError("invalid_arguments")
+	}
+	view, _ := call.Params["view"].(map[string]interface{})
+	result := map[string]interface{}{"id": s.newID("V")}
+	for k, v := range view {
+		result[k] = v
+	}
+	return success(map[string]interface{}{"view": result}), nil
+}
+
+func (s *Server) viewsUpdate(call Call) (interface{}, error) {
+	if call.Arg("view_id") == "" && call.Arg("external_id") == "" {
+		return nil, slackError("not_found")
+	}
+	view, _ := call.Params["view"].(map[string]interface{})
+	result := map[string]interface{}{"id": call.Arg("view_id")}
+	for k, v := range view {
+		result[k] = v
+	}
+	return success(map[string]interface{}{"view": result}), nil
+}
+
+func (s *Server) dialogOpen(call Call) (interface{}, error) {
+	if call.Arg("trigger_id") == "" {
+		return nil, slackError("invalid_trigger_id")
+	}
+	return success(nil), nil
+}
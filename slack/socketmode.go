@@ -0,0 +1,282 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultReconnectDelay is how long SocketMode waits before its first attempt to reconnect
+	// after losing its connection unexpectedly. The delay doubles with each failed attempt.
+	defaultReconnectDelay = time.Second
+	// maxReconnectDelay is the longest SocketMode waits between attempts to reconnect.
+	maxReconnectDelay = time.Minute
+	// socketPingInterval is how often SocketMode pings Slack to check the connection is alive.
+	socketPingInterval = 30 * time.Second
+	// socketReadTimeout is how long SocketMode waits to hear anything from Slack, including
+	// replies to its pings, before giving up on the connection.
+	socketReadTimeout = 2 * socketPingInterval
+	// socketWriteTimeout is how long SocketMode spends trying to send a message to Slack.
+	socketWriteTimeout = 10 * time.Second
+)
+
+// ErrSocketModeDisabled is returned by SocketMode.Run when Slack says Socket Mode has been
+// turned off for the app.
+var ErrSocketModeDisabled = errors.New("socket mode was disabled for the app")
+
+// errReconnectRequested is returned when Slack asks us to reconnect.
+var errReconnectRequested = errors.New("slack requested a reconnect")
+
+// SocketModeHandler handles the requests Slack delivers over Socket Mode. It is implemented by
+// events.Router, so the same handlers can serve requests over both Socket Mode and HTTP.
+type SocketModeHandler interface {
+	// HandleEvent handles the body of an Events API request.
+	HandleEvent(ctx context.Context, body []byte) ([]byte, error)
+	// HandleInteraction handles an interaction payload.
+	HandleInteraction(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// OpenConnection calls apps.connections.open with the app-level token in Config.AppToken,
+// returning the URL of a WebSocket to connect to for Socket Mode.
+func (c *Client) OpenConnection(ctx context.Context) (string, error) {
+	appToken := c.CurrentConfig().AppToken
+	if appToken == "" {
+		return "", errors.New("no app-level token is configured")
+	}
+	result := struct {
+		URL string `json:"url"`
+	}{}
+	if err := c.WithToken(appToken).CallMethodContext(ctx, "apps.connections.open", nil, &result); err != nil {
+		return "", fmt.Errorf("failed to open connection: %w", err)
+	}
+	return result.URL, nil
+}
+
+// SocketMode receives events and interactions from Slack over a WebSocket, instead of Slack
+// sending them to a public HTTP endpoint, and passes them to a handler.
+type SocketMode struct {
+	// Client is used to open connections. Its Config.AppToken must be set.
+	Client *Client
+	// Handler handles everything Slack sends.
+	Handler SocketModeHandler
+	// Dialer is used to connect to Slack. If nil, websocket.DefaultDialer is used.
+	Dialer *websocket.Dialer
+	// ReconnectDelay is how long to wait before reconnecting after the connection is lost. It
+	// doubles with each failed attempt, up to a minute. If zero, one second is used.
+	ReconnectDelay time.Duration
+
+	// inFlight tracks envelopes that are being handled.
+	inFlight sync.WaitGroup
+}
+
+// socketModeEnvelope is a message from Slack received over Socket Mode.
+type socketModeEnvelope struct {
+	Type                   string          `json:"type"`
+	EnvelopeID             string          `json:"envelope_id"`
+	Payload                json.RawMessage `json:"payload"`
+	AcceptsResponsePayload bool            `json:"accepts_response_payload"`
+	RetryAttempt           int             `json:"retry_attempt"`
+	RetryReason            string          `json:"retry_reason"`
+	// Reason is set on disconnect messages.
+	Reason string `json:"reason"`
+}
+
+// socketModeAck acknowledges an envelope.
+type socketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// Run receives and handles envelopes from Slack until ctx is done, reconnecting whenever the
+// connection is lost or Slack asks us to. Once ctx is done, Run waits for the handlers it started
+// to return, then returns nil. If Slack disables Socket Mode for the app, Run returns
+// ErrSocketModeDisabled.
+func (s *SocketMode) Run(ctx context.Context) error {
+	defer s.inFlight.Wait()
+	initialDelay := s.ReconnectDelay
+	if initialDelay <= 0 {
+		initialDelay = defaultReconnectDelay
+	}
+	delay := initialDelay
+	for {
+		connected, err := s.serve(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrSocketModeDisabled) {
+			return err
+		}
+		if connected {
+			delay = initialDelay
+		}
+		if errors.Is(err, errReconnectRequested) {
+			log.Printf("Reconnecting to Slack at its request")
+			continue
+		}
+		log.Printf("Lost Socket Mode connection: %v. Reconnecting in %s", err, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// serve opens a connection and handles envelopes from it until it is lost or ctx is done. It
+// reports whether Slack said hello, i.e. whether the connection was successfully established.
+func (s *SocketMode) serve(ctx context.Context) (bool, error) {
+	u, err := s.Client.OpenConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+	dialer := s.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	conn, _, err := dialer.DialContext(ctx, u, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	// Closing the connection is the only way to interrupt a read.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	w := &socketWriter{conn: conn}
+	extendDeadline := func() { _ = conn.SetReadDeadline(time.Now().Add(socketReadTimeout)) }
+	extendDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		extendDeadline()
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(socketWriteTimeout))
+		if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+			log.Printf("Failed to reply to ping: %v", err)
+		}
+		return nil
+	})
+	pingDone := make(chan struct{})
+	defer close(pingDone)
+	go func() {
+		ticker := time.NewTicker(socketPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pingDone:
+				return
+			case <-ticker.C:
+				_ = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteTimeout))
+			}
+		}
+	}()
+
+	connected := false
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return connected, fmt.Errorf("failed to read: %v", err)
+		}
+		extendDeadline()
+		envelope := socketModeEnvelope{}
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			log.Printf("Failed to parse Socket Mode message: %v", err)
+			continue
+		}
+		switch envelope.Type {
+		case "hello":
+			connected = true
+			log.Printf("Connected to Slack using Socket Mode")
+		case "disconnect":
+			if envelope.Reason == "link_disabled" {
+				return connected, ErrSocketModeDisabled
+			}
+			return connected, errReconnectRequested
+		case "events_api", "interactive":
+			s.inFlight.Add(1)
+			go s.handle(ctx, w, envelope)
+		default:
+			log.Printf("Ignoring unsupported Socket Mode message of type %q", envelope.Type)
+			if envelope.EnvelopeID != "" {
+				if err := w.ack(socketModeAck{EnvelopeID: envelope.EnvelopeID}); err != nil {
+					log.Printf("Failed to acknowledge envelope %s: %v", envelope.EnvelopeID, err)
+				}
+			}
+		}
+	}
+}
+
+// handle passes an envelope to the handler and acknowledges it. If the handler fails, the
+// envelope is not acknowledged, so Slack treats it as it would a failed HTTP request.
+func (s *SocketMode) handle(ctx context.Context, w *socketWriter, envelope socketModeEnvelope) {
+	defer s.inFlight.Done()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Panic while handling envelope %s: %v\n%s", envelope.EnvelopeID, p, debug.Stack())
+		}
+	}()
+
+	if envelope.RetryAttempt > 0 {
+		ctx = WithRedelivery(ctx, Redelivery{Attempt: envelope.RetryAttempt, Reason: envelope.RetryReason})
+	}
+	var response []byte
+	var err error
+	if envelope.Type == "events_api" {
+		response, err = s.Handler.HandleEvent(ctx, envelope.Payload)
+	} else {
+		response, err = s.Handler.HandleInteraction(ctx, envelope.Payload)
+	}
+	if err != nil {
+		log.Printf("Failed to handle %s envelope %s: %v", envelope.Type, envelope.EnvelopeID, err)
+		return
+	}
+	ack := socketModeAck{EnvelopeID: envelope.EnvelopeID}
+	if envelope.AcceptsResponsePayload {
+		ack.Payload = response
+	}
+	if err := w.ack(ack); err != nil {
+		log.Printf("Failed to acknowledge envelope %s: %v", envelope.EnvelopeID, err)
+	}
+}
+
+// socketWriter serialises writes to a WebSocket, which permits only one writer at a time.
+type socketWriter struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (w *socketWriter) ack(ack socketModeAck) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout)); err != nil {
+		return err
+	}
+	return w.conn.WriteJSON(ack)
+}
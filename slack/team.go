@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+// Team identifies a Slack workspace, and the Enterprise Grid organisation it belongs to, if any.
+type Team struct {
+	EnterpriseID string `json:"enterprise_id,omitempty"`
+	ID           string `json:"team_id,omitempty"`
+}
+
+func (t Team) String() string {
+	if t.EnterpriseID == "" {
+		return t.ID
+	}
+	return t.EnterpriseID + "/" + t.ID
+}
+
+// Identity describes the user and team a client's token belongs to.
+type Identity struct {
+	URL    string `json:"url"`
+	Team   string `json:"team"`
+	User   string `json:"user"`
+	TeamID string `json:"team_id"`
+	UserID string `json:"user_id"`
+	BotID  string `json:"bot_id,omitempty"`
+}
+
+// AuthTest returns the identity of the client's token.
+func (c *Client) AuthTest(ctx context.Context) (Identity, error) {
+	var ret Identity
+	if err := c.CallMethodContext(ctx, "auth.test", nil, &ret); err != nil {
+		return Identity{}, fmt.Errorf("failed to check identity: %w", err)
+	}
+	return ret, nil
+}
+
+type teamKey struct{}
+
+// WithTeam returns a context recording that work done with it is on behalf of the given team.
+// Clients with a TokenSource use this to decide which token to call Slack with.
+func WithTeam(ctx context.Context, team Team) context.Context {
+	return context.WithValue(ctx, teamKey{}, team)
+}
+
+// TeamFromContext returns the team recorded in ctx by WithTeam, if any.
+func TeamFromContext(ctx context.Context) (Team, bool) {
+	team, ok := ctx.Value(teamKey{}).(Team)
+	return team, ok
+}
+
+// TokenSource finds the token to use to call Slack on behalf of a team.
+type TokenSource interface {
+	Token(ctx context.Context, team Team) (string, error)
+}
+
+// accessToken returns the token to use for a call to api made with ctx: the one from the
+// client's TokenSource if it has one and ctx names a team, or else the client's own token, which
+// is refreshed first if it is about to expire. Webhooks don't need a token, so never look one up.
+func (c *Client) accessToken(ctx context.Context, api string) (string, error) {
+	config := c.CurrentConfig()
+	if !isMethodName(api) {
+		return config.AccessToken, nil
+	}
+	if team, ok := TeamFromContext(ctx); ok && c.Tokens != nil {
+		token, err := c.Tokens.Token(ctx, team)
+		if err != nil {
+			return "", fmt.Errorf("couldn't get a token for team %s: %w", team, err)
+		}
+		return token, nil
+	}
+	if config.RefreshToken != "" && c.rotation != nil {
+		return c.rotation.token(ctx, c, config)
+	}
+	return config.AccessToken, nil
+}
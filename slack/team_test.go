@@ -0,0 +1,93 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+type fakeTokens map[slack.Team]string
+
+func (f fakeTokens) Token(ctx context.Context, team slack.Team) (string, error) {
+	if token, ok := f[team]; ok {
+		return token, nil
+	}
+	return "", errors.New("not installed")
+}
+
+func TestTokenIsChosenByTeam(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{AccessToken: "xoxb-default"})
+	c.Tokens = fakeTokens{{ID: "T1"}: "xoxb-t1", {EnterpriseID: "E1", ID: "T2"}: "xoxb-t2"}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantToken string
+		wantErr   bool
+	}{
+		{name: "no team", ctx: context.Background(), wantToken: "xoxb-default"},
+		{name: "workspace", ctx: slack.WithTeam(context.Background(), slack.Team{ID: "T1"}), wantToken: "xoxb-t1"},
+		{name: "grid workspace", ctx: slack.WithTeam(context.Background(), slack.Team{EnterpriseID: "E1", ID: "T2"}), wantToken: "xoxb-t2"},
+		{name: "unknown team", ctx: slack.WithTeam(context.Background(), slack.Team{ID: "T3"}), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := len(s.CallsTo("auth.test"))
+			err := c.CallMethodContext(tc.ctx, "auth.test", nil, nil)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			calls := s.CallsTo("auth.test")
+			if len(calls) != before+1 || calls[before].Token != tc.wantToken {
+				t.Errorf("Expected a call with token %q, got %v", tc.wantToken, calls[before:])
+			}
+		})
+	}
+}
+
+func TestExchangeOAuthCode(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	c := s.Client(slack.Config{ClientID: "123.456", ClientSecret: "secret"})
+	token := s.AddOAuthCode("code", slacktest.OAuthGrant{ClientID: "123.456", ClientSecret: "secret", Team: slack.Team{EnterpriseID: "E1"}, Scope: "chat:write"})
+
+	access, err := c.ExchangeOAuthCode(context.Background(), "code", "")
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	if access.AccessToken != token || access.InstalledTeam() != (slack.Team{EnterpriseID: "E1"}) {
+		t.Errorf("Unexpected result %+v", access)
+	}
+	_, err = c.ExchangeOAuthCode(context.Background(), "code", "")
+	var e slack.ErrSlack
+	if !errors.As(err, &e) || e.Type != "invalid_code" {
+		t.Errorf("Expected a code to only be usable once, got %v", err)
+	}
+}
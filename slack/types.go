@@ -16,6 +16,8 @@ limitations under the License.
 
 package slack
 
+import "encoding/json"
+
 // User represents a slack User object.
 type User struct {
 	ID             string `json:"id"`
@@ -115,3 +117,18 @@ type Conversation struct {
 	NumMembers    int      `json:"num_members"`
 	Locale        string   `json:"locale"`
 }
+
+// Message represents a slack message, as returned by conversations.history and
+// conversations.replies.
+type Message struct {
+	Type       string `json:"type"`
+	Subtype    string `json:"subtype"`
+	User       string `json:"user"`
+	BotID      string `json:"bot_id"`
+	Text       string `json:"text"`
+	TS         string `json:"ts"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+	// Blocks holds the message's Block Kit blocks, if any, for ParseMessage.
+	Blocks json.RawMessage `json:"blocks,omitempty"`
+}
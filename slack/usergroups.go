@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ListUsergroupsRequest controls which usergroups ListUsergroups returns.
+type ListUsergroupsRequest struct {
+	// IncludeUsers populates the Users of each returned usergroup.
+	IncludeUsers bool
+	// IncludeDisabled includes disabled usergroups.
+	IncludeDisabled bool
+}
+
+// ListUsergroups returns the workspace's usergroups.
+func (c *Client) ListUsergroups(ctx context.Context, req ListUsergroupsRequest) ([]Subteam, error) {
+	args := map[string]string{
+		"include_users":    strconv.FormatBool(req.IncludeUsers),
+		"include_disabled": strconv.FormatBool(req.IncludeDisabled),
+	}
+	ret := struct {
+		Usergroups []Subteam `json:"usergroups"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "usergroups.list", args, &ret); err != nil {
+		return nil, fmt.Errorf("failed to list usergroups: %w", err)
+	}
+	return ret.Usergroups, nil
+}
+
+// UsergroupRequest describes a usergroup to be created or updated.
+type UsergroupRequest struct {
+	// ID is the usergroup to update. It is ignored when creating a usergroup.
+	ID          string
+	Name        string
+	Handle      string
+	Description string
+	// Channels is a list of channel IDs that members of the usergroup are invited to by default.
+	Channels []string
+}
+
+func (r UsergroupRequest) args() map[string]string {
+	return map[string]string{
+		"name":        r.Name,
+		"handle":      r.Handle,
+		"description": r.Description,
+		"channels":    strings.Join(r.Channels, ","),
+	}
+}
+
+// CreateUsergroup creates a new usergroup.
+func (c *Client) CreateUsergroup(ctx context.Context, req UsergroupRequest) (Subteam, error) {
+	ret := struct {
+		Usergroup Subteam `json:"usergroup"`
+	}{}
+	if err := c.CallMethodContext(ctx, "usergroups.create", req.args(), &ret); err != nil {
+		return Subteam{}, fmt.Errorf("failed to create usergroup %s: %w", req.Handle, err)
+	}
+	return ret.Usergroup, nil
+}
+
+// UpdateUsergroup updates the name, handle, description and default channels of a usergroup.
+func (c *Client) UpdateUsergroup(ctx context.Context, req UsergroupRequest) (Subteam, error) {
+	args := req.args()
+	args["usergroup"] = req.ID
+	ret := struct {
+		Usergroup Subteam `json:"usergroup"`
+	}{}
+	if err := c.CallMethodContext(ctx, "usergroups.update", args, &ret); err != nil {
+		return Subteam{}, fmt.Errorf("failed to update usergroup %s (%s): %w", req.Handle, req.ID, err)
+	}
+	return ret.Usergroup, nil
+}
+
+// EnableUsergroup reactivates a disabled usergroup.
+func (c *Client) EnableUsergroup(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "usergroups.enable", map[string]string{"usergroup": id}, nil); err != nil {
+		return fmt.Errorf("failed to enable usergroup %s: %w", id, err)
+	}
+	return nil
+}
+
+// DisableUsergroup disables a usergroup.
+func (c *Client) DisableUsergroup(ctx context.Context, id string) error {
+	if err := c.CallMethodContext(ctx, "usergroups.disable", map[string]string{"usergroup": id}, nil); err != nil {
+		return fmt.Errorf("failed to disable usergroup %s: %w", id, err)
+	}
+	return nil
+}
+
+// ListUsergroupUsers returns the IDs of the members of a usergroup.
+func (c *Client) ListUsergroupUsers(ctx context.Context, id string) ([]string, error) {
+	ret := struct {
+		Users []string `json:"users"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "usergroups.users.list", map[string]string{"usergroup": id}, &ret); err != nil {
+		return nil, fmt.Errorf("failed to list members of usergroup %s: %w", id, err)
+	}
+	return ret.Users, nil
+}
+
+// UpdateUsergroupUsers replaces the members of a usergroup.
+func (c *Client) UpdateUsergroupUsers(ctx context.Context, id string, users []string) error {
+	args := map[string]string{
+		"usergroup": id,
+		"users":     strings.Join(users, ","),
+	}
+	if err := c.CallMethodContext(ctx, "usergroups.users.update", args, nil); err != nil {
+		return fmt.Errorf("failed to update members of usergroup %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetUser returns the user with the given ID.
+func (c *Client) GetUser(ctx context.Context, id string) (User, error) {
+	ret := struct {
+		User User `json:"user"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "users.info", map[string]string{"user": id}, &ret); err != nil {
+		return User{}, fmt.Errorf("failed to get user %s: %w", id, err)
+	}
+	return ret.User, nil
+}
+
+// LookupUserByEmail returns the user with the given email address.
+func (c *Client) LookupUserByEmail(ctx context.Context, email string) (User, error) {
+	ret := struct {
+		User User `json:"user"`
+	}{}
+	if err := c.CallOldMethodContext(ctx, "users.lookupByEmail", map[string]string{"email": email}, &ret); err != nil {
+		return User{}, fmt.Errorf("failed to look up user %s: %w", email, err)
+	}
+	return ret.User, nil
+}
+
+// DeactivateUser deactivates the given user. This is an undocumented API that requires a client
+// with an admin user token.
+func (c *Client) DeactivateUser(ctx context.Context, id string) error {
+	if err := c.CallOldMethodContext(ctx, "users.admin.setInactive", map[string]string{"user": id}, nil); err != nil {
+		return fmt.Errorf("failed to deactivate user %s: %w", id, err)
+	}
+	return nil
+}
+
+// IsModerator returns true if the user is an admin or owner of the workspace.
+func (u User) IsModerator() bool {
+	return u.IsAdmin || u.IsOwner || u.IsPrimaryOwner
+}
+
+// ListUsers returns a Pager over every user in the workspace, including deactivated ones.
+func (c *Client) ListUsers() Pager[User] {
+	return Pager[User]{Client: c, Method: "users.list", Field: "members"}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack/blockkit"
+)
+
+// OpenView opens a modal in response to the interaction that provided triggerID.
+func (c *Client) OpenView(ctx context.Context, triggerID string, view *blockkit.View) (blockkit.ViewPayload, error) {
+	return c.callViewMethod(ctx, "views.open", map[string]interface{}{"trigger_id": triggerID, "view": view}, view)
+}
+
+// PushView pushes a modal on top of the stack of modals opened by the interaction that provided
+// triggerID.
+func (c *Client) PushView(ctx context.Context, triggerID string, view *blockkit.View) (blockkit.ViewPayload, error) {
+	return c.callViewMethod(ctx, "views.push", map[string]interface{}{"trigger_id": triggerID, "view": view}, view)
+}
+
+// UpdateView replaces the open view with the given ID. If hash is not empty, the update fails if
+// the view has changed since hash was obtained.
+func (c *Client) UpdateView(ctx context.Context, viewID, hash string, view *blockkit.View) (blockkit.ViewPayload, error) {
+	args := map[string]interface{}{"view_id": viewID, "view": view}
+	if hash != "" {
+		args["hash"] = hash
+	}
+	return c.callViewMethod(ctx, "views.update", args, view)
+}
+
+func (c *Client) callViewMethod(ctx context.Context, method string, args interface{}, view *blockkit.View) (blockkit.ViewPayload, error) {
+	if err := view.Validate(); err != nil {
+		return blockkit.ViewPayload{}, fmt.Errorf("invalid view: %v", err)
+	}
+	ret := struct {
+		View blockkit.ViewPayload `json:"view"`
+	}{}
+	if err := c.CallMethodContext(ctx, method, args, &ret); err != nil {
+		return blockkit.ViewPayload{}, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	return ret.View, nil
+}
@@ -18,7 +18,9 @@ package config
 
 import (
 	"fmt"
+	"net/mail"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -42,16 +44,45 @@ type Restrictions struct {
 }
 
 type Channel struct {
-	Name       string   `json:"name"`
-	ID         string   `json:"id,omitempty"`
-	Archived   bool     `json:"archived,omitempty"`
+	Name     string   `json:"name"`
+	ID       string   `json:"id,omitempty"`
+	Archived bool     `json:"archived,omitempty"`
+	Private  bool     `json:"private,omitempty"`
+	Members  []string `json:"members,omitempty"`
+	// Moderators can moderate the channel using the moderator bot, and are made channel managers
+	// if Tempelis is asked to manage them. Like members, they must be listed in Users.
 	Moderators []string `json:"moderators,omitempty"`
+	// Topic and Purpose, or the channel template's if they are empty, are kept up to date unless
+	// IgnoreTopic or IgnorePurpose is set, in which case they are only used when creating the
+	// channel.
+	Topic         string `json:"topic,omitempty"`
+	Purpose       string `json:"purpose,omitempty"`
+	IgnoreTopic   bool   `json:"ignore_topic,omitempty"`
+	IgnorePurpose bool   `json:"ignore_purpose,omitempty"`
+	// Pins and Bookmarks, if not nil, are kept up to date. An empty list removes them all.
+	Pins      []string   `json:"pins,omitempty"`
+	Bookmarks []Bookmark `json:"bookmarks,omitempty"`
 }
 
+type Bookmark struct {
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// MaxTopicLength is the longest topic or purpose Slack allows.
+const MaxTopicLength = 250
+
+// MaxChannelNameLength is the longest channel name Slack allows.
+const MaxChannelNameLength = 80
+
 type Usergroup struct {
-	Name        string   `json:"name,omitempty"`
-	LongName    string   `json:"long_name,omitempty"`
+	Name     string `json:"name,omitempty"`
+	LongName string `json:"long_name,omitempty"`
+	// Members are the names of users, or other usergroups as "@handle", whose members are all
+	// included. Anyone in Exclude, which can also list usergroups, is left out.
 	Members     []string `json:"members,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
 	Channels    []string `json:"channels,omitempty"`
 	Description string   `json:"description,omitempty"`
 	External    bool     `json:"external,omitempty"`
@@ -63,6 +94,38 @@ type ChannelTemplate struct {
 	Purpose string   `json:"purpose,omitempty"`
 }
 
+// UserRef is an entry in the users map. Exactly one of ID, Email or Handle is set; Source is the
+// name of the identity source that knows the Handle's email address.
+type UserRef struct {
+	ID     string
+	Email  string
+	Source string
+	Handle string
+}
+
+var sourceRef = regexp.MustCompile(`^([a-z][a-z0-9-]*):([^:@\s]+)$`)
+
+// ParseUserRef parses an entry in the users map, which is a Slack user ID, an email address, or
+// a handle known to an identity source, such as "github:katharine".
+func ParseUserRef(v string) (UserRef, error) {
+	if m := sourceRef.FindStringSubmatch(v); m != nil {
+		return UserRef{Source: m[1], Handle: m[2]}, nil
+	}
+	if strings.Contains(v, "@") {
+		if a, err := mail.ParseAddress(v); err != nil || a.Address != v {
+			return UserRef{}, fmt.Errorf("%q is not a valid email address", v)
+		}
+		return UserRef{Email: v}, nil
+	}
+	if (len(v) == 9 || len(v) == 11) && !strings.ContainsAny(v, ": \t") {
+		return UserRef{ID: v}, nil
+	}
+	return UserRef{}, fmt.Errorf("%q is not a valid slack user ID, email address or identity source handle", v)
+}
+
+// UsergroupPrefix marks a usergroup member that refers to another usergroup by its handle.
+const UsergroupPrefix = "@"
+
 // NamesToIDs converts a list of names to a list of slack user IDs
 func (c *Config) NamesToIDs(names []string) ([]string, error) {
 	result := make([]string, 0, len(names))
@@ -80,3 +143,129 @@ func (c *Config) NamesToIDs(names []string) ([]string, error) {
 	}
 	return result, nil
 }
+
+// ModeratorIDs returns the Slack IDs of the moderators of each channel that has any, keyed by
+// channel name. Unknown moderators are an error.
+func (c *Config) ModeratorIDs() (map[string][]string, error) {
+	result := map[string][]string{}
+	var problems []string
+	for _, ch := range c.Channels {
+		if len(ch.Moderators) == 0 {
+			continue
+		}
+		ids, err := c.NamesToIDs(ch.Moderators)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("channel %s has %v", ch.Name, err))
+			continue
+		}
+		result[ch.Name] = ids
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("bad moderators: %s", strings.Join(problems, "; "))
+	}
+	return result, nil
+}
+
+// Validate checks the parts of the config that can only be checked once all of it has been
+// parsed, because they refer to things that may be defined in other files.
+func (c *Config) Validate() error {
+	if _, err := c.ModeratorIDs(); err != nil {
+		return err
+	}
+	for _, g := range c.Usergroups {
+		if g.External {
+			continue
+		}
+		if _, err := c.usergroupMembers(g.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UsergroupMemberIDs returns the sorted Slack IDs of the members of the named usergroup, once
+// the usergroups it refers to have been expanded and anyone excluded has been left out.
+func (c *Config) UsergroupMemberIDs(name string) ([]string, error) {
+	names, err := c.UsergroupMembers(name)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := c.NamesToIDs(names)
+	if err != nil {
+		return nil, err
+	}
+	unique := map[string]bool{}
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !unique[id] {
+			unique[id] = true
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// UsergroupMembers returns the sorted names of the users in the named usergroup, once the
+// usergroups it refers to have been expanded and anyone excluded has been left out.
+func (c *Config) UsergroupMembers(name string) ([]string, error) {
+	return c.usergroupMembers(name, nil)
+}
+
+// usergroupMembers returns the sorted names of the users in the named usergroup. path is the
+// chain of usergroups that led to this one, and is used to detect cycles.
+func (c *Config) usergroupMembers(name string, path []string) ([]string, error) {
+	path = append(append([]string{}, path...), name)
+	for _, p := range path[:len(path)-1] {
+		if p == name {
+			return nil, fmt.Errorf("usergroups refer to each other in a cycle: %s%s", UsergroupPrefix, strings.Join(path, " -> "+UsergroupPrefix))
+		}
+	}
+	var group *Usergroup
+	for i := range c.Usergroups {
+		if c.Usergroups[i].Name == name {
+			group = &c.Usergroups[i]
+		}
+	}
+	if group == nil {
+		return nil, fmt.Errorf("usergroup %s refers to unknown usergroup %s%s", path[0], UsergroupPrefix, name)
+	}
+	if group.External {
+		return nil, fmt.Errorf("usergroup %s refers to %s%s, which is external, so its members are unknown", path[0], UsergroupPrefix, name)
+	}
+	included, err := c.expandMembers(group.Members, path)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := c.expandMembers(group.Exclude, path)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(included))
+	for m := range included {
+		if !excluded[m] {
+			result = append(result, m)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// expandMembers returns the set of users in members, expanding any usergroups.
+func (c *Config) expandMembers(members []string, path []string) (map[string]bool, error) {
+	result := map[string]bool{}
+	for _, m := range members {
+		if !strings.HasPrefix(m, UsergroupPrefix) {
+			result[m] = true
+			continue
+		}
+		nested, err := c.usergroupMembers(strings.TrimPrefix(m, UsergroupPrefix), path)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nested {
+			result[n] = true
+		}
+	}
+	return result, nil
+}
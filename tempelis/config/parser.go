@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/bmatcuk/doublestar"
 	"sigs.k8s.io/yaml"
@@ -135,6 +137,9 @@ func ParseFile(path string) (Config, error) {
 	if err := p.ParseFile(path, path); err != nil {
 		return Config{}, err
 	}
+	if err := p.Config.Validate(); err != nil {
+		return Config{}, err
+	}
 	return p.Config, nil
 }
 
@@ -143,6 +148,9 @@ func ParseDir(path string) (Config, error) {
 	if err := p.ParseDir(path); err != nil {
 		return Config{}, err
 	}
+	if err := p.Config.Validate(); err != nil {
+		return Config{}, err
+	}
 	return p.Config, nil
 }
 
@@ -205,8 +213,8 @@ func mergeUsers(target map[string]string, source map[string]string, r Restrictio
 		if _, ok := target[k]; ok {
 			return fmt.Errorf("cannot overwrite users (duplicate user %s)", k)
 		}
-		if len(v) != 9 && len(v) != 11 {
-			return fmt.Errorf("%s: %q is not a valid slack user ID", k, v)
+		if _, err := ParseUserRef(v); err != nil {
+			return fmt.Errorf("%s: %v", k, err)
 		}
 		target[k] = v
 	}
@@ -229,6 +237,18 @@ func mergeChannels(a []Channel, b []Channel, r Restrictions) ([]Channel, error)
 		if !matchesRegexList(v.Name, r.Channels) {
 			return nil, fmt.Errorf("cannot define channel %q in %q", v.Name, r.Path)
 		}
+		if len(v.Members) > 0 && !v.Private {
+			return nil, fmt.Errorf("channel %s lists members, but only private channels can", v.Name)
+		}
+		if err := validateModerators(v.Moderators); err != nil {
+			return nil, fmt.Errorf("channel %s: %v", v.Name, err)
+		}
+		if utf8.RuneCountInString(v.Topic) > MaxTopicLength || utf8.RuneCountInString(v.Purpose) > MaxTopicLength {
+			return nil, fmt.Errorf("channel %s has a topic or purpose longer than %d characters", v.Name, MaxTopicLength)
+		}
+		if err := validateBookmarks(v.Bookmarks); err != nil {
+			return nil, fmt.Errorf("channel %s: %v", v.Name, err)
+		}
 		if _, ok := names[v.Name]; ok {
 			return nil, fmt.Errorf("cannot overwrite channel definitions (duplicate channel name %s)", v.Name)
 		}
@@ -240,6 +260,34 @@ func mergeChannels(a []Channel, b []Channel, r Restrictions) ([]Channel, error)
 	return append(a, b...), nil
 }
 
+func validateModerators(moderators []string) error {
+	seen := map[string]struct{}{}
+	for _, m := range moderators {
+		if _, ok := seen[m]; ok {
+			return fmt.Errorf("duplicate moderator %s", m)
+		}
+		seen[m] = struct{}{}
+	}
+	return nil
+}
+
+func validateBookmarks(bookmarks []Bookmark) error {
+	titles := map[string]struct{}{}
+	for _, b := range bookmarks {
+		if b.Title == "" {
+			return fmt.Errorf("bookmarks must have titles")
+		}
+		if _, ok := titles[b.Title]; ok {
+			return fmt.Errorf("duplicate bookmark title %q", b.Title)
+		}
+		titles[b.Title] = struct{}{}
+		if u, err := url.Parse(b.Link); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("bookmark %q must link to an http or https URL, not %q", b.Title, b.Link)
+		}
+	}
+	return nil
+}
+
 func mergeUsergroups(a []Usergroup, b []Usergroup, r Restrictions) ([]Usergroup, error) {
 	names := map[string]struct{}{}
 	for _, v := range a {
@@ -262,6 +310,8 @@ func mergeUsergroups(a []Usergroup, b []Usergroup, r Restrictions) ([]Usergroup,
 			if len(v.Members) == 0 {
 				return nil, fmt.Errorf("usergroup %s must have at least one member", v.Name)
 			}
+		} else if len(v.Exclude) > 0 {
+			return nil, fmt.Errorf("usergroup %s is external, so can't exclude anyone", v.Name)
 		}
 		if _, ok := names[v.Name]; ok {
 			return nil, fmt.Errorf("cannot usergroups (duplicate usergroup %s)", v.Name)
@@ -16,6 +16,7 @@ package config
 import (
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -278,6 +279,20 @@ func TestMergeUsers(t *testing.T) {
 			restrictions: defaultRestriction,
 			expectErr:    true,
 		},
+		{
+			name:         "users can be given by email or identity source handle",
+			a:            map[string]string{},
+			b:            map[string]string{"Katharine": "katharine@example.com", "bentheelder": "github:BenTheElder"},
+			restrictions: defaultRestriction,
+			expected:     map[string]string{"Katharine": "katharine@example.com", "bentheelder": "github:BenTheElder"},
+		},
+		{
+			name:         "a name with an invalid email address is an error",
+			a:            map[string]string{},
+			b:            map[string]string{"Katharine": "Katharine <katharine@example.com>"},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
 		{
 			name:         "doing nothing is fine regardless of permissions",
 			a:            map[string]string{"Katharine": "U1234567890"},
@@ -368,6 +383,54 @@ func TestMergeChannels(t *testing.T) {
 			restrictions: defaultRestriction,
 			expectErr:    true,
 		},
+		{
+			name:         "private channels can list members",
+			b:            []Channel{{Name: "security", Private: true, Members: []string{"alice"}}},
+			restrictions: defaultRestriction,
+			expected:     []Channel{{Name: "security", Private: true, Members: []string{"alice"}}},
+		},
+		{
+			name:         "topics can't be too long",
+			b:            []Channel{{Name: "ponies", Topic: strings.Repeat("🐴", MaxTopicLength+1)}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
+		{
+			name:         "bookmarks must have titles",
+			b:            []Channel{{Name: "ponies", Bookmarks: []Bookmark{{Link: "https://ponies.dev"}}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
+		{
+			name:         "bookmark titles must be unique within a channel",
+			b:            []Channel{{Name: "ponies", Bookmarks: []Bookmark{{Title: "Ponies", Link: "https://ponies.dev"}, {Title: "Ponies", Link: "https://ponies.dev/more"}}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
+		{
+			name:         "bookmarks must link to web pages",
+			b:            []Channel{{Name: "ponies", Bookmarks: []Bookmark{{Title: "Ponies", Link: "javascript:alert(1)"}}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
+		{
+			name:         "public channels can't list members",
+			b:            []Channel{{Name: "ponies", Members: []string{"alice"}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
+		{
+			name:         "public channels can have moderators",
+			b:            []Channel{{Name: "ponies", Moderators: []string{"alice", "bob"}}},
+			restrictions: defaultRestriction,
+			expected:     []Channel{{Name: "ponies", Moderators: []string{"alice", "bob"}}},
+		},
+		{
+			name:         "moderators can't be listed twice",
+			b:            []Channel{{Name: "ponies", Moderators: []string{"alice", "alice"}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -451,6 +514,12 @@ func TestMergeUsergroups(t *testing.T) {
 			restrictions: Restrictions{Usergroups: []*regexp.Regexp{regexp.MustCompile("sig.*"), regexp.MustCompile("pony|ponies")}},
 			expected:     []Usergroup{group1, group2},
 		},
+		{
+			name:         "external usergroups can't exclude anyone",
+			b:            []Usergroup{{Name: "sig-testing", External: true, Exclude: []string{"alice"}}},
+			restrictions: defaultRestriction,
+			expectErr:    true,
+		},
 		{
 			name: "a usergroup missing a name is an error",
 			a:    nil,
@@ -533,3 +602,135 @@ func TestMergeUsergroups(t *testing.T) {
 		})
 	}
 }
+
+func TestModeratorIDs(t *testing.T) {
+	c := Config{
+		Users: map[string]string{"alice": "U00000001", "bob": "U00000002"},
+		Channels: []Channel{
+			{Name: "ponies", Moderators: []string{"alice", "bob"}},
+			{Name: "horses"},
+		},
+	}
+	ids, err := c.ModeratorIDs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string][]string{"ponies": {"U00000001", "U00000002"}}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected moderators %v, got %v", expected, ids)
+	}
+
+	c.Channels = append(c.Channels, Channel{Name: "unicorns", Moderators: []string{"alice", "carol"}})
+	if _, err := c.ModeratorIDs(); err == nil || !strings.Contains(err.Error(), "unicorns") || !strings.Contains(err.Error(), "carol") {
+		t.Errorf("Expected an error about carol moderating unicorns, got %v", err)
+	}
+}
+
+func TestUsergroupMemberIDs(t *testing.T) {
+	users := map[string]string{"alice": "U00000001", "bob": "U00000002", "carol": "U00000003", "bobby": "U00000002"}
+	group := func(name string, members, exclude []string) Usergroup {
+		return Usergroup{Name: name, LongName: name, Description: name, Members: members, Exclude: exclude}
+	}
+	tests := []struct {
+		name      string
+		groups    []Usergroup
+		expected  []string
+		expectErr string
+	}{
+		{
+			name:     "plain members",
+			groups:   []Usergroup{group("leads", []string{"bob", "alice"}, nil)},
+			expected: []string{"U00000001", "U00000002"},
+		},
+		{
+			name:     "duplicate IDs are only listed once",
+			groups:   []Usergroup{group("leads", []string{"bob", "bobby"}, nil)},
+			expected: []string{"U00000002"},
+		},
+		{
+			name: "nested groups, with exclusions",
+			groups: []Usergroup{
+				group("leads", []string{"@node-leads", "@api-leads"}, []string{"@emeritus"}),
+				group("node-leads", []string{"alice", "bob"}, nil),
+				group("api-leads", []string{"carol"}, []string{"alice"}),
+				group("emeritus", []string{"bob"}, nil),
+			},
+			expected: []string{"U00000001", "U00000003"},
+		},
+		{
+			name:      "unknown groups",
+			groups:    []Usergroup{group("leads", []string{"@node-leads"}, nil)},
+			expectErr: "unknown usergroup @node-leads",
+		},
+		{
+			name:      "external groups",
+			groups:    []Usergroup{group("leads", []string{"@node-leads"}, nil), {Name: "node-leads", External: true}},
+			expectErr: "external",
+		},
+		{
+			name: "cycles",
+			groups: []Usergroup{
+				group("leads", []string{"@node-leads"}, nil),
+				group("node-leads", []string{"alice"}, []string{"@sig-node"}),
+				group("sig-node", []string{"@leads"}, nil),
+			},
+			expectErr: "@leads -> @node-leads -> @sig-node -> @leads",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Config{Users: users, Usergroups: tc.groups}
+			ids, err := c.UsergroupMemberIDs("leads")
+			validateErr := c.Validate()
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tc.expectErr, err)
+				}
+				if validateErr == nil {
+					t.Errorf("Expected validation to fail too")
+				}
+				return
+			}
+			if err != nil || validateErr != nil {
+				t.Fatalf("Unexpected errors: %v, %v", err, validateErr)
+			}
+			if !reflect.DeepEqual(ids, tc.expected) {
+				t.Errorf("Expected members %v, got %v", tc.expected, ids)
+			}
+		})
+	}
+}
+
+func TestParseUserRef(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  UserRef
+		expectErr bool
+	}{
+		{value: "U12345678", expected: UserRef{ID: "U12345678"}},
+		{value: "W1234567890", expected: UserRef{ID: "W1234567890"}},
+		{value: "katharine@example.com", expected: UserRef{Email: "katharine@example.com"}},
+		{value: "github:Katharine", expected: UserRef{Source: "github", Handle: "Katharine"}},
+		{value: "wat", expectErr: true},
+		{value: "not@an@email", expectErr: true},
+		{value: "github:", expectErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			ref, err := ParseUserRef(tc.value)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if ref != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, ref)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"sigs.k8s.io/yaml"
+)
+
+// JSONSchema is the subset of JSON Schema (draft-07) needed to describe Tempelis config files.
+type JSONSchema struct {
+	SchemaURI   string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Const       interface{}            `json:"const,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	MinLength   int                    `json:"minLength,omitempty"`
+	MaxLength   int                    `json:"maxLength,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	MinItems    int                    `json:"minItems,omitempty"`
+	UniqueItems bool                   `json:"uniqueItems,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	// AdditionalProperties is either false, or a *JSONSchema that the values of a map must match.
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	If                   *JSONSchema            `json:"if,omitempty"`
+	Then                 *JSONSchema            `json:"then,omitempty"`
+	Else                 *JSONSchema            `json:"else,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+const (
+	// channelNamePattern is Slack's rule for channel names: lowercase letters, in any script,
+	// numbers, hyphens and underscores. They can also be no longer than MaxChannelNameLength.
+	channelNamePattern = `^[\p{Ll}\p{Lm}\p{Lo}\p{M}\p{N}_-]+$`
+	channelIDPattern   = `^[CG][A-Z0-9]+$`
+	// usergroupHandlePattern is Slack's rule for usergroup handles, which may also have periods.
+	usergroupHandlePattern = `^[a-z0-9._-]+$`
+	// userRefPattern matches everything ParseUserRef accepts: a Slack ID, an email address or an
+	// identity source handle.
+	userRefPattern      = `^([^:@\s]{9}|[^:@\s]{11}|[^@\s]+@[^@\s]+|[a-z][a-z0-9-]*:[^:@\s]+)$`
+	bookmarkLinkPattern = `^https?://[^/\s]+`
+)
+
+// schemaPatterns are the compiled patterns used in the schema.
+var schemaPatterns = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, p := range []string{channelNamePattern, channelIDPattern, usergroupHandlePattern, userRefPattern, bookmarkLinkPattern} {
+		schemaPatterns[p] = regexp.MustCompile(p)
+	}
+}
+
+// typeSchemas describe the config types, and are merged into the schemas generated from them.
+var typeSchemas = map[string]JSONSchema{
+	"Config":          {Title: "Tempelis config", Description: "Slack channels, usergroups and users to keep Slack in line with. Config can be split across several files."},
+	"Channel":         {Description: "A channel. Every public channel must be listed.", Required: []string{"name"}},
+	"Bookmark":        {Description: "A link bookmarked in a channel.", Required: []string{"title", "link"}},
+	"ChannelTemplate": {Description: "Defaults for channels: pins for new channels, and the topic and purpose of channels without their own. It can only be defined once."},
+	"Restrictions":    {Description: "What config files matching a path may define.", Required: []string{"path"}},
+	"Usergroup": {
+		Description: "A usergroup. Every usergroup must be listed, or it is deactivated.",
+		Required:    []string{"name"},
+		If:          &JSONSchema{Properties: map[string]*JSONSchema{"external": {Const: true}}, Required: []string{"external"}},
+		Else:        &JSONSchema{Required: []string{"long_name", "description", "members"}},
+	},
+}
+
+// fieldSchemas describe the fields of the config types, keyed by type and JSON name, and are
+// merged into the schemas generated from them.
+var fieldSchemas = map[string]JSONSchema{
+	"Config.users":        {Description: "Maps the names used in the rest of the config to Slack users, each given as a Slack ID, an email address, or an identity source handle such as github:katharine.", AdditionalProperties: &JSONSchema{Pattern: userRefPattern}},
+	"Config.channels":     {Description: "Channels to manage."},
+	"Config.usergroups":   {Description: "Usergroups to manage."},
+	"Config.restrictions": {Description: "Restrictions on what other config files can define, searched in order."},
+
+	"Restrictions.path":       {Description: "A glob matching the config files these restrictions apply to.", MinLength: 1},
+	"Restrictions.users":      {Description: "Whether matching files can define users."},
+	"Restrictions.channels":   {Description: "Regular expressions matching the channels that matching files can define.", Items: &JSONSchema{Format: "regex"}},
+	"Restrictions.usergroups": {Description: "Regular expressions matching the usergroups that matching files can define.", Items: &JSONSchema{Format: "regex"}},
+	"Restrictions.template":   {Description: "Whether matching files can define the channel template."},
+
+	"Channel.name":           {Description: "The channel's name: lowercase letters in any script, numbers, hyphens and underscores.", Pattern: channelNamePattern, MaxLength: MaxChannelNameLength},
+	"Channel.id":             {Description: "The channel's Slack ID, which is needed to rename it.", Pattern: channelIDPattern},
+	"Channel.archived":       {Description: "Whether the channel is archived."},
+	"Channel.private":        {Description: "Whether the channel is private."},
+	"Channel.members":        {Description: "Users to invite to a private channel.", UniqueItems: true},
+	"Channel.moderators":     {Description: "Users who can moderate the channel.", UniqueItems: true},
+	"Channel.topic":          {Description: "The channel's topic.", MaxLength: MaxTopicLength},
+	"Channel.purpose":        {Description: "The channel's purpose.", MaxLength: MaxTopicLength},
+	"Channel.ignore_topic":   {Description: "Only set the topic, or the channel template's, when creating the channel."},
+	"Channel.ignore_purpose": {Description: "Only set the purpose, or the channel template's, when creating the channel."},
+	"Channel.pins":           {Description: "Messages to keep pinned. An empty list removes them all."},
+	"Channel.bookmarks":      {Description: "Links to keep bookmarked. An empty list removes them all."},
+
+	"Bookmark.title": {Description: "The bookmark's title, which is unique within the channel.", MinLength: 1},
+	"Bookmark.link":  {Description: "An http or https URL.", Pattern: bookmarkLinkPattern, Format: "uri"},
+	"Bookmark.emoji": {Description: "An emoji to show next to the bookmark, such as memo."},
+
+	"Usergroup.name":        {Description: "The usergroup's pingable handle: lowercase letters, numbers, hyphens, periods and underscores.", Pattern: usergroupHandlePattern},
+	"Usergroup.long_name":   {Description: "The usergroup's human-readable name.", MinLength: 1},
+	"Usergroup.members":     {Description: "Users, or other usergroups written as @handle, whose members are all included.", MinItems: 1},
+	"Usergroup.exclude":     {Description: "Users, or other usergroups written as @handle, to leave out."},
+	"Usergroup.channels":    {Description: "Channels that members automatically join."},
+	"Usergroup.description": {Description: "A description of the usergroup.", MinLength: 1},
+	"Usergroup.external":    {Description: "The usergroup is managed by something else, so Tempelis leaves it alone."},
+
+	"ChannelTemplate.pins":    {Description: "Messages to pin in new channels."},
+	"ChannelTemplate.topic":   {Description: "The topic of channels without one of their own.", MaxLength: MaxTopicLength},
+	"ChannelTemplate.purpose": {Description: "The purpose of channels without one of their own.", MaxLength: MaxTopicLength},
+}
+
+// Schema returns a JSON Schema describing Tempelis config files, generated from the Config type.
+func Schema() *JSONSchema {
+	definitions := map[string]*JSONSchema{}
+	root := structSchema(reflect.TypeOf(Config{}), definitions)
+	root.SchemaURI = "http://json-schema.org/draft-07/schema#"
+	root.Definitions = definitions
+	return root
+}
+
+func typeSchema(t reflect.Type, definitions map[string]*JSONSchema) *JSONSchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), definitions)
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem(), definitions)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), definitions)}
+	case reflect.Struct:
+		if _, ok := definitions[t.Name()]; !ok {
+			definitions[t.Name()] = nil
+			definitions[t.Name()] = structSchema(t, definitions)
+		}
+		return &JSONSchema{Ref: "#/definitions/" + t.Name()}
+	}
+	panic(fmt.Sprintf("config: can't describe %s in a schema", t))
+}
+
+// structSchema describes the fields of a struct that have JSON names.
+func structSchema(t reflect.Type, definitions map[string]*JSONSchema) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		property := typeSchema(f.Type, definitions)
+		mergeSchema(property, fieldSchemas[t.Name()+"."+name])
+		s.Properties[name] = property
+	}
+	mergeSchema(s, typeSchemas[t.Name()])
+	return s
+}
+
+// mergeSchema copies the parts of extra that are set into s.
+func mergeSchema(s *JSONSchema, extra JSONSchema) {
+	if extra.Title != "" {
+		s.Title = extra.Title
+	}
+	if extra.Description != "" {
+		s.Description = extra.Description
+	}
+	if extra.Pattern != "" {
+		s.Pattern = extra.Pattern
+	}
+	if extra.Format != "" {
+		s.Format = extra.Format
+	}
+	if extra.MinLength != 0 {
+		s.MinLength = extra.MinLength
+	}
+	if extra.MaxLength != 0 {
+		s.MaxLength = extra.MaxLength
+	}
+	if extra.MinItems != 0 {
+		s.MinItems = extra.MinItems
+	}
+	if extra.UniqueItems {
+		s.UniqueItems = true
+	}
+	if extra.Required != nil {
+		s.Required = extra.Required
+	}
+	if extra.If != nil {
+		s.If, s.Then, s.Else = extra.If, extra.Then, extra.Else
+	}
+	if extra.Items != nil && s.Items != nil {
+		mergeSchema(s.Items, *extra.Items)
+	}
+	if a, ok := extra.AdditionalProperties.(*JSONSchema); ok {
+		if b, ok := s.AdditionalProperties.(*JSONSchema); ok {
+			mergeSchema(b, *a)
+		}
+	}
+}
+
+// ValidateSchema checks the content of a yaml config file against the Schema, returning every
+// problem it finds.
+func ValidateSchema(content []byte) []error {
+	j, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return []error{fmt.Errorf("failed to parse yaml: %v", err)}
+	}
+	var v interface{}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return []error{fmt.Errorf("failed to parse yaml: %v", err)}
+	}
+	if v == nil {
+		return nil
+	}
+	root := Schema()
+	var errs []error
+	root.validate(root, "", v, &errs)
+	return errs
+}
+
+// validate checks v, which was decoded from JSON, against s, appending any problems to errs.
+func (s *JSONSchema) validate(root *JSONSchema, path string, v interface{}, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		at := path
+		if at == "" {
+			at = "config"
+		}
+		*errs = append(*errs, fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...)))
+	}
+	if s.Ref != "" {
+		root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")].validate(root, path, v, errs)
+		return
+	}
+	if s.Const != nil && !reflect.DeepEqual(s.Const, v) {
+		fail("must be %v", s.Const)
+		return
+	}
+	switch s.Type {
+	case "object":
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		s.validateObject(root, path, o, errs, fail)
+	case "array":
+		a, ok := v.([]interface{})
+		if !ok {
+			fail("must be a list")
+			return
+		}
+		if len(a) < s.MinItems {
+			if s.MinItems == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must have at least %d items", s.MinItems)
+			}
+		}
+		seen := map[string]bool{}
+		for i, item := range a {
+			if s.UniqueItems {
+				key := fmt.Sprint(item)
+				if seen[key] {
+					fail("must not list %v more than once", item)
+				}
+				seen[key] = true
+			}
+			if s.Items != nil {
+				s.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if n := utf8.RuneCountInString(str); n < s.MinLength {
+			fail("must not be empty")
+		} else if s.MaxLength > 0 && n > s.MaxLength {
+			fail("must be at most %d characters long", s.MaxLength)
+		}
+		if s.Pattern != "" && !schemaPatterns[s.Pattern].MatchString(str) {
+			fail("%q must match %s", str, s.Pattern)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("must be true or false")
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			fail("must be a whole number")
+		}
+	case "":
+		if o, ok := v.(map[string]interface{}); ok {
+			s.validateObject(root, path, o, errs, fail)
+		}
+	}
+	if s.If != nil {
+		// Only whether the condition holds matters, not why it doesn't.
+		var conditionErrs []error
+		s.If.validate(root, path, v, &conditionErrs)
+		if len(conditionErrs) == 0 && s.Then != nil {
+			s.Then.validate(root, path, v, errs)
+		} else if len(conditionErrs) > 0 && s.Else != nil {
+			s.Else.validate(root, path, v, errs)
+		}
+	}
+}
+
+func (s *JSONSchema) validateObject(root *JSONSchema, path string, o map[string]interface{}, errs *[]error, fail func(string, ...interface{})) {
+	for _, r := range s.Required {
+		if _, ok := o[r]; !ok {
+			fail("must have %s", r)
+		}
+	}
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		at := k
+		if path != "" {
+			at = path + "." + k
+		}
+		if p, ok := s.Properties[k]; ok {
+			p.validate(root, at, o[k], errs)
+			continue
+		}
+		switch a := s.AdditionalProperties.(type) {
+		case *JSONSchema:
+			a.validate(root, at, o[k], errs)
+		case bool:
+			if !a {
+				fail("unknown field %s", k)
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaIsUpToDate(t *testing.T) {
+	b, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to generate schema: %v", err)
+	}
+	existing, err := os.ReadFile("schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read schema.json: %v", err)
+	}
+	if !bytes.Equal(append(b, '\n'), existing) {
+		t.Errorf("schema.json is out of date; run go run ./tempelis schema > tempelis/config/schema.json")
+	}
+}
+
+func TestSchemaDescribesEveryField(t *testing.T) {
+	s := Schema()
+	schemas := map[string]*JSONSchema{"Config": s}
+	for name, d := range s.Definitions {
+		schemas[name] = d
+	}
+	for name, d := range schemas {
+		if d.Description == "" {
+			t.Errorf("%s has no description", name)
+		}
+		for field, p := range d.Properties {
+			if p.Ref == "" && p.Description == "" {
+				t.Errorf("%s.%s has no description", name, field)
+			}
+		}
+	}
+}
+
+func TestSchemaPatternsAreCompiled(t *testing.T) {
+	var check func(path string, s *JSONSchema)
+	check = func(path string, s *JSONSchema) {
+		if s == nil {
+			return
+		}
+		if s.Pattern != "" && schemaPatterns[s.Pattern] == nil {
+			t.Errorf("%s has pattern %s, which isn't in schemaPatterns", path, s.Pattern)
+		}
+		for name, p := range s.Properties {
+			check(path+"."+name, p)
+		}
+		check(path+"[]", s.Items)
+		if a, ok := s.AdditionalProperties.(*JSONSchema); ok {
+			check(path+".*", a)
+		}
+	}
+	s := Schema()
+	check("Config", s)
+	for name, d := range s.Definitions {
+		check(name, d)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errors []string
+	}{
+		{
+			name:   "empty file",
+			config: "",
+		},
+		{
+			name: "valid config",
+			config: `
+users:
+  katharine: U12345678
+  someone: someone@example.com
+  other: github:other
+channels:
+  - name: sig-node
+    topic: Nodes
+    moderators: [katharine]
+    bookmarks:
+      - title: Docs
+        link: https://kubernetes.io
+  - name: día-de-muertos
+  - name: 日本語
+  - name: private_thing
+    id: C12345678
+    private: true
+    members: [katharine, someone]
+usergroups:
+  - name: test-infra.oncall
+    long_name: Test Infra oncall
+    description: The oncall
+    members: [katharine, "@other-group"]
+    exclude: [someone]
+  - name: managed-elsewhere
+    external: true
+channel_template:
+  pins: [Welcome!]
+restrictions:
+  - path: sigs/*.yaml
+    channels: ["^sig-"]
+`,
+		},
+		{
+			name: "invalid channel names",
+			config: `
+channels:
+  - name: Sig-Node
+  - name: sig node
+  - name: ` + strings.Repeat("a", MaxChannelNameLength+1),
+			errors: []string{
+				`channels[0].name: "Sig-Node" must match ^[\p{Ll}\p{Lm}\p{Lo}\p{M}\p{N}_-]+$`,
+				`channels[1].name: "sig node" must match ^[\p{Ll}\p{Lm}\p{Lo}\p{M}\p{N}_-]+$`,
+				"channels[2].name: must be at most 80 characters long",
+			},
+		},
+		{
+			name: "channel without a name",
+			config: `
+channels:
+  - topic: Nameless`,
+			errors: []string{"channels[0]: must have name"},
+		},
+		{
+			name: "topic too long",
+			config: `
+channels:
+  - name: ponies
+    topic: ` + strings.Repeat("🐴", MaxTopicLength+1),
+			errors: []string{"channels[0].topic: must be at most 250 characters long"},
+		},
+		{
+			name: "unknown fields",
+			config: `
+channel:
+  - name: typo
+channels:
+  - name: ponies
+    moderator: [katharine]`,
+			errors: []string{"config: unknown field channel", "channels[0]: unknown field moderator"},
+		},
+		{
+			name: "wrong types",
+			config: `
+channels:
+  - name: ponies
+    private: "yes"
+    moderators: katharine`,
+			errors: []string{"channels[0].moderators: must be a list", "channels[0].private: must be true or false"},
+		},
+		{
+			name: "duplicate moderators",
+			config: `
+channels:
+  - name: ponies
+    moderators: [katharine, katharine]`,
+			errors: []string{"channels[0].moderators: must not list katharine more than once"},
+		},
+		{
+			name: "bad bookmark",
+			config: `
+channels:
+  - name: ponies
+    bookmarks:
+      - title: Ponies
+        link: ftp://example.com
+      - link: https://example.com`,
+			errors: []string{
+				`channels[0].bookmarks[0].link: "ftp://example.com" must match ^https?://[^/\s]+`,
+				"channels[0].bookmarks[1]: must have title",
+			},
+		},
+		{
+			name: "bad user references",
+			config: `
+users:
+  katharine: kat
+  other: "github:"`,
+			errors: []string{
+				`users.katharine: "kat" must match ^([^:@\s]{9}|[^:@\s]{11}|[^@\s]+@[^@\s]+|[a-z][a-z0-9-]*:[^:@\s]+)$`,
+				`users.other: "github:" must match ^([^:@\s]{9}|[^:@\s]{11}|[^@\s]+@[^@\s]+|[a-z][a-z0-9-]*:[^:@\s]+)$`,
+			},
+		},
+		{
+			name: "incomplete usergroup",
+			config: `
+usergroups:
+  - name: Oncall
+    members: []`,
+			errors: []string{
+				"usergroups[0].members: must not be empty",
+				`usergroups[0].name: "Oncall" must match ^[a-z0-9._-]+$`,
+				"usergroups[0]: must have long_name",
+				"usergroups[0]: must have description",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []string
+			for _, err := range ValidateSchema([]byte(tc.config)) {
+				errs = append(errs, err.Error())
+			}
+			if strings.Join(errs, "\n") != strings.Join(tc.errors, "\n") {
+				t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(tc.errors, "\n"), strings.Join(errs, "\n"))
+			}
+		})
+	}
+}
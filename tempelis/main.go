@@ -17,22 +17,28 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 	"path"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/moderators"
 	"sigs.k8s.io/slack-infra/tempelis/config"
 	"sigs.k8s.io/slack-infra/tempelis/reconciler"
+	"sigs.k8s.io/slack-infra/tempelis/users"
 )
 
 type options struct {
-	dryRun       bool
-	validateOnly bool
-	config       string
-	restrictions string
-	authConfig   string
+	dryRun           bool
+	validateOnly     bool
+	config           string
+	restrictions     string
+	authConfig       string
+	moderatorsOutput string
+	channelManagers  bool
+	identities       string
 }
 
 func parseOptions() options {
@@ -42,13 +48,27 @@ func parseOptions() options {
 	flag.StringVar(&o.config, "config", "", "path to a configuration file, or directory of files")
 	flag.StringVar(&o.restrictions, "restrictions", "", "path to a configuration file containing restrictions")
 	flag.StringVar(&o.authConfig, "auth", "", "path to slack auth")
+	flag.StringVar(&o.moderatorsOutput, "moderators-output", "", "path to write channel moderators to, for the moderator bot")
+	flag.BoolVar(&o.channelManagers, "channel-managers", false, "make channel moderators channel managers (requires Enterprise Grid)")
+	flag.StringVar(&o.identities, "identities", "", "comma-separated identity sources for the users map, as name=path to a yaml file mapping handles to email addresses")
 	flag.Parse()
 	return o
 }
 
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "users" && os.Args[2] == "resolve" {
+		os.Exit(resolveUsers(os.Args[3:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(printSchema())
+	}
 	o := parseOptions()
 
+	// Check against the schema first, which reports every problem at once, in every file.
+	if o.validateOnly && !checkSchema(o.restrictions, o.config) {
+		log.Fatalln("Configuration does not match the schema.")
+	}
+
 	stat, err := os.Stat(o.config)
 	if err != nil {
 		log.Fatalf("Failed to stat %s: %v\n", o.config, err)
@@ -70,19 +90,54 @@ func main() {
 		log.Fatalf("Failed to load config: %v\n", err)
 	}
 
+	if err := p.Config.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v\n", err)
+	}
+
 	// If validate-only mode, just validate the config and exit
 	if o.validateOnly {
 		log.Println("Configuration validation successful!")
 		return
 	}
 
-	sc, err := slack.LoadConfig(o.authConfig)
+	sc, err := slack.ConfigLoader{Path: o.authConfig, EnvPrefix: "SLACK_"}.Load()
 	if err != nil {
 		log.Fatalf("Failed to load slack auth config: %v.\n", err)
 	}
 
-	r := reconciler.New(slack.New(sc), p.Config)
-	if err := r.Reconcile(o.dryRun); err != nil {
+	sources, err := loadIdentities(o.identities)
+	if err != nil {
+		log.Fatalf("Failed to load identity sources: %v\n", err)
+	}
+	client := slack.New(sc)
+	resolver := users.Resolver{Slack: client, Sources: sources}
+	results, err := resolver.Resolve(context.Background(), p.Config.Users)
+	if err != nil {
+		log.Fatalf("Failed to resolve users: %v\n", err)
+	}
+	unresolved := map[string]bool{}
+	for _, r := range results {
+		if r.Problem != "" {
+			log.Printf("Warning: leaving user %s (%s) out of every channel and usergroup: %s.\n", r.Name, r.Value, r.Problem)
+			unresolved[r.Name] = true
+		}
+	}
+	p.Config.Users = users.IDs(results)
+
+	r := reconciler.New(client, p.Config)
+	r.ChannelManagers = o.channelManagers
+	r.UnresolvedUsers = unresolved
+	if err := r.Reconcile(context.Background(), o.dryRun); err != nil {
 		log.Fatalf("Reconciliation failed: %v\n", err)
 	}
+
+	if o.moderatorsOutput != "" {
+		list, err := r.Moderators()
+		if err != nil {
+			log.Fatalf("Failed to get channel moderators: %v\n", err)
+		}
+		if err := moderators.Save(o.moderatorsOutput, list); err != nil {
+			log.Fatalf("Failed to write channel moderators: %v\n", err)
+		}
+	}
 }
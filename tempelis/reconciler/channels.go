@@ -17,11 +17,33 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/tempelis/config"
 )
 
+// channelTopic returns the topic c should have: its own, or else the channel template's.
+func (r *Reconciler) channelTopic(c config.Channel) string {
+	if c.Topic != "" {
+		return c.Topic
+	}
+	return r.config.ChannelTemplate.Topic
+}
+
+// channelPurpose returns the purpose c should have: its own, or else the channel template's.
+func (r *Reconciler) channelPurpose(c config.Channel) string {
+	if c.Purpose != "" {
+		return c.Purpose
+	}
+	return r.config.ChannelTemplate.Purpose
+}
+
 func (r *Reconciler) reconcileChannels() ([]Action, []error) {
 	missingChannels := map[string]*slack.Conversation{}
 
@@ -33,6 +55,10 @@ func (r *Reconciler) reconcileChannels() ([]Action, []error) {
 	}
 
 	for _, c := range r.config.Channels {
+		moderators, moderatorsErr := r.userIDs(c.Moderators)
+		if moderatorsErr != nil {
+			errors = append(errors, fmt.Errorf("%s moderators: %v", c.Name, moderatorsErr))
+		}
 		if c.ID != "" {
 			if o, ok := r.channels.byID[c.ID]; ok {
 				if o.Name != c.Name {
@@ -54,74 +80,257 @@ func (r *Reconciler) reconcileChannels() ([]Action, []error) {
 			} else if !c.Archived && o.IsArchived {
 				actions = append(actions, unarchiveChannelAction{id: o.ID, name: o.Name})
 			}
+			if !c.Archived && c.Private != o.IsPrivate {
+				actions = append(actions, convertChannelAction{id: o.ID, name: o.Name, private: c.Private})
+			}
+			if !c.Archived && len(c.Members) > 0 {
+				targetIDs, err := r.userIDs(c.Members)
+				if err != nil {
+					errors = append(errors, fmt.Errorf("%s: %v", c.Name, err))
+				} else if missing := missingMembers(targetIDs, r.channels.members[o.ID]); len(missing) > 0 {
+					actions = append(actions, inviteChannelMembersAction{id: o.ID, name: o.Name, users: missing})
+				}
+			}
+			if topic := r.channelTopic(c); !c.Archived && topic != "" && !c.IgnoreTopic && !sameText(topic, o.Topic.Topic) {
+				actions = append(actions, setChannelTopicAction{id: o.ID, name: o.Name, topic: topic})
+			}
+			if purpose := r.channelPurpose(c); !c.Archived && purpose != "" && !c.IgnorePurpose && !sameText(purpose, o.Purpose.Purpose) {
+				actions = append(actions, setChannelPurposeAction{id: o.ID, name: o.Name, purpose: purpose})
+			}
+			if !c.Archived && c.Pins != nil {
+				actions = append(actions, r.reconcilePins(o, c.Pins)...)
+			}
+			if !c.Archived && c.Bookmarks != nil {
+				actions = append(actions, r.reconcileBookmarks(o, c.Bookmarks)...)
+			}
+			if !c.Archived && r.ChannelManagers && c.Moderators != nil && moderatorsErr == nil {
+				actions = append(actions, r.reconcileManagers(o, moderators)...)
+			}
 			delete(missingChannels, o.Name)
 		} else {
 			if c.Archived {
 				errors = append(errors, fmt.Errorf("channel %s is new but already marked as archived, which is not permitted", c.Name))
-			} else {
-				actions = append(actions, createChannelAction{name: c.Name})
+				continue
+			}
+			var members []string
+			if len(c.Members) > 0 {
+				var err error
+				if members, err = r.userIDs(c.Members); err != nil {
+					errors = append(errors, fmt.Errorf("%s: %v", c.Name, err))
+					continue
+				}
 			}
+			a := createChannelAction{name: c.Name, private: c.Private, members: members, topic: r.channelTopic(c), purpose: r.channelPurpose(c), pins: c.Pins, managedPins: c.Pins != nil, bookmarks: c.Bookmarks}
+			if !a.managedPins {
+				a.pins = r.config.ChannelTemplate.Pins
+			}
+			if r.ChannelManagers {
+				a.managers = moderators
+			}
+			actions = append(actions, a)
 		}
 	}
 
 	for _, o := range missingChannels {
+		// We can only see the private channels we're in, and not all of those need be ours.
+		if o.IsPrivate {
+			continue
+		}
 		errors = append(errors, fmt.Errorf("channel %s (%s) not referenced in config", o.Name, o.ID))
 	}
 
 	return actions, errors
 }
 
+// topicUnescaper undoes the escaping Slack applies to stored topics and purposes.
+var topicUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// sameText returns true if the configured topic or purpose want matches got, as stored by Slack.
+func sameText(want, got string) bool {
+	return want == got || want == topicUnescaper.Replace(got)
+}
+
+// linkedURL matches URLs that Slack has turned into links in a message it stored.
+var linkedURL = regexp.MustCompile(`<((?:https?|mailto):[^|>]*)>`)
+
+// samePin returns true if the configured pin want matches the text of the message got.
+func samePin(want, got string) bool {
+	return sameText(want, got) || sameText(want, linkedURL.ReplaceAllString(got, "$1"))
+}
+
+// reconcilePins returns the actions needed to make the messages we have pinned in o match pins,
+// reusing pins that need changing rather than adding new ones. Other users' pins are left alone.
+func (r *Reconciler) reconcilePins(o *slack.Conversation, pins []string) []Action {
+	var ours []*slack.Message
+	for _, p := range r.channels.pins[o.ID] {
+		if p.Message != nil && p.Message.User == r.self {
+			ours = append(ours, p.Message)
+		}
+	}
+	var unmatched []string
+	for _, want := range pins {
+		found := false
+		for i, m := range ours {
+			if samePin(want, m.Text) {
+				ours = append(ours[:i], ours[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, want)
+		}
+	}
+
+	var actions []Action
+	for i, text := range unmatched {
+		if i < len(ours) {
+			actions = append(actions, updatePinAction{id: o.ID, name: o.Name, ts: ours[i].TS, text: text})
+		} else {
+			actions = append(actions, addPinAction{id: o.ID, name: o.Name, text: text})
+		}
+	}
+	for i := len(unmatched); i < len(ours); i++ {
+		actions = append(actions, removePinAction{id: o.ID, name: o.Name, ts: ours[i].TS, text: ours[i].Text})
+	}
+	return actions
+}
+
+// reconcileBookmarks returns the actions needed to make the bookmarks in o match bookmarks.
+// Bookmarks are matched up by title.
+func (r *Reconciler) reconcileBookmarks(o *slack.Conversation, bookmarks []config.Bookmark) []Action {
+	existing := r.channels.bookmarks[o.ID]
+	used := make([]bool, len(existing))
+	var actions []Action
+	for _, b := range bookmarks {
+		found := false
+		for i, e := range existing {
+			if used[i] || e.Title != b.Title {
+				continue
+			}
+			used[i] = true
+			found = true
+			if e.Link != b.Link || strings.Trim(e.Emoji, ":") != strings.Trim(b.Emoji, ":") {
+				actions = append(actions, editBookmarkAction{id: o.ID, name: o.Name, bookmarkID: e.ID, bookmark: b})
+			}
+			break
+		}
+		if !found {
+			actions = append(actions, addBookmarkAction{id: o.ID, name: o.Name, bookmark: b})
+		}
+	}
+	for i, e := range existing {
+		if !used[i] {
+			actions = append(actions, removeBookmarkAction{id: o.ID, name: o.Name, bookmarkID: e.ID, title: e.Title})
+		}
+	}
+	return actions
+}
+
+// emojiCode returns the emoji name e in the :name: form Slack expects, or "" if e is empty.
+func emojiCode(e string) string {
+	if e = strings.Trim(e, ":"); e == "" {
+		return ""
+	}
+	return ":" + e + ":"
+}
+
+// reconcileManagers makes the channel managers of o exactly the given users.
+func (r *Reconciler) reconcileManagers(o *slack.Conversation, users []string) []Action {
+	var actions []Action
+	current := r.channels.managers[o.ID]
+	if add := missingMembers(users, current); len(add) > 0 {
+		actions = append(actions, addChannelManagersAction{id: o.ID, name: o.Name, users: add})
+	}
+	if remove := missingMembers(current, users); len(remove) > 0 {
+		actions = append(actions, removeChannelManagersAction{id: o.ID, name: o.Name, users: remove})
+	}
+	return actions
+}
+
+// missingMembers returns the users in target that aren't in current.
+func missingMembers(target, current []string) []string {
+	have := map[string]bool{}
+	for _, u := range current {
+		have[u] = true
+	}
+	var missing []string
+	for _, u := range target {
+		if !have[u] {
+			missing = append(missing, u)
+		}
+	}
+	return missing
+}
+
 type createChannelAction struct {
-	name string
+	name    string
+	private bool
+	members []string
+	topic   string
+	purpose string
+	pins    []string
+	// managedPins is set if the pins are the channel's own, rather than the template's. Those are
+	// kept up to date later, so are posted exactly as written rather than with names linked.
+	managedPins bool
+	bookmarks   []config.Bookmark
+	managers    []string
 }
 
 func (a createChannelAction) Describe() string {
+	if a.private {
+		return fmt.Sprintf("Create new private channel: %s, with members %v", a.name, a.members)
+	}
 	return fmt.Sprintf("Create new channel: %s", a.name)
 }
 
-func (a createChannelAction) Perform(reconciler *Reconciler) error {
-	ret := struct {
-		Channel slack.Conversation `json:"channel"`
-	}{}
-	if err := reconciler.slack.CallMethod("conversations.create", map[string]string{"name": a.name}, &ret); err != nil {
+func (a createChannelAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	// None of the errors are wrapped, so that the action isn't retried: Slack may have created the
+	// channel even if the call failed, and retrying would try to create it again, or post its pins
+	// twice.
+	c, err := reconciler.slack.CreateConversation(ctx, a.name, a.private)
+	if errors.Is(err, slack.ErrNameTaken) {
+		return fmt.Errorf("failed to create channel %s: the name is taken, perhaps by an archived channel or a private channel we aren't in: %v", a.name, err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to create channel: %v", err)
 	}
-	c := ret.Channel
 	reconciler.channels.byName[c.Name] = &c
-	reconciler.channels.byID[c.Name] = &c
-	t := &reconciler.config.ChannelTemplate
-	if t.Topic != "" {
-		if err := reconciler.slack.CallMethod("conversations.setTopic", map[string]string{"channel": c.ID, "topic": t.Topic}, nil); err != nil {
-			return fmt.Errorf("failed to set topic of channel %s to %q: %v", c.Name, t.Topic, err)
-		}
-	}
-	if t.Purpose != "" {
-		if err := reconciler.slack.CallMethod("conversations.setPurpose", map[string]interface{}{"channel": c.ID, "purpose": t.Purpose}, nil); err != nil {
-			return fmt.Errorf("failed to set purpose of channel %s to %q: %v", c.Name, c.Topic, err)
-		}
-	}
-	for _, p := range t.Pins {
-		message := struct {
-			Channel   string `json:"channel"`
-			Text      string `json:"text"`
-			AsUser    bool   `json:"as_user"`
-			LinkNames bool   `json:"link_names"`
-		}{
-			Channel:   c.ID,
-			Text:      p,
-			AsUser:    false,
-			LinkNames: true,
-		}
-		r := struct {
-			TS string `json:"ts"`
-		}{}
-		if err := reconciler.slack.CallMethod("chat.postMessage", message, &r); err != nil {
+	reconciler.channels.byID[c.ID] = &c
+	if a.topic != "" {
+		if err := reconciler.slack.SetTopic(ctx, c.ID, a.topic); err != nil {
+			return fmt.Errorf("failed to set topic of channel %s to %q: %v", c.Name, a.topic, err)
+		}
+	}
+	if a.purpose != "" {
+		if err := reconciler.slack.SetPurpose(ctx, c.ID, a.purpose); err != nil {
+			return fmt.Errorf("failed to set purpose of channel %s to %q: %v", c.Name, a.purpose, err)
+		}
+	}
+	for _, p := range a.pins {
+		r, err := reconciler.slack.PostMessage(ctx, slack.PostMessageRequest{Channel: c.ID, Text: p, LinkNames: !a.managedPins})
+		if err != nil {
 			return fmt.Errorf("failed to send message: %v", err)
 		}
-		if err := reconciler.slack.CallMethod("pins.add", map[string]string{"channel": c.ID, "timestamp": r.TS}, nil); err != nil {
+		if err := reconciler.slack.PinMessage(ctx, c.ID, r.TS); err != nil {
 			return fmt.Errorf("failed to pin message %s in %s: %v", r.TS, c.Name, err)
 		}
 	}
+	if len(a.members) > 0 {
+		if err := reconciler.slack.InviteToConversation(ctx, c.ID, a.members); err != nil {
+			return fmt.Errorf("failed to invite members to %s: %v", c.Name, err)
+		}
+	}
+	for _, b := range a.bookmarks {
+		if _, err := reconciler.slack.AddBookmark(ctx, slack.BookmarkRequest{Channel: c.ID, Title: b.Title, Link: b.Link, Emoji: emojiCode(b.Emoji)}); err != nil {
+			return fmt.Errorf("failed to add bookmark %q to %s: %v", b.Title, c.Name, err)
+		}
+	}
+	if len(a.managers) > 0 {
+		if err := reconciler.slack.AddRoleAssignments(ctx, slack.RoleChannelManager, []string{c.ID}, a.managers); err != nil {
+			return fmt.Errorf("failed to make %v channel managers of %s: %v", a.managers, c.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -134,9 +343,14 @@ func (a unarchiveChannelAction) Describe() string {
 	return fmt.Sprintf("Unarchive channel: %s", a.name)
 }
 
-func (a unarchiveChannelAction) Perform(reconciler *Reconciler) error {
-	if err := reconciler.slack.CallMethod("conversations.unarchive", map[string]string{"channel": a.id}, nil); err != nil {
-		return fmt.Errorf("failed to unarchive channel %s (%s): %v", a.id, a.name, err)
+func (a unarchiveChannelAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	err := reconciler.slack.UnarchiveConversation(ctx, a.id)
+	if errors.Is(err, slack.ErrNotArchived) {
+		log.Printf("Channel %s (%s) was already unarchived.", a.name, a.id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unarchive channel %s (%s): %w", a.id, a.name, err)
 	}
 	return nil
 }
@@ -150,9 +364,14 @@ func (a archiveChannelAction) Describe() string {
 	return fmt.Sprintf("Archive channel: %s", a.name)
 }
 
-func (a archiveChannelAction) Perform(reconciler *Reconciler) error {
-	if err := reconciler.slack.CallMethod("conversations.archive", map[string]string{"channel": a.id}, nil); err != nil {
-		return fmt.Errorf("failed to archive channel %s (%s): %v", a.name, a.id, err)
+func (a archiveChannelAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	err := reconciler.slack.ArchiveConversation(ctx, a.id)
+	if errors.Is(err, slack.ErrAlreadyArchived) {
+		log.Printf("Channel %s (%s) was already archived.", a.name, a.id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive channel %s (%s): %w", a.name, a.id, err)
 	}
 	return nil
 }
@@ -167,9 +386,253 @@ func (a renameChannelAction) Describe() string {
 	return fmt.Sprintf("Rename channel %s from %s to %s", a.id, a.oldName, a.newName)
 }
 
-func (a renameChannelAction) Perform(reconciler *Reconciler) error {
-	if err := reconciler.slack.CallMethod("conversations.rename", map[string]string{"channel": a.id, "name": a.newName}, nil); err != nil {
-		return fmt.Errorf("failed to rename channel %s (%s) to %s: %v", a.oldName, a.id, a.newName, err)
+func (a renameChannelAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	err := reconciler.slack.RenameConversation(ctx, a.id, a.newName)
+	if errors.Is(err, slack.ErrNameTaken) {
+		return fmt.Errorf("failed to rename channel %s (%s) to %s: a channel called %s already exists, and may be archived: %w", a.oldName, a.id, a.newName, a.newName, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rename channel %s (%s) to %s: %w", a.oldName, a.id, a.newName, err)
+	}
+	return nil
+}
+
+type convertChannelAction struct {
+	id      string
+	name    string
+	private bool
+}
+
+func (a convertChannelAction) Describe() string {
+	if a.private {
+		return fmt.Sprintf("Convert channel %s to private", a.name)
+	}
+	return fmt.Sprintf("Convert channel %s to public", a.name)
+}
+
+func (a convertChannelAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if !a.private {
+		if err := reconciler.slack.ConvertToPublic(ctx, a.id); err != nil {
+			return fmt.Errorf("failed to convert channel %s (%s) to public: %w", a.name, a.id, err)
+		}
+		return nil
+	}
+	// We can only see private channels we're in, so make sure we still can afterwards.
+	if err := reconciler.slack.JoinConversation(ctx, a.id); err != nil {
+		return fmt.Errorf("failed to join channel %s (%s) before making it private: %w", a.name, a.id, err)
+	}
+	if err := reconciler.slack.ConvertToPrivate(ctx, a.id); err != nil {
+		return fmt.Errorf("failed to convert channel %s (%s) to private: %w", a.name, a.id, err)
+	}
+	return nil
+}
+
+type setChannelTopicAction struct {
+	id    string
+	name  string
+	topic string
+}
+
+func (a setChannelTopicAction) Describe() string {
+	return fmt.Sprintf("Set topic of channel %s to %q", a.name, a.topic)
+}
+
+func (a setChannelTopicAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.SetTopic(ctx, a.id, a.topic); err != nil {
+		return fmt.Errorf("failed to set topic of channel %s (%s): %w", a.name, a.id, err)
+	}
+	return nil
+}
+
+type setChannelPurposeAction struct {
+	id      string
+	name    string
+	purpose string
+}
+
+func (a setChannelPurposeAction) Describe() string {
+	return fmt.Sprintf("Set purpose of channel %s to %q", a.name, a.purpose)
+}
+
+func (a setChannelPurposeAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.SetPurpose(ctx, a.id, a.purpose); err != nil {
+		return fmt.Errorf("failed to set purpose of channel %s (%s): %w", a.name, a.id, err)
+	}
+	return nil
+}
+
+type inviteChannelMembersAction struct {
+	id    string
+	name  string
+	users []string
+}
+
+func (a inviteChannelMembersAction) Describe() string {
+	return fmt.Sprintf("Invite %v to channel %s", a.users, a.name)
+}
+
+func (a inviteChannelMembersAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	err := reconciler.slack.InviteToConversation(ctx, a.id, a.users)
+	if errors.Is(err, slack.ErrAlreadyInChannel) {
+		log.Printf("Some of %v were already in channel %s (%s).", a.users, a.name, a.id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to invite %v to channel %s (%s): %w", a.users, a.name, a.id, err)
+	}
+	return nil
+}
+
+type addPinAction struct {
+	id   string
+	name string
+	text string
+}
+
+func (a addPinAction) Describe() string {
+	return fmt.Sprintf("Pin a new message in channel %s: %q", a.name, a.text)
+}
+
+func (a addPinAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	// Not wrapped, so that the message isn't posted again: Slack may have posted it even if the
+	// call failed.
+	r, err := reconciler.slack.PostMessage(ctx, slack.PostMessageRequest{Channel: a.id, Text: a.text})
+	if err != nil {
+		return fmt.Errorf("failed to post message to pin in %s (%s): %v", a.name, a.id, err)
+	}
+	if err := reconciler.slack.PinMessage(ctx, a.id, r.TS); err != nil {
+		return fmt.Errorf("failed to pin message %s in %s (%s): %v", r.TS, a.name, a.id, err)
+	}
+	return nil
+}
+
+type updatePinAction struct {
+	id   string
+	name string
+	ts   string
+	text string
+}
+
+func (a updatePinAction) Describe() string {
+	return fmt.Sprintf("Change pinned message %s in channel %s to %q", a.ts, a.name, a.text)
+}
+
+func (a updatePinAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.UpdateMessage(ctx, slack.UpdateMessageRequest{Channel: a.id, TS: a.ts, Text: a.text}); err != nil {
+		return fmt.Errorf("failed to update pinned message %s in %s (%s): %w", a.ts, a.name, a.id, err)
+	}
+	return nil
+}
+
+type removePinAction struct {
+	id   string
+	name string
+	ts   string
+	text string
+}
+
+func (a removePinAction) Describe() string {
+	return fmt.Sprintf("Unpin message %s in channel %s: %q", a.ts, a.name, a.text)
+}
+
+func (a removePinAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	err := reconciler.slack.UnpinMessage(ctx, a.id, a.ts)
+	if errors.Is(err, slack.ErrNoPin) {
+		log.Printf("Message %s in %s (%s) was already unpinned.", a.ts, a.name, a.id)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unpin message %s in %s (%s): %w", a.ts, a.name, a.id, err)
+	}
+	return nil
+}
+
+type addBookmarkAction struct {
+	id       string
+	name     string
+	bookmark config.Bookmark
+}
+
+func (a addBookmarkAction) Describe() string {
+	return fmt.Sprintf("Bookmark %s in channel %s as %q", a.bookmark.Link, a.name, a.bookmark.Title)
+}
+
+func (a addBookmarkAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	req := slack.BookmarkRequest{Channel: a.id, Title: a.bookmark.Title, Link: a.bookmark.Link, Emoji: emojiCode(a.bookmark.Emoji)}
+	// Not wrapped, so that a bookmark Slack added despite the call failing isn't added twice.
+	if _, err := reconciler.slack.AddBookmark(ctx, req); err != nil {
+		return fmt.Errorf("failed to add bookmark %q to %s (%s): %v", a.bookmark.Title, a.name, a.id, err)
+	}
+	return nil
+}
+
+type editBookmarkAction struct {
+	id         string
+	name       string
+	bookmarkID string
+	bookmark   config.Bookmark
+}
+
+func (a editBookmarkAction) Describe() string {
+	return fmt.Sprintf("Change bookmark %q in channel %s to %s", a.bookmark.Title, a.name, a.bookmark.Link)
+}
+
+func (a editBookmarkAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	req := slack.BookmarkRequest{ID: a.bookmarkID, Channel: a.id, Title: a.bookmark.Title, Link: a.bookmark.Link, Emoji: emojiCode(a.bookmark.Emoji)}
+	if _, err := reconciler.slack.EditBookmark(ctx, req); err != nil {
+		return fmt.Errorf("failed to edit bookmark %q in %s (%s): %w", a.bookmark.Title, a.name, a.id, err)
+	}
+	return nil
+}
+
+type removeBookmarkAction struct {
+	id         string
+	name       string
+	bookmarkID string
+	title      string
+}
+
+func (a removeBookmarkAction) Describe() string {
+	return fmt.Sprintf("Remove bookmark %q from channel %s", a.title, a.name)
+}
+
+func (a removeBookmarkAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.RemoveBookmark(ctx, a.id, a.bookmarkID); err != nil {
+		return fmt.Errorf("failed to remove bookmark %q from %s (%s): %w", a.title, a.name, a.id, err)
+	}
+	return nil
+}
+
+type addChannelManagersAction struct {
+	id    string
+	name  string
+	users []string
+}
+
+func (a addChannelManagersAction) Describe() string {
+	return fmt.Sprintf("Make %v channel managers of %s", a.users, a.name)
+}
+
+func (a addChannelManagersAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.AddRoleAssignments(ctx, slack.RoleChannelManager, []string{a.id}, a.users); err != nil {
+		return fmt.Errorf("failed to make %v channel managers of %s (%s): %w", a.users, a.name, a.id, err)
+	}
+	return nil
+}
+
+type removeChannelManagersAction struct {
+	id    string
+	name  string
+	users []string
+}
+
+func (a removeChannelManagersAction) Describe() string {
+	return fmt.Sprintf("Stop %v being channel managers of %s", a.users, a.name)
+}
+
+func (a removeChannelManagersAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.RemoveRoleAssignments(ctx, slack.RoleChannelManager, []string{a.id}, a.users); err != nil {
+		return fmt.Errorf("failed to remove %v as channel managers of %s (%s): %w", a.users, a.name, a.id, err)
 	}
 	return nil
 }
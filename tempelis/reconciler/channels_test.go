@@ -28,7 +28,11 @@ func TestReconcileChannels(t *testing.T) {
 	tests := []struct {
 		name             string
 		priorChannels    []slack.Conversation
+		priorMembers     map[string][]string
+		priorPins        map[string][]slack.Pin
+		priorBookmarks   map[string][]slack.Bookmark
 		newChannels      []config.Channel
+		template         config.ChannelTemplate
 		expectedActions  []Action
 		expectedErrCount int
 	}{
@@ -80,13 +84,173 @@ func TestReconcileChannels(t *testing.T) {
 			expectedActions:  []Action{renameChannelAction{id: "C12345678", oldName: "sig-testing", newName: "sig-hmm"}, archiveChannelAction{id: "C12345678", name: "sig-hmm"}, unarchiveChannelAction{id: "C11111111", name: "sig-ponies"}},
 			expectedErrCount: 1,
 		},
+		{
+			name:            "create a private channel with members",
+			newChannels:     []config.Channel{{Name: "security", Private: true, Members: []string{"alice", "bob"}}},
+			expectedActions: []Action{createChannelAction{name: "security", private: true, members: []string{"U00000001", "U00000002"}}},
+		},
+		{
+			name:            "an unreferenced private channel is not an error",
+			priorChannels:   []slack.Conversation{{Name: "secret", ID: "G12345678", IsPrivate: true}},
+			newChannels:     []config.Channel{},
+			expectedActions: nil,
+		},
+		{
+			name:            "convert a public channel to private",
+			priorChannels:   []slack.Conversation{{Name: "sig-leads", ID: "C12345678"}},
+			newChannels:     []config.Channel{{Name: "sig-leads", Private: true}},
+			expectedActions: []Action{convertChannelAction{id: "C12345678", name: "sig-leads", private: true}},
+		},
+		{
+			name:            "convert a private channel to public",
+			priorChannels:   []slack.Conversation{{Name: "sig-leads", ID: "C12345678", IsPrivate: true}},
+			newChannels:     []config.Channel{{Name: "sig-leads"}},
+			expectedActions: []Action{convertChannelAction{id: "C12345678", name: "sig-leads", private: false}},
+		},
+		{
+			name:          "don't convert a channel that should be archived",
+			priorChannels: []slack.Conversation{{Name: "sig-leads", ID: "C12345678", IsArchived: true}},
+			newChannels:   []config.Channel{{Name: "sig-leads", Private: true, Archived: true}},
+		},
+		{
+			name:            "invite missing members to a private channel",
+			priorChannels:   []slack.Conversation{{Name: "security", ID: "C12345678", IsPrivate: true}},
+			priorMembers:    map[string][]string{"C12345678": {"U00000002", "U00000003"}},
+			newChannels:     []config.Channel{{Name: "security", Private: true, Members: []string{"alice", "bob"}}},
+			expectedActions: []Action{inviteChannelMembersAction{id: "C12345678", name: "security", users: []string{"U00000001"}}},
+		},
+		{
+			name:          "members not in the config aren't removed",
+			priorChannels: []slack.Conversation{{Name: "security", ID: "C12345678", IsPrivate: true}},
+			priorMembers:  map[string][]string{"C12345678": {"U00000001", "U00000002", "U00000003"}},
+			newChannels:   []config.Channel{{Name: "security", Private: true, Members: []string{"alice"}}},
+		},
+		{
+			name:             "unknown members are an error",
+			priorChannels:    []slack.Conversation{{Name: "security", ID: "C12345678", IsPrivate: true}},
+			newChannels:      []config.Channel{{Name: "security", Private: true, Members: []string{"mallory"}}, {Name: "other", Private: true, Members: []string{"mallory"}}},
+			expectedErrCount: 2,
+		},
+		{
+			name:            "a new channel's topic and purpose fall back to the template",
+			newChannels:     []config.Channel{{Name: "sig-ponies", Topic: "Ponies!"}},
+			template:        config.ChannelTemplate{Topic: "A channel", Purpose: "Talking"},
+			expectedActions: []Action{createChannelAction{name: "sig-ponies", topic: "Ponies!", purpose: "Talking"}},
+		},
+		{
+			name:          "the template's topic and purpose are kept on existing channels",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678"}, "Changed", "")},
+			newChannels:   []config.Channel{{Name: "sig-testing"}, {Name: "sig-ponies", Topic: "Ponies!"}},
+			template:      config.ChannelTemplate{Topic: "A channel", Purpose: "Talking"},
+			expectedActions: []Action{
+				setChannelTopicAction{id: "C12345678", name: "sig-testing", topic: "A channel"},
+				setChannelPurposeAction{id: "C12345678", name: "sig-testing", purpose: "Talking"},
+				createChannelAction{name: "sig-ponies", topic: "Ponies!", purpose: "Talking"},
+			},
+		},
+		{
+			name:          "channels can opt out of the template's topic and purpose",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678"}, "Changed", "")},
+			newChannels:   []config.Channel{{Name: "sig-testing", IgnoreTopic: true, IgnorePurpose: true}},
+			template:      config.ChannelTemplate{Topic: "A channel", Purpose: "Talking"},
+		},
+		{
+			name:          "fix a topic and purpose that have drifted",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678"}, "Old topic", "Old purpose")},
+			newChannels:   []config.Channel{{Name: "sig-testing", Topic: "New topic", Purpose: "New purpose"}},
+			expectedActions: []Action{
+				setChannelTopicAction{id: "C12345678", name: "sig-testing", topic: "New topic"},
+				setChannelPurposeAction{id: "C12345678", name: "sig-testing", purpose: "New purpose"},
+			},
+		},
+		{
+			name:          "ignored topics and purposes aren't fixed",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678"}, "Old topic", "Old purpose")},
+			newChannels:   []config.Channel{{Name: "sig-testing", Topic: "New topic", Purpose: "New purpose", IgnoreTopic: true, IgnorePurpose: true}},
+		},
+		{
+			name:          "topics escaped by Slack match",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678"}, "Tests &amp; <https://testgrid.k8s.io|dashboards>", "")},
+			newChannels:   []config.Channel{{Name: "sig-testing", Topic: "Tests & <https://testgrid.k8s.io|dashboards>"}},
+		},
+		{
+			name:          "archived channels' topics aren't fixed",
+			priorChannels: []slack.Conversation{withTopic(slack.Conversation{Name: "sig-testing", ID: "C12345678", IsArchived: true}, "Old topic", "")},
+			newChannels:   []config.Channel{{Name: "sig-testing", Topic: "New topic", Archived: true}},
+		},
+		{
+			name:          "pins that already match are left alone, including ones with links Slack added",
+			priorChannels: []slack.Conversation{{Name: "sig-testing", ID: "C12345678"}},
+			priorPins:     map[string][]slack.Pin{"C12345678": {ourPin("1.1", "Be nice."), ourPin("1.2", "Notes: <https://docs.k8s.io>")}},
+			newChannels:   []config.Channel{{Name: "sig-testing", Pins: []string{"Notes: https://docs.k8s.io", "Be nice."}}},
+		},
+		{
+			name:          "changed pins are updated, then added or removed",
+			priorChannels: []slack.Conversation{{Name: "sig-testing", ID: "C12345678"}, {Name: "sig-ponies", ID: "C11111111"}},
+			priorPins: map[string][]slack.Pin{
+				"C12345678": {ourPin("1.1", "Be nice."), ourPin("1.2", "Old notes")},
+				"C11111111": {ourPin("2.1", "Be nice."), ourPin("2.2", "Old notes")},
+			},
+			newChannels: []config.Channel{
+				{Name: "sig-testing", Pins: []string{"Be nice.", "New notes", "Charter"}},
+				{Name: "sig-ponies", Pins: []string{}},
+			},
+			expectedActions: []Action{
+				updatePinAction{id: "C12345678", name: "sig-testing", ts: "1.2", text: "New notes"},
+				addPinAction{id: "C12345678", name: "sig-testing", text: "Charter"},
+				removePinAction{id: "C11111111", name: "sig-ponies", ts: "2.1", text: "Be nice."},
+				removePinAction{id: "C11111111", name: "sig-ponies", ts: "2.2", text: "Old notes"},
+			},
+		},
+		{
+			name:          "other users' pins are left alone",
+			priorChannels: []slack.Conversation{{Name: "sig-testing", ID: "C12345678"}},
+			priorPins: map[string][]slack.Pin{"C12345678": {
+				{Type: "message", Message: &slack.Message{User: "U00000001", TS: "1.1", Text: "Someone's pin"}},
+				{Type: "file", File: &slack.File{ID: "F12345678"}},
+			}},
+			newChannels:     []config.Channel{{Name: "sig-testing", Pins: []string{"Be nice."}}},
+			expectedActions: []Action{addPinAction{id: "C12345678", name: "sig-testing", text: "Be nice."}},
+		},
+		{
+			name:          "channels without pins don't have their pins managed",
+			priorChannels: []slack.Conversation{{Name: "sig-testing", ID: "C12345678"}},
+			priorPins:     map[string][]slack.Pin{"C12345678": {ourPin("1.1", "Be nice.")}},
+			newChannels:   []config.Channel{{Name: "sig-testing"}},
+		},
+		{
+			name:          "bookmarks are matched by title, then added, edited and removed",
+			priorChannels: []slack.Conversation{{Name: "sig-testing", ID: "C12345678"}},
+			priorBookmarks: map[string][]slack.Bookmark{"C12345678": {
+				{ID: "Bk1", Title: "Charter", Link: "https://k8s.io/charter", Emoji: ":scroll:"},
+				{ID: "Bk2", Title: "Notes", Link: "https://k8s.io/old-notes"},
+				{ID: "Bk3", Title: "Old", Link: "https://k8s.io/old"},
+			}},
+			newChannels: []config.Channel{{Name: "sig-testing", Bookmarks: []config.Bookmark{
+				{Title: "Charter", Link: "https://k8s.io/charter", Emoji: "scroll"},
+				{Title: "Notes", Link: "https://k8s.io/notes"},
+				{Title: "Board", Link: "https://k8s.io/board"},
+			}}},
+			expectedActions: []Action{
+				editBookmarkAction{id: "C12345678", name: "sig-testing", bookmarkID: "Bk2", bookmark: config.Bookmark{Title: "Notes", Link: "https://k8s.io/notes"}},
+				addBookmarkAction{id: "C12345678", name: "sig-testing", bookmark: config.Bookmark{Title: "Board", Link: "https://k8s.io/board"}},
+				removeBookmarkAction{id: "C12345678", name: "sig-testing", bookmarkID: "Bk3", title: "Old"},
+			},
+		},
+		{
+			name:            "a new channel gets its own pins and bookmarks instead of the template's",
+			newChannels:     []config.Channel{{Name: "sig-ponies", Pins: []string{"Ponies only."}, Bookmarks: []config.Bookmark{{Title: "Ponies", Link: "https://ponies.dev"}}}},
+			template:        config.ChannelTemplate{Pins: []string{"Be nice."}},
+			expectedActions: []Action{createChannelAction{name: "sig-ponies", pins: []string{"Ponies only."}, managedPins: true, bookmarks: []config.Bookmark{{Title: "Ponies", Link: "https://ponies.dev"}}}},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			r := Reconciler{
-				config:   config.Config{Channels: tc.newChannels},
-				channels: channelState{byID: map[string]*slack.Conversation{}, byName: map[string]*slack.Conversation{}},
+				config:   config.Config{Users: map[string]string{"alice": "U00000001", "bob": "U00000002"}, Channels: tc.newChannels, ChannelTemplate: tc.template},
+				channels: channelState{byID: map[string]*slack.Conversation{}, byName: map[string]*slack.Conversation{}, members: tc.priorMembers, pins: tc.priorPins, bookmarks: tc.priorBookmarks},
+				self:     "UTEMPELIS",
 			}
 			for _, c := range tc.priorChannels {
 				c2 := c
@@ -103,3 +267,13 @@ func TestReconcileChannels(t *testing.T) {
 		})
 	}
 }
+
+func withTopic(c slack.Conversation, topic, purpose string) slack.Conversation {
+	c.Topic.Topic = topic
+	c.Purpose.Purpose = purpose
+	return c
+}
+
+func ourPin(ts, text string) slack.Pin {
+	return slack.Pin{Type: "message", Message: &slack.Message{User: "UTEMPELIS", TS: ts, Text: text}}
+}
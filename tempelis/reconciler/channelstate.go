@@ -17,6 +17,7 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
 	"sigs.k8s.io/slack-infra/slack"
 	"strings"
@@ -25,12 +26,25 @@ import (
 type channelState struct {
 	byName map[string]*slack.Conversation
 	byID   map[string]*slack.Conversation
+	// members, pins, bookmarks and managers map channel IDs to their members, pins, bookmarks and
+	// channel managers, for the channels that were loaded with loadMembers, loadPins,
+	// loadBookmarks and loadManagers.
+	members   map[string][]string
+	pins      map[string][]slack.Pin
+	bookmarks map[string][]slack.Bookmark
+	managers  map[string][]string
 }
 
-func (c *channelState) init(s *slack.Client) error {
+// init loads every public channel, and every private channel the client's user is a member of;
+// other private channels are invisible to it.
+func (c *channelState) init(ctx context.Context, s *slack.Client) error {
 	c.byName = map[string]*slack.Conversation{}
 	c.byID = map[string]*slack.Conversation{}
-	channels, err := s.GetPublicChannels()
+	c.members = map[string][]string{}
+	c.pins = map[string][]slack.Pin{}
+	c.bookmarks = map[string][]slack.Bookmark{}
+	c.managers = map[string][]string{}
+	channels, err := s.GetConversationsContext(ctx, []slack.ConversationType{slack.ConversationTypePublicChannel, slack.ConversationTypePrivateChannel})
 	if err != nil {
 		return err
 	}
@@ -42,6 +56,46 @@ func (c *channelState) init(s *slack.Client) error {
 	return nil
 }
 
+func (c *channelState) loadMembers(ctx context.Context, s *slack.Client, id string) error {
+	members, err := s.ListConversationMembers(id).Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get members of %s: %v", id, err)
+	}
+	c.members[id] = members
+	return nil
+}
+
+func (c *channelState) loadPins(ctx context.Context, s *slack.Client, id string) error {
+	pins, err := s.ListPins(ctx, id)
+	if err != nil {
+		return err
+	}
+	c.pins[id] = pins
+	return nil
+}
+
+func (c *channelState) loadBookmarks(ctx context.Context, s *slack.Client, id string) error {
+	bookmarks, err := s.ListBookmarks(ctx, id)
+	if err != nil {
+		return err
+	}
+	c.bookmarks[id] = bookmarks
+	return nil
+}
+
+func (c *channelState) loadManagers(ctx context.Context, s *slack.Client, id string) error {
+	assignments, err := s.ListRoleAssignments(slack.RoleChannelManager, []string{id}).Collect(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get channel managers of %s: %v", id, err)
+	}
+	managers := []string{}
+	for _, a := range assignments {
+		managers = append(managers, a.UserID)
+	}
+	c.managers[id] = managers
+	return nil
+}
+
 func (c *channelState) rename(old, new string) error {
 	if _, ok := c.byName[new]; ok {
 		return fmt.Errorf("can't rename %s to %s: name already used", old, new)
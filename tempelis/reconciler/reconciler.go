@@ -17,18 +17,31 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/moderators"
 	"sigs.k8s.io/slack-infra/tempelis/config"
 )
 
 type Reconciler struct {
+	// ChannelManagers makes each channel's moderators its channel managers, for the channels that
+	// list moderators. This uses the admin API, which is only available on Enterprise Grid.
+	ChannelManagers bool
+	// UnresolvedUsers are the names of users in the config that don't refer to an active Slack
+	// account, perhaps because they have left. They are left out of the channels and usergroups
+	// that list them, rather than making those invalid.
+	UnresolvedUsers map[string]bool
+
 	slack    *slack.Client
 	config   config.Config
 	channels channelState
 	groups   usergroupState
+	// self is the ID of our own user, if any configured channel has pins.
+	self string
 }
 
 func New(slack *slack.Client, config config.Config) *Reconciler {
@@ -40,11 +53,14 @@ func New(slack *slack.Client, config config.Config) *Reconciler {
 	}
 }
 
-func (r *Reconciler) Reconcile(dryRun bool) error {
-	if err := r.channels.init(r.slack); err != nil {
+func (r *Reconciler) Reconcile(ctx context.Context, dryRun bool) error {
+	if err := r.channels.init(ctx, r.slack); err != nil {
+		return fmt.Errorf("failed to get initial channel state: %v", err)
+	}
+	if err := r.loadChannelDetails(ctx); err != nil {
 		return fmt.Errorf("failed to get initial channel state: %v", err)
 	}
-	if err := r.groups.init(r.slack); err != nil {
+	if err := r.groups.init(ctx, r.slack); err != nil {
 		return fmt.Errorf("failed to get initial usergroup state: %v", err)
 	}
 	var actions []Action
@@ -77,7 +93,7 @@ func (r *Reconciler) Reconcile(dryRun bool) error {
 		for i, a := range actions {
 			log.Printf("Step %d: %s.\n", i+1, a.Describe())
 			if !dryRun {
-				if err := a.Perform(r); err != nil {
+				if err := r.perform(ctx, a); err != nil {
 					log.Printf("Failed: %v.\n", err)
 				}
 			}
@@ -92,7 +108,91 @@ func (r *Reconciler) Reconcile(dryRun bool) error {
 	return nil
 }
 
+// loadChannelDetails loads the parts of existing channels that only some channels' config needs.
+func (r *Reconciler) loadChannelDetails(ctx context.Context) error {
+	for _, c := range r.config.Channels {
+		o, ok := r.channels.byID[c.ID]
+		if !ok {
+			o, ok = r.channels.byName[c.Name]
+		}
+		if !ok || c.Archived {
+			continue
+		}
+		if len(c.Members) > 0 {
+			if err := r.channels.loadMembers(ctx, r.slack, o.ID); err != nil {
+				return err
+			}
+		}
+		if c.Pins != nil {
+			if err := r.channels.loadPins(ctx, r.slack, o.ID); err != nil {
+				return err
+			}
+			if r.self == "" {
+				identity, err := r.slack.AuthTest(ctx)
+				if err != nil {
+					return err
+				}
+				r.self = identity.UserID
+			}
+		}
+		if c.Bookmarks != nil {
+			if err := r.channels.loadBookmarks(ctx, r.slack, o.ID); err != nil {
+				return err
+			}
+		}
+		if r.ChannelManagers && c.Moderators != nil {
+			if err := r.channels.loadManagers(ctx, r.slack, o.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Moderators returns the moderators of every unarchived channel that has an ID, for the moderator
+// bot. After Reconcile, that includes the channels it created.
+func (r *Reconciler) Moderators() (moderators.List, error) {
+	list := moderators.List{Channels: map[string][]string{}}
+	for _, c := range r.config.Channels {
+		ids, err := r.userIDs(c.Moderators)
+		if err != nil {
+			return moderators.List{}, fmt.Errorf("channel %s has bad moderators: %v", c.Name, err)
+		}
+		o, ok := r.channels.byName[c.Name]
+		if !ok || o.ID == "" || c.Archived || len(ids) == 0 {
+			continue
+		}
+		list.Channels[o.ID] = ids
+	}
+	return list, nil
+}
+
+// userIDs converts the names of users to their Slack IDs, leaving out UnresolvedUsers.
+func (r *Reconciler) userIDs(names []string) ([]string, error) {
+	resolved := make([]string, 0, len(names))
+	for _, n := range names {
+		if !r.UnresolvedUsers[n] {
+			resolved = append(resolved, n)
+		}
+	}
+	return r.config.NamesToIDs(resolved)
+}
+
+// actionRetries is how many more times to try an action after Slack fails in a way that might
+// not happen again.
+const actionRetries = 2
+
+// actionRetryDelay is how long to wait before trying an action again.
+var actionRetryDelay = 5 * time.Second
+
+// perform performs a, trying again if it fails with an error that slack.IsRetryable.
+func (r *Reconciler) perform(ctx context.Context, a Action) error {
+	return slack.Retry(ctx, actionRetries, actionRetryDelay, func() error {
+		return a.Perform(ctx, r)
+	})
+}
+
 type Action interface {
 	Describe() string
-	Perform(reconciler *Reconciler) error
+	Perform(ctx context.Context, reconciler *Reconciler) error
 }
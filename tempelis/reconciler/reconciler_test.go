@@ -0,0 +1,438 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+	"sigs.k8s.io/slack-infra/tempelis/config"
+)
+
+func TestReconcileAgainstFakeSlack(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+
+	alice := s.AddUser(slack.User{Name: "alice"})
+	bob := s.AddUser(slack.User{Name: "bob"})
+	sigTesting := s.AddConversation(slack.Conversation{Name: "sig-testing"})
+	old := s.AddConversation(slack.Conversation{Name: "sig-old"})
+	oldGroup := s.AddUsergroup(slack.Subteam{Handle: "old-group", Name: "Old Group", Users: []string{alice}})
+
+	c := config.Config{
+		Users: map[string]string{"alice": alice, "bob": bob},
+		Channels: []config.Channel{
+			{Name: "sig-testing"},
+			{Name: "sig-old", Archived: true},
+			{Name: "sig-new"},
+		},
+		Usergroups: []config.Usergroup{
+			{Name: "leads", LongName: "Leads", Description: "The leads", Members: []string{"alice", "bob"}, Channels: []string{"sig-testing"}},
+		},
+		ChannelTemplate: config.ChannelTemplate{
+			Topic: "A topic",
+			Pins:  []string{"Be nice."},
+		},
+	}
+
+	r := New(s.Client(slack.Config{}), c)
+	if err := r.Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if conv, _ := s.Conversation(old); !conv.IsArchived {
+		t.Errorf("Expected sig-old to be archived")
+	}
+	if conv, _ := s.Conversation(sigTesting); conv.IsArchived {
+		t.Errorf("Expected sig-testing not to be archived")
+	}
+	newChannel, ok := s.ConversationByName("sig-new")
+	if !ok {
+		t.Fatalf("Expected sig-new to be created")
+	}
+	if newChannel.Topic.Topic != "A topic" {
+		t.Errorf("Expected sig-new to have topic %q, but got %q", "A topic", newChannel.Topic.Topic)
+	}
+	if pins := s.Pins(newChannel.ID); len(pins) != 1 {
+		t.Errorf("Expected sig-new to have one pin, but got %d", len(pins))
+	}
+
+	leads, ok := s.UsergroupByHandle("leads")
+	if !ok {
+		t.Fatalf("Expected usergroup leads to be created")
+	}
+	if !reflect.DeepEqual(leads.Users, []string{alice, bob}) {
+		t.Errorf("Expected leads to contain %v, but got %v", []string{alice, bob}, leads.Users)
+	}
+	if !reflect.DeepEqual(leads.Prefs.Channels, []string{sigTesting}) {
+		t.Errorf("Expected leads to have default channels %v, but got %v", []string{sigTesting}, leads.Prefs.Channels)
+	}
+	if g, _ := s.Usergroup(oldGroup); g.DeleteTime == 0 {
+		t.Errorf("Expected old-group to be disabled")
+	}
+}
+
+func TestReconcilePrivateChannels(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+
+	alice := s.AddUser(slack.User{Name: "alice"})
+	bob := s.AddUser(slack.User{Name: "bob"})
+	leads := s.AddConversation(slack.Conversation{Name: "sig-leads"})
+	security := s.AddConversation(slack.Conversation{Name: "security", IsPrivate: true, IsMember: true}, bob)
+	s.AddConversation(slack.Conversation{Name: "unmanaged", IsPrivate: true, IsMember: true})
+	s.AddConversation(slack.Conversation{Name: "invisible", IsPrivate: true})
+
+	c := config.Config{
+		Users: map[string]string{"alice": alice, "bob": bob},
+		Channels: []config.Channel{
+			{Name: "sig-leads", Private: true, Members: []string{"alice"}},
+			{Name: "security", Private: true, Members: []string{"alice", "bob"}},
+			{Name: "new-private", Private: true, Members: []string{"bob"}},
+		},
+	}
+	if err := New(s.Client(slack.Config{}), c).Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if conv, _ := s.Conversation(leads); !conv.IsPrivate || !conv.IsMember {
+		t.Errorf("Expected sig-leads to be converted to private with us still in it, got %+v", conv)
+	}
+	if members := s.Members(leads); !reflect.DeepEqual(members, []string{slacktest.DefaultBotUserID, alice}) {
+		t.Errorf("Expected sig-leads to have members %v, got %v", []string{slacktest.DefaultBotUserID, alice}, members)
+	}
+	if members := s.Members(security); !reflect.DeepEqual(members, []string{bob, slacktest.DefaultBotUserID, alice}) {
+		t.Errorf("Expected alice to be invited to security, got members %v", members)
+	}
+	created, ok := s.ConversationByName("new-private")
+	if !ok || !created.IsPrivate {
+		t.Fatalf("Expected new-private to be created as a private channel, got %+v", created)
+	}
+	if members := s.Members(created.ID); !reflect.DeepEqual(members, []string{slacktest.DefaultBotUserID, bob}) {
+		t.Errorf("Expected new-private to have members %v, got %v", []string{slacktest.DefaultBotUserID, bob}, members)
+	}
+}
+
+func TestReconcileTopicsAndPurposes(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	drifted := s.AddConversation(slack.Conversation{Name: "sig-testing"})
+	live := s.AddConversation(slack.Conversation{Name: "sig-live"})
+	client := s.Client(slack.Config{})
+	ctx := context.Background()
+	for _, id := range []string{drifted, live} {
+		if err := client.SetTopic(ctx, id, "Someone's topic"); err != nil {
+			t.Fatalf("Failed to set topic: %v", err)
+		}
+	}
+
+	c := config.Config{
+		Channels: []config.Channel{
+			{Name: "sig-testing", Topic: "Testing & more", Purpose: "Tests"},
+			{Name: "sig-live", Topic: "Our topic", IgnoreTopic: true},
+		},
+	}
+	if err := New(client, c).Reconcile(ctx, false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if conv, _ := s.Conversation(drifted); conv.Topic.Topic != "Testing & more" || conv.Purpose.Purpose != "Tests" {
+		t.Errorf("Expected sig-testing's topic and purpose to be fixed, got %q and %q", conv.Topic.Topic, conv.Purpose.Purpose)
+	}
+	if conv, _ := s.Conversation(live); conv.Topic.Topic != "Someone's topic" {
+		t.Errorf("Expected sig-live's topic to be left alone, got %q", conv.Topic.Topic)
+	}
+}
+
+func TestReconcilePinsAndBookmarks(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	channel := s.AddConversation(slack.Conversation{Name: "sig-testing", IsMember: true})
+	someone := s.AddUser(slack.User{Name: "someone"})
+	theirs := s.AddMessage(channel, slacktest.Message{User: someone, Text: "Their pin"})
+	ours := s.AddMessage(channel, slacktest.Message{User: slacktest.DefaultBotUserID, Text: "Old notes"})
+	stale := s.AddMessage(channel, slacktest.Message{User: slacktest.DefaultBotUserID, Text: "Stale"})
+	client := s.Client(slack.Config{})
+	ctx := context.Background()
+	for _, ts := range []string{theirs, ours, stale} {
+		if err := client.PinMessage(ctx, channel, ts); err != nil {
+			t.Fatalf("Failed to pin message: %v", err)
+		}
+	}
+	s.AddBookmark(channel, slack.Bookmark{Title: "Notes", Link: "https://k8s.io/old-notes"})
+
+	c := config.Config{Channels: []config.Channel{{
+		Name:      "sig-testing",
+		Pins:      []string{"New notes", "Stale"},
+		Bookmarks: []config.Bookmark{{Title: "Notes", Link: "https://k8s.io/notes", Emoji: "memo"}},
+	}}}
+	if err := New(client, c).Reconcile(ctx, false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if pins := s.Pins(channel); !reflect.DeepEqual(pins, []string{theirs, ours, stale}) {
+		t.Errorf("Expected the pins to be unchanged, got %v", pins)
+	}
+	var texts []string
+	for _, m := range s.Messages(channel) {
+		texts = append(texts, m.Text)
+	}
+	if want := []string{"Their pin", "New notes", "Stale"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("Expected messages %q, got %q", want, texts)
+	}
+	bookmarks := s.Bookmarks(channel)
+	if len(bookmarks) != 1 || bookmarks[0].Link != "https://k8s.io/notes" || bookmarks[0].Emoji != ":memo:" {
+		t.Errorf("Expected the notes bookmark to be updated, got %+v", bookmarks)
+	}
+
+	// Running again should have nothing left to do.
+	before := len(s.Calls())
+	if err := New(client, c).Reconcile(ctx, false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	for _, call := range s.Calls()[before:] {
+		switch call.Method {
+		case "conversations.list", "usergroups.list", "pins.list", "bookmarks.list", "auth.test":
+		default:
+			t.Errorf("Unexpected call to %s when already up to date", call.Method)
+		}
+	}
+}
+
+func TestReconcileDryRunChangesNothing(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	s.AddConversation(slack.Conversation{Name: "sig-testing"})
+
+	c := config.Config{Channels: []config.Channel{{Name: "sig-testing", Archived: true}, {Name: "sig-new"}}}
+	r := New(s.Client(slack.Config{}), c)
+	if err := r.Reconcile(context.Background(), true); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	for _, call := range s.Calls() {
+		if call.Method != "conversations.list" && call.Method != "usergroups.list" {
+			t.Errorf("Unexpected call to %s in dry-run mode", call.Method)
+		}
+	}
+}
+
+func TestReconcileRetriesOnlyRetryableFailures(t *testing.T) {
+	defer func(d time.Duration) { actionRetryDelay = d }(actionRetryDelay)
+	actionRetryDelay = 0
+
+	tests := []struct {
+		name         string
+		injected     []string
+		wantCalls    int
+		wantArchived bool
+	}{
+		{
+			name:         "success",
+			wantCalls:    1,
+			wantArchived: true,
+		},
+		{
+			name:         "transient failure is retried",
+			injected:     []string{"internal_error"},
+			wantCalls:    2,
+			wantArchived: true,
+		},
+		{
+			name:      "retries are limited",
+			injected:  []string{"service_unavailable", "service_unavailable", "service_unavailable"},
+			wantCalls: 3,
+		},
+		{
+			name:      "permanent failure is not retried",
+			injected:  []string{"missing_scope"},
+			wantCalls: 1,
+		},
+		{
+			name:      "already archived counts as success",
+			injected:  []string{"already_archived"},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := slacktest.NewServer()
+			defer s.Close()
+			id := s.AddConversation(slack.Conversation{Name: "sig-old"})
+			for _, e := range tc.injected {
+				s.InjectError("conversations.archive", e)
+			}
+
+			c := config.Config{Channels: []config.Channel{{Name: "sig-old", Archived: true}}}
+			if err := New(s.Client(slack.Config{}), c).Reconcile(context.Background(), false); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+			if calls := len(s.CallsTo("conversations.archive")); calls != tc.wantCalls {
+				t.Errorf("Expected %d calls to conversations.archive, got %d", tc.wantCalls, calls)
+			}
+			if conv, _ := s.Conversation(id); conv.IsArchived != tc.wantArchived {
+				t.Errorf("Expected archived to be %t, got %t", tc.wantArchived, conv.IsArchived)
+			}
+		})
+	}
+}
+
+func TestReconcileDoesNotRetryCreation(t *testing.T) {
+	defer func(d time.Duration) { actionRetryDelay = d }(actionRetryDelay)
+	actionRetryDelay = 0
+
+	s := slacktest.NewServer()
+	defer s.Close()
+	user := s.AddUser(slack.User{Name: "katharine"})
+	s.AddConversation(slack.Conversation{Name: "sig-old"})
+	creations := []string{"conversations.create", "chat.postMessage", "bookmarks.add", "usergroups.create"}
+	for _, method := range creations {
+		s.InjectError(method, "internal_error")
+	}
+
+	c := config.Config{
+		Users: map[string]string{"katharine": user},
+		Channels: []config.Channel{
+			{Name: "sig-new"},
+			{Name: "sig-old", Pins: []string{"Be nice."}, Bookmarks: []config.Bookmark{{Title: "Docs", Link: "https://docs.k8s.io"}}},
+		},
+		Usergroups: []config.Usergroup{{Name: "oncall", LongName: "Oncall", Description: "The oncall", Members: []string{"katharine"}}},
+	}
+	if err := New(s.Client(slack.Config{}), c).Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	for _, method := range creations {
+		if calls := len(s.CallsTo(method)); calls != 1 {
+			t.Errorf("Expected 1 call to %s, got %d", method, calls)
+		}
+	}
+}
+
+func TestReconcileChannelModerators(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	alice := s.AddUser(slack.User{Name: "alice"})
+	bob := s.AddUser(slack.User{Name: "bob"})
+	carol := s.AddUser(slack.User{Name: "carol"})
+	sigTesting := s.AddConversation(slack.Conversation{Name: "sig-testing"})
+	unmanaged := s.AddConversation(slack.Conversation{Name: "sig-unmanaged"})
+	s.AddRoleAssignment(slack.RoleAssignment{RoleID: slack.RoleChannelManager, EntityID: sigTesting, UserID: carol})
+	s.AddRoleAssignment(slack.RoleAssignment{RoleID: slack.RoleChannelManager, EntityID: unmanaged, UserID: carol})
+
+	c := config.Config{
+		Users: map[string]string{"alice": alice, "bob": bob, "carol": carol},
+		Channels: []config.Channel{
+			{Name: "sig-testing", Moderators: []string{"alice", "bob"}},
+			{Name: "sig-unmanaged"},
+			{Name: "sig-new", Moderators: []string{"bob"}},
+		},
+	}
+	r := New(s.Client(slack.Config{}), c)
+	r.ChannelManagers = true
+	if err := r.Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	created, ok := s.ConversationByName("sig-new")
+	if !ok {
+		t.Fatalf("Expected sig-new to be created")
+	}
+	expected := []slack.RoleAssignment{
+		{RoleID: slack.RoleChannelManager, EntityID: sigTesting, UserID: alice},
+		{RoleID: slack.RoleChannelManager, EntityID: sigTesting, UserID: bob},
+		{RoleID: slack.RoleChannelManager, EntityID: unmanaged, UserID: carol},
+		{RoleID: slack.RoleChannelManager, EntityID: created.ID, UserID: bob},
+	}
+	var got []slack.RoleAssignment
+	for _, a := range s.RoleAssignments(slack.RoleChannelManager) {
+		a.DateCreate = 0
+		got = append(got, a)
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].EntityID < expected[j].EntityID || expected[i].EntityID == expected[j].EntityID && expected[i].UserID < expected[j].UserID
+	})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected channel managers %+v, got %+v", expected, got)
+	}
+
+	list, err := r.Moderators()
+	if err != nil {
+		t.Fatalf("Failed to get moderators: %v", err)
+	}
+	want := map[string][]string{sigTesting: {alice, bob}, created.ID: {bob}}
+	if !reflect.DeepEqual(list.Channels, want) {
+		t.Errorf("Expected moderators %v, got %v", want, list.Channels)
+	}
+}
+
+func TestReconcileRejectsUnknownModerators(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	s.AddConversation(slack.Conversation{Name: "sig-testing"})
+
+	c := config.Config{Channels: []config.Channel{{Name: "sig-testing", Moderators: []string{"mallory"}}}}
+	if err := New(s.Client(slack.Config{}), c).Reconcile(context.Background(), true); err == nil {
+		t.Errorf("Expected an unknown moderator to be a configuration error")
+	}
+	if calls := s.CallsTo("admin.roles.listAssignments"); len(calls) != 0 {
+		t.Errorf("Expected channel managers to be left alone unless asked, got %v", calls)
+	}
+}
+
+func TestReconcileLeavesOutUnresolvedUsers(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	alice := s.AddUser(slack.User{Name: "alice"})
+	sigTesting := s.AddConversation(slack.Conversation{Name: "sig-testing"})
+
+	// gone was in the config, but couldn't be resolved, so isn't in the users map any more.
+	c := config.Config{
+		Users: map[string]string{"alice": alice},
+		Channels: []config.Channel{
+			{Name: "sig-testing", Moderators: []string{"alice", "gone"}},
+			{Name: "sig-private", Private: true, Members: []string{"gone", "alice"}},
+		},
+		Usergroups: []config.Usergroup{{Name: "oncall", LongName: "Oncall", Description: "The oncall", Members: []string{"alice", "gone"}}},
+	}
+	r := New(s.Client(slack.Config{}), c)
+	r.UnresolvedUsers = map[string]bool{"gone": true}
+	if err := r.Reconcile(context.Background(), false); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	g, ok := s.UsergroupByHandle("oncall")
+	if !ok {
+		t.Fatalf("Expected the oncall usergroup to be created")
+	}
+	if !reflect.DeepEqual(g.Users, []string{alice}) {
+		t.Errorf("Expected the usergroup's members to be [%s], got %v", alice, g.Users)
+	}
+	if _, ok := s.ConversationByName("sig-private"); !ok {
+		t.Errorf("Expected sig-private to be created")
+	}
+	list, err := r.Moderators()
+	if err != nil {
+		t.Fatalf("Failed to get moderators: %v", err)
+	}
+	if want := map[string][]string{sigTesting: {alice}}; !reflect.DeepEqual(list.Channels, want) {
+		t.Errorf("Expected moderators %v, got %v", want, list.Channels)
+	}
+}
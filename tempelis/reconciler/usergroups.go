@@ -17,9 +17,9 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
 	"sort"
-	"strings"
 
 	"sigs.k8s.io/slack-infra/slack"
 )
@@ -49,12 +49,11 @@ func (r *Reconciler) reconcileUsergroups() ([]Action, []error) {
 			}
 
 			needsUpdate := false
-			targetIDs, err := r.config.NamesToIDs(g.Members)
+			targetIDs, err := r.usergroupMemberIDs(g.Name)
 			if err != nil {
 				errors = append(errors, fmt.Errorf("%s: %v", o.Name, err))
 				continue
 			}
-			sort.Strings(targetIDs)
 			sort.Strings(o.Users)
 
 			targetChannels, err := r.channels.namesToIDs(g.Channels)
@@ -77,7 +76,7 @@ func (r *Reconciler) reconcileUsergroups() ([]Action, []error) {
 				actions = append(actions, updateUsergroupMembersAction{id: o.ID, name: o.Handle, users: targetIDs})
 			}
 		} else {
-			targetIDs, err := r.config.NamesToIDs(g.Members)
+			targetIDs, err := r.usergroupMemberIDs(g.Name)
 			if err != nil {
 				errors = append(errors, fmt.Errorf("%s: %v", g.Name, err))
 				continue
@@ -95,6 +94,34 @@ func (r *Reconciler) reconcileUsergroups() ([]Action, []error) {
 	return actions, errors
 }
 
+// usergroupMemberIDs returns the sorted IDs of everyone in the named usergroup, including the
+// members of any usergroups it refers to, but not UnresolvedUsers. Slack doesn't allow empty
+// usergroups, so that is an error.
+func (r *Reconciler) usergroupMemberIDs(name string) ([]string, error) {
+	names, err := r.config.UsergroupMembers(name)
+	if err != nil {
+		return nil, err
+	}
+	all, err := r.userIDs(names)
+	if err != nil {
+		return nil, err
+	}
+	// Several names may refer to the same user.
+	unique := map[string]bool{}
+	ids := make([]string, 0, len(all))
+	for _, id := range all {
+		if !unique[id] {
+			unique[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("usergroup %s has no members once its exclusions are left out", name)
+	}
+	return ids, nil
+}
+
 func stringSlicesEqual(a []string, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -116,9 +143,9 @@ func (a deactivateUsergroupAction) Describe() string {
 	return fmt.Sprintf("Deactivate usergroup %s (%s)", a.handle, a.id)
 }
 
-func (a deactivateUsergroupAction) Perform(reconciler *Reconciler) error {
-	if err := reconciler.slack.CallMethod("usergroups.disable", map[string]string{"usergroup": a.id}, nil); err != nil {
-		return fmt.Errorf("failed to disable usergroup %s (%s): %v", a.handle, a.id, err)
+func (a deactivateUsergroupAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.DisableUsergroup(ctx, a.id); err != nil {
+		return fmt.Errorf("failed to disable usergroup %s (%s): %w", a.handle, a.id, err)
 	}
 	return nil
 }
@@ -132,9 +159,9 @@ func (a reactivateUsergroupAction) Describe() string {
 	return fmt.Sprintf("Reactivate usergroup: %s", a.handle)
 }
 
-func (a reactivateUsergroupAction) Perform(reconciler *Reconciler) error {
-	if err := reconciler.slack.CallMethod("usergroups.enable", map[string]string{"usergroup": a.id}, nil); err != nil {
-		return fmt.Errorf("failed to reactivate usergroup %s (%s): %v", a.handle, a.id, err)
+func (a reactivateUsergroupAction) Perform(ctx context.Context, reconciler *Reconciler) error {
+	if err := reconciler.slack.EnableUsergroup(ctx, a.id); err != nil {
+		return fmt.Errorf("failed to reactivate usergroup %s (%s): %w", a.handle, a.id, err)
 	}
 	return nil
 }
@@ -156,7 +183,7 @@ func (a updateUsergroupAction) Describe() string {
 	return fmt.Sprintf("%s usergroup %s (%s): name = %q, description = %q, channels = %v", verb, a.handle, a.id, a.name, a.description, a.channelNames)
 }
 
-func (a updateUsergroupAction) Perform(reconciler *Reconciler) error {
+func (a updateUsergroupAction) Perform(ctx context.Context, reconciler *Reconciler) error {
 	channelIDs, err := reconciler.channels.namesToIDs(a.channelNames)
 	if err != nil {
 		return fmt.Errorf("couldn't find channel IDs for usergroup %s: %v", a.name, err)
@@ -167,30 +194,27 @@ func (a updateUsergroupAction) Perform(reconciler *Reconciler) error {
 		}
 	}
 
-	req := map[string]string{
-		"usergroup":   a.id,
-		"channels":    strings.Join(channelIDs, ","),
-		"description": a.description,
-		"name":        a.name,
-		"handle":      a.handle,
+	req := slack.UsergroupRequest{
+		ID:          a.id,
+		Name:        a.name,
+		Handle:      a.handle,
+		Description: a.description,
+		Channels:    channelIDs,
 	}
 
-	action := "usergroups.update"
-	if a.create {
-		action = "usergroups.create"
-	}
-
-	ret := struct {
-		Usergroup slack.Subteam `json:"usergroup"`
-	}{}
-
-	if err := reconciler.slack.CallMethod(action, req, &ret); err != nil {
-		return fmt.Errorf("failed to update usergroup %s (%s): %v", a.name, a.id, err)
+	if !a.create {
+		if _, err := reconciler.slack.UpdateUsergroup(ctx, req); err != nil {
+			return fmt.Errorf("failed to update usergroup %s (%s): %w", a.name, a.id, err)
+		}
+		return nil
 	}
-	if a.create {
-		reconciler.groups.byHandle[ret.Usergroup.Handle] = &ret.Usergroup
-		reconciler.groups.byID[ret.Usergroup.ID] = &ret.Usergroup
+	// Not wrapped, so that a usergroup Slack created despite the call failing isn't created again.
+	g, err := reconciler.slack.CreateUsergroup(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to create usergroup %s: %v", a.name, err)
 	}
+	reconciler.groups.byHandle[g.Handle] = &g
+	reconciler.groups.byID[g.ID] = &g
 	return nil
 }
 
@@ -204,7 +228,7 @@ func (a updateUsergroupMembersAction) Describe() string {
 	return fmt.Sprintf("Set members of usergroup %s (%s) to %v", a.name, a.id, a.users)
 }
 
-func (a updateUsergroupMembersAction) Perform(reconciler *Reconciler) error {
+func (a updateUsergroupMembersAction) Perform(ctx context.Context, reconciler *Reconciler) error {
 	if a.id == "" {
 		if a.name == "" {
 			return fmt.Errorf("internal error: updateUsergroupMembersAction: at least one of name and id must be specified")
@@ -215,8 +239,8 @@ func (a updateUsergroupMembersAction) Perform(reconciler *Reconciler) error {
 			return fmt.Errorf("couldn't find the ID for the group %q", a.name)
 		}
 	}
-	if err := reconciler.slack.CallMethod("usergroups.users.update", map[string]string{"usergroup": a.id, "users": strings.Join(a.users, ",")}, nil); err != nil {
-		return fmt.Errorf("failed to update members of usergroup %s: %v", a.id, err)
+	if err := reconciler.slack.UpdateUsergroupUsers(ctx, a.id, a.users); err != nil {
+		return fmt.Errorf("failed to update members of usergroup %s: %w", a.id, err)
 	}
 	return nil
 }
@@ -87,6 +87,44 @@ func TestReconcileUsergroups(t *testing.T) {
 			name:        "don't try deleting and already-deleted group",
 			priorGroups: []slack.Subteam{{Handle: "pony-fans", ID: "S12345678", Name: "Pony Fans", Description: "Fans of ponies", Users: []string{"U12345678"}, DeleteTime: 10000}},
 		},
+		{
+			name:        "flattening nested groups",
+			priorGroups: []slack.Subteam{{Handle: "pony-fans", ID: "S12345678", Name: "Pony Fans", Description: "Fans of ponies", Users: []string{"U12345678"}}},
+			newGroups: []config.Usergroup{
+				{Name: "pony-fans", LongName: "Pony Fans", Description: "Fans of ponies", Members: []string{"@horse-fans", "Katharine"}},
+				{Name: "horse-fans", LongName: "Horse Fans", Description: "Fans of horses", Members: []string{"bentheelder", "Katharine"}},
+			},
+			expectedActions: []Action{
+				updateUsergroupMembersAction{id: "S12345678", name: "pony-fans", users: []string{"U11111111", "U12345678"}},
+				updateUsergroupAction{handle: "horse-fans", name: "Horse Fans", description: "Fans of horses", create: true},
+				updateUsergroupMembersAction{name: "horse-fans", users: []string{"U11111111", "U12345678"}},
+			},
+		},
+		{
+			name: "excluding members of nested groups",
+			newGroups: []config.Usergroup{
+				{Name: "pony-fans", LongName: "Pony Fans", Description: "Fans of ponies", Members: []string{"@horse-fans"}, Exclude: []string{"Katharine"}},
+				{Name: "horse-fans", LongName: "Horse Fans", Description: "Fans of horses", Members: []string{"bentheelder", "Katharine"}},
+			},
+			expectedActions: []Action{
+				updateUsergroupAction{handle: "pony-fans", name: "Pony Fans", description: "Fans of ponies", create: true},
+				updateUsergroupMembersAction{name: "pony-fans", users: []string{"U11111111"}},
+				updateUsergroupAction{handle: "horse-fans", name: "Horse Fans", description: "Fans of horses", create: true},
+				updateUsergroupMembersAction{name: "horse-fans", users: []string{"U11111111", "U12345678"}},
+			},
+		},
+		{
+			name: "excluding everyone",
+			newGroups: []config.Usergroup{
+				{Name: "pony-fans", LongName: "Pony Fans", Description: "Fans of ponies", Members: []string{"Katharine"}, Exclude: []string{"@horse-fans"}},
+				{Name: "horse-fans", LongName: "Horse Fans", Description: "Fans of horses", Members: []string{"Katharine"}},
+			},
+			expectedActions: []Action{
+				updateUsergroupAction{handle: "horse-fans", name: "Horse Fans", description: "Fans of horses", create: true},
+				updateUsergroupMembersAction{name: "horse-fans", users: []string{"U12345678"}},
+			},
+			expectedErrCount: 1,
+		},
 	}
 
 	for _, tc := range tests {
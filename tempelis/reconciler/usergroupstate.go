@@ -17,6 +17,7 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
 	"sigs.k8s.io/slack-infra/slack"
 )
@@ -26,18 +27,16 @@ type usergroupState struct {
 	byID     map[string]*slack.Subteam
 }
 
-func (u *usergroupState) init(s *slack.Client) error {
+func (u *usergroupState) init(ctx context.Context, s *slack.Client) error {
 	u.byHandle = map[string]*slack.Subteam{}
 	u.byID = map[string]*slack.Subteam{}
 
-	result := struct {
-		Usergroups []slack.Subteam `json:"usergroups"`
-	}{}
-	if err := s.CallOldMethod("usergroups.list", map[string]string{"include_users": "true", "include_disabled": "true"}, &result); err != nil {
+	usergroups, err := s.ListUsergroups(ctx, slack.ListUsergroupsRequest{IncludeUsers: true, IncludeDisabled: true})
+	if err != nil {
 		return fmt.Errorf("couldn't get usergroup list: %v", err)
 	}
 
-	for _, ug := range result.Usergroups {
+	for _, ug := range usergroups {
 		ug2 := ug
 		u.byHandle[ug.Handle] = &ug2
 		u.byID[ug.ID] = &ug2
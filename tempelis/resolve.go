@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/tempelis/users"
+	"sigs.k8s.io/yaml"
+)
+
+// loadIdentities loads the identity sources given as a comma-separated list of name=path pairs,
+// each naming a yaml file that maps handles to email addresses.
+func loadIdentities(spec string) (map[string]users.IdentitySource, error) {
+	sources := map[string]users.IdentitySource{}
+	if spec == "" {
+		return sources, nil
+	}
+	for _, s := range strings.Split(spec, ",") {
+		name, path, ok := strings.Cut(s, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("identity sources must look like name=path, not %q", s)
+		}
+		source, err := users.LoadMapSource(path)
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = source
+	}
+	return sources, nil
+}
+
+// configFiles returns the config files at path, which is a file or a directory of them.
+func configFiles(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{path}, nil
+	}
+	return doublestar.Glob(filepath.Join(path, "**/*.yaml"))
+}
+
+// resolveUsers implements "tempelis users resolve": it resolves the users map, writes the Slack
+// IDs of entries given by email address or identity source back to the config files, and reports
+// entries that don't refer to an active account. It returns the exit code.
+func resolveUsers(args []string) int {
+	fs := flag.NewFlagSet("users resolve", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to a configuration file, or directory of files")
+	authConfig := fs.String("auth", "", "path to slack auth")
+	identities := fs.String("identities", "", "comma-separated identity sources, as name=path to a yaml file mapping handles to email addresses")
+	dryRun := fs.Bool("dry-run", false, "only report what would change, without writing any files")
+	_ = fs.Parse(args)
+
+	sources, err := loadIdentities(*identities)
+	if err != nil {
+		log.Printf("Failed to load identity sources: %v\n", err)
+		return 1
+	}
+	files, err := configFiles(*configPath)
+	if err != nil {
+		log.Printf("Failed to find config files: %v\n", err)
+		return 1
+	}
+	all := map[string]string{}
+	contents := map[string][]byte{}
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			log.Printf("Failed to read %s: %v\n", f, err)
+			return 1
+		}
+		var c struct {
+			Users map[string]string `json:"users"`
+		}
+		if err := yaml.Unmarshal(content, &c); err != nil {
+			log.Printf("Failed to parse %s: %v\n", f, err)
+			return 1
+		}
+		if len(c.Users) == 0 {
+			continue
+		}
+		contents[f] = content
+		for name, value := range c.Users {
+			if _, ok := all[name]; ok {
+				log.Printf("User %s is defined more than once.\n", name)
+				return 1
+			}
+			all[name] = value
+		}
+	}
+
+	sc, err := slack.ConfigLoader{Path: *authConfig, EnvPrefix: "SLACK_"}.Load()
+	if err != nil {
+		log.Printf("Failed to load slack auth config: %v.\n", err)
+		return 1
+	}
+	resolver := users.Resolver{Slack: slack.New(sc), Sources: sources, CheckIDs: true}
+	results, err := resolver.Resolve(context.Background(), all)
+	if err != nil {
+		log.Printf("Failed to resolve users: %v\n", err)
+		return 1
+	}
+
+	problems := 0
+	for _, r := range results {
+		if r.Problem != "" {
+			log.Printf("User %s (%s): %s.\n", r.Name, r.Value, r.Problem)
+			problems++
+		}
+	}
+	ids := users.IDs(results)
+	for _, f := range files {
+		content, ok := contents[f]
+		if !ok {
+			continue
+		}
+		updated, changed := users.Rewrite(content, ids)
+		if len(changed) == 0 {
+			continue
+		}
+		log.Printf("Resolved %s in %s.\n", strings.Join(changed, ", "), f)
+		if *dryRun {
+			continue
+		}
+		if err := os.WriteFile(f, updated, 0644); err != nil {
+			log.Printf("Failed to write %s: %v\n", f, err)
+			return 1
+		}
+	}
+	if problems > 0 {
+		log.Printf("%d users don't refer to active Slack accounts.\n", problems)
+		return 1
+	}
+	return 0
+}
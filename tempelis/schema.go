@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"sigs.k8s.io/slack-infra/tempelis/config"
+)
+
+// printSchema implements "tempelis schema": it writes the JSON Schema for config files to stdout.
+// It returns the exit code.
+func printSchema() int {
+	b, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to generate schema: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(b))
+	return 0
+}
+
+// checkSchema checks every config file under the given paths against the JSON Schema, logging
+// each problem it finds. It returns false if there were any.
+func checkSchema(paths ...string) bool {
+	ok := true
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		files, err := configFiles(p)
+		if err != nil {
+			log.Printf("Failed to list config files in %s: %v\n", p, err)
+			ok = false
+			continue
+		}
+		for _, f := range files {
+			content, err := os.ReadFile(f)
+			if err != nil {
+				log.Printf("Failed to read %s: %v\n", f, err)
+				ok = false
+				continue
+			}
+			for _, err := range config.ValidateSchema(content) {
+				log.Printf("%s: %v\n", f, err)
+				ok = false
+			}
+		}
+	}
+	return ok
+}
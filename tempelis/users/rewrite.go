@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package users
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	// usersKey starts the top-level users block of a config file.
+	usersKey = regexp.MustCompile(`^users:\s*(#.*)?$`)
+	// userEntry is an entry in the users block: indentation, name, value and an optional comment.
+	userEntry = regexp.MustCompile(`^(\s+)(["']?)([^"'#:]+)(["']?):(\s*)(["']?)([^"'#\s]*)(["']?)(\s*#.*)?$`)
+)
+
+// Rewrite replaces the values in the top-level users block of a yaml config file with the Slack
+// IDs in ids, keyed by name, leaving everything else as it was. The old value is kept as a
+// comment, unless the entry already had one. It returns the new content, and the names whose
+// entries were changed. Only block-style users maps are understood.
+func Rewrite(content []byte, ids map[string]string) ([]byte, []string) {
+	lines := strings.SplitAfter(string(content), "\n")
+	var changed []string
+	inUsers := false
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if usersKey.MatchString(trimmed) {
+			inUsers = true
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(strings.TrimSpace(trimmed), "#") {
+			continue
+		}
+		if trimmed[0] != ' ' && trimmed[0] != '\t' {
+			inUsers = false
+			continue
+		}
+		if !inUsers {
+			continue
+		}
+		m := userEntry.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		name, value, comment := strings.TrimSpace(m[3]), m[7], m[9]
+		id, ok := ids[name]
+		if !ok || id == value {
+			continue
+		}
+		space := m[5]
+		if space == "" {
+			space = " "
+		}
+		if comment == "" {
+			comment = " # " + value
+		}
+		lines[i] = m[1] + m[2] + m[3] + m[4] + ":" + space + id + comment + line[len(trimmed):]
+		changed = append(changed, name)
+	}
+	var b bytes.Buffer
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+	return b.Bytes(), changed
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package users resolves the entries of Tempelis' users map, which may be email addresses or
+// handles in an identity source rather than Slack IDs, to the Slack accounts they refer to.
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/tempelis/config"
+	"sigs.k8s.io/yaml"
+)
+
+// IdentitySource knows the email addresses of people by some other handle, such as their GitHub
+// username.
+type IdentitySource interface {
+	// Email returns the email address of the given handle, or false if the handle is unknown.
+	Email(handle string) (string, bool)
+}
+
+// MapSource is an IdentitySource backed by a map of handles to email addresses. Handles are
+// matched case-insensitively, as GitHub usernames are.
+type MapSource map[string]string
+
+func (m MapSource) Email(handle string) (string, bool) {
+	if email, ok := m[handle]; ok {
+		return email, true
+	}
+	for h, email := range m {
+		if strings.EqualFold(h, handle) {
+			return email, true
+		}
+	}
+	return "", false
+}
+
+// LoadMapSource loads a MapSource from a yaml file mapping handles to email addresses.
+func LoadMapSource(path string) (MapSource, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identities: %v", err)
+	}
+	m := MapSource{}
+	if err := yaml.UnmarshalStrict(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse identities in %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Result is what an entry in the users map resolved to.
+type Result struct {
+	Name  string
+	Value string
+	// ID is the Slack ID of the account the entry refers to, if one was found.
+	ID string
+	// Problem explains why the entry doesn't refer to an active account, if it doesn't.
+	Problem string
+}
+
+// Resolver resolves entries in the users map to Slack IDs.
+type Resolver struct {
+	Slack *slack.Client
+	// Sources are the identity sources that entries like "github:katharine" can use, by name.
+	Sources map[string]IdentitySource
+	// CheckIDs also checks that entries that are already Slack IDs belong to active accounts,
+	// which requires listing every user in the workspace.
+	CheckIDs bool
+}
+
+// Resolve resolves every entry in users, returning the results sorted by name. Entries that
+// can't be resolved have a Problem; an error is only returned if Slack couldn't be asked.
+func (r *Resolver) Resolve(ctx context.Context, users map[string]string) ([]Result, error) {
+	var accounts map[string]slack.User
+	if r.CheckIDs {
+		all, err := r.Slack.ListUsers().Collect(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %v", err)
+		}
+		accounts = map[string]slack.User{}
+		for _, u := range all {
+			accounts[u.ID] = u
+		}
+	}
+
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		result := Result{Name: name, Value: users[name]}
+		ref, err := config.ParseUserRef(result.Value)
+		if err != nil {
+			result.Problem = err.Error()
+			results = append(results, result)
+			continue
+		}
+		switch {
+		case ref.ID != "":
+			result.ID = ref.ID
+			if accounts != nil {
+				if u, ok := accounts[ref.ID]; !ok {
+					result.Problem = fmt.Sprintf("no Slack account has ID %s", ref.ID)
+				} else if u.Deleted {
+					result.Problem = fmt.Sprintf("Slack account %s (%s) is deactivated", ref.ID, u.Name)
+				}
+			}
+		case ref.Source != "":
+			source, ok := r.Sources[ref.Source]
+			if !ok {
+				result.Problem = fmt.Sprintf("unknown identity source %q", ref.Source)
+				break
+			}
+			email, ok := source.Email(ref.Handle)
+			if !ok {
+				result.Problem = fmt.Sprintf("%s has no email address for %s", ref.Source, ref.Handle)
+				break
+			}
+			err = r.lookup(ctx, email, &result)
+		default:
+			err = r.lookup(ctx, ref.Email, &result)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// lookup sets the result's ID to that of the account with the given email address.
+func (r *Resolver) lookup(ctx context.Context, email string, result *Result) error {
+	u, err := r.Slack.LookupUserByEmail(ctx, email)
+	if errors.Is(err, slack.ErrUsersNotFound) || errors.Is(err, slack.ErrUserNotFound) {
+		result.Problem = fmt.Sprintf("no active Slack account has email address %s", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result.ID = u.ID
+	if u.Deleted {
+		result.Problem = fmt.Sprintf("Slack account %s (%s) with email address %s is deactivated", u.ID, u.Name, email)
+	}
+	return nil
+}
+
+// IDs returns a users map with the Slack ID of every entry that resolved to an active account.
+// Entries with problems are left out, so that using them is an error.
+func IDs(results []Result) map[string]string {
+	resolved := make(map[string]string, len(results))
+	for _, r := range results {
+		if r.Problem == "" && r.ID != "" {
+			resolved[r.Name] = r.ID
+		}
+	}
+	return resolved
+}
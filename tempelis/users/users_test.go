@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package users
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/slacktest"
+)
+
+func TestResolve(t *testing.T) {
+	s := slacktest.NewServer()
+	defer s.Close()
+	newUser := func(name, email string, deleted bool) string {
+		u := slack.User{Name: name, Deleted: deleted}
+		u.Profile.Email = email
+		return s.AddUser(u)
+	}
+	alice := newUser("alice", "alice@example.com", false)
+	bob := newUser("bob", "bob@example.com", false)
+	carol := newUser("carol", "carol@example.com", true)
+
+	path := filepath.Join(t.TempDir(), "github.yaml")
+	if err := os.WriteFile(path, []byte("Bob: bob@example.com\nmallory: mallory@example.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write identities: %v", err)
+	}
+	github, err := LoadMapSource(path)
+	if err != nil {
+		t.Fatalf("Failed to load identities: %v", err)
+	}
+
+	r := Resolver{Slack: s.Client(slack.Config{}), Sources: map[string]IdentitySource{"github": github}, CheckIDs: true}
+	results, err := r.Resolve(context.Background(), map[string]string{
+		"alice":   "alice@example.com",
+		"bob":     "github:bob",
+		"carol":   carol,
+		"dave":    "U99999999",
+		"erin":    "erin@example.com",
+		"mallory": "github:mallory",
+		"trent":   "gitlab:trent",
+		"victor":  alice,
+	})
+	if err != nil {
+		t.Fatalf("Failed to resolve users: %v", err)
+	}
+
+	problems := map[string]string{}
+	for _, r := range results {
+		if r.Problem != "" {
+			problems[r.Name] = r.Problem
+		}
+	}
+	for name, want := range map[string]string{"carol": "deactivated", "dave": "no Slack account", "erin": "no active Slack account", "mallory": "no active Slack account", "trent": "unknown identity source"} {
+		if !strings.Contains(problems[name], want) {
+			t.Errorf("Expected %s's problem to mention %q, got %q", name, want, problems[name])
+		}
+	}
+	expected := map[string]string{"alice": alice, "bob": bob, "victor": alice}
+	if ids := IDs(results); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected IDs %v, got %v", expected, ids)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	content := `# People.
+users:
+  alice: alice@example.com
+  "bob": github:bob # Bob's GitHub
+  carol: U00000003
+
+channels:
+- name: general
+  alice: alice@example.com
+`
+	expected := `# People.
+users:
+  alice: U00000001 # alice@example.com
+  "bob": U00000002 # Bob's GitHub
+  carol: U00000003
+
+channels:
+- name: general
+  alice: alice@example.com
+`
+	updated, changed := Rewrite([]byte(content), map[string]string{"alice": "U00000001", "bob": "U00000002", "carol": "U00000003"})
+	if string(updated) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, updated)
+	}
+	if !reflect.DeepEqual(changed, []string{"alice", "bob"}) {
+		t.Errorf("Expected alice and bob to be changed, got %v", changed)
+	}
+}